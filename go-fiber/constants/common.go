@@ -9,4 +9,92 @@ const (
 
 	ENUM_PAGINATION_LIMIT = 10
 	ENUM_PAGINATION_PAGE  = 1
+
+	ENUM_TRANSACTION_STATUS_PENDING   = "pending"
+	ENUM_TRANSACTION_STATUS_PAID      = "paid"
+	ENUM_TRANSACTION_STATUS_CANCELLED = "cancelled"
+	ENUM_TRANSACTION_STATUS_REFUNDED  = "refunded"
+	ENUM_TRANSACTION_STATUS_EXPIRED   = "expired"
+	ENUM_TRANSACTION_STATUS_FAILED    = "failed"
+
+	ENUM_PAYMENT_PROVIDER_MOCK = "mock"
+
+	ENUM_PAYMENT_STATUS_PENDING  = "pending"
+	ENUM_PAYMENT_STATUS_PAID     = "paid"
+	ENUM_PAYMENT_STATUS_FAILED   = "failed"
+	ENUM_PAYMENT_STATUS_REFUNDED = "refunded"
+
+	ENUM_REFUND_STATUS_PENDING = "pending"
+	ENUM_REFUND_STATUS_SENT    = "sent"
+	ENUM_REFUND_STATUS_FAILED  = "failed"
+
+	ENUM_TICKET_STATUS_ACTIVE = "active"
+	ENUM_TICKET_STATUS_VOID   = "void"
+	ENUM_TICKET_STATUS_USED   = "used"
+
+	ENUM_CHECK_IN_RESULT_ACCEPTED = "accepted"
+	ENUM_CHECK_IN_RESULT_REJECTED = "rejected"
+
+	ENUM_TICKET_TIER_DEFAULT = "Regular"
+
+	ENUM_VOUCHER_TYPE_PERCENTAGE = "percentage"
+	ENUM_VOUCHER_TYPE_FIXED      = "fixed"
+
+	ENUM_CURRENCY_DEFAULT = "IDR"
+
+	ENUM_SEAT_HOLD_STATUS_ACTIVE    = "active"
+	ENUM_SEAT_HOLD_STATUS_CONVERTED = "converted"
+	ENUM_SEAT_HOLD_STATUS_RELEASED  = "released"
+	ENUM_SEAT_HOLD_STATUS_EXPIRED   = "expired"
+
+	ENUM_SEAT_HOLD_MINUTES_DEFAULT     = 15
+	ENUM_HOLD_SWEEP_INTERVAL_DEFAULT   = 30
+	ENUM_HOLD_SWEEP_BATCH_SIZE_DEFAULT = 100
+
+	ENUM_WAITLIST_STATUS_WAITING   = "waiting"
+	ENUM_WAITLIST_STATUS_OFFERED   = "offered"
+	ENUM_WAITLIST_STATUS_CLAIMED   = "claimed"
+	ENUM_WAITLIST_STATUS_EXPIRED   = "expired"
+	ENUM_WAITLIST_STATUS_CANCELLED = "cancelled"
+
+	ENUM_WAITLIST_OFFER_MINUTES_DEFAULT = 30
+
+	ENUM_TICKET_TRANSFER_STATUS_PENDING   = "pending"
+	ENUM_TICKET_TRANSFER_STATUS_ACCEPTED  = "accepted"
+	ENUM_TICKET_TRANSFER_STATUS_DECLINED  = "declined"
+	ENUM_TICKET_TRANSFER_STATUS_CANCELLED = "cancelled"
+
+	ENUM_PURCHASE_LIMIT_SCOPE_ORDER = "order"
+	ENUM_PURCHASE_LIMIT_SCOPE_USER  = "user"
+
+	ENUM_REPORT_GROUP_EVENT = "event"
+	ENUM_REPORT_GROUP_TIER  = "tier"
+	ENUM_REPORT_GROUP_DAY   = "day"
+
+	ENUM_REPORT_TIMEZONE_DEFAULT = "UTC"
+
+	ENUM_EXPORT_BATCH_SIZE = 500
+
+	ENUM_PAGINATION_MAX_PER_PAGE = 100
+
+	ENUM_EVENT_STATUS_DRAFT     = "draft"
+	ENUM_EVENT_STATUS_PUBLISHED = "published"
+	ENUM_EVENT_STATUS_POSTPONED = "postponed"
+	ENUM_EVENT_STATUS_CANCELLED = "cancelled"
+
+	ENUM_EVENT_WHEN_UPCOMING = "upcoming"
+	ENUM_EVENT_WHEN_ONGOING  = "ongoing"
+	ENUM_EVENT_WHEN_PAST     = "past"
+
+	ENUM_EVENT_TIMEZONE_DEFAULT = "UTC"
+
+	ENUM_EVENT_SORT_PRICE      = "price"
+	ENUM_EVENT_SORT_DATE       = "date"
+	ENUM_EVENT_SORT_POPULARITY = "popularity"
+
+	ENUM_EVENT_SEARCH_CONFIG = "simple"
+
+	ENUM_ATTENDEE_CHECKED_IN     = "checked_in"
+	ENUM_ATTENDEE_PARTIAL        = "partially_checked_in"
+	ENUM_ATTENDEE_NOT_CHECKED_IN = "not_checked_in"
 )
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,19 +17,23 @@ type (
 		GetAllEvent(ctx *fiber.Ctx) error
 		GetEventById(ctx *fiber.Ctx) error
 		Update(ctx *fiber.Ctx) error
+		UpdateStatus(ctx *fiber.Ctx) error
+		Cancel(ctx *fiber.Ctx) error
 		Delete(ctx *fiber.Ctx) error
 	}
 
 	eventController struct {
-		eventService service.EventService
-		userService  service.UserService
+		eventService       service.EventService
+		userService        service.UserService
+		transactionService service.TransactionService
 	}
 )
 
-func NewEventController(eventService service.EventService, userService service.UserService) EventController {
+func NewEventController(eventService service.EventService, userService service.UserService, transactionService service.TransactionService) EventController {
 	return &eventController{
-		eventService: eventService,
-		userService:  userService,
+		eventService:       eventService,
+		userService:        userService,
+		transactionService: transactionService,
 	}
 }
 
@@ -72,6 +77,28 @@ func (c *eventController) CreateEvent(ctx *fiber.Ctx) error {
 			Price:       result.Price,
 			Capacity:    result.Capacity,
 			Availabilty: result.Availabilty,
+			Currency:    result.Currency,
+			Tiers:       result.Tiers,
+
+			Status:      result.Status,
+			PublishedAt: result.PublishedAt,
+			CancelledAt: result.CancelledAt,
+
+			StartsAt:    result.StartsAt,
+			EndsAt:      result.EndsAt,
+			Timezone:    result.Timezone,
+			Venue:       result.Venue,
+			Address:     result.Address,
+			Description: result.Description,
+			MeetingURL:  result.MeetingURL,
+
+			RefundDeadline:   result.RefundDeadline,
+			RefundPercentage: result.RefundPercentage,
+
+			TransfersDisabled: result.TransfersDisabled,
+
+			MaxTicketsPerOrder: result.MaxTicketsPerOrder,
+			MaxTicketsPerUser:  result.MaxTicketsPerUser,
 		},
 		AuthorName: author.Name,
 	})
@@ -80,13 +107,22 @@ func (c *eventController) CreateEvent(ctx *fiber.Ctx) error {
 }
 
 func (c *eventController) GetAllEvent(ctx *fiber.Ctx) error {
-	var req dto.PaginationRequest
-	// Parse request body into PaginationRequest DTO
-	if err := ctx.BodyParser(&req); err != nil {
+	var req dto.EventPaginationRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if err := req.Validate(); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
+	// Only admins see events that are not on sale.
+	if _, err := authorizeAdmin(ctx, c.userService); err != nil {
+		req.PublishedOnly = true
+	}
+
 	// Get paginated list of events from service
 	result, err := c.eventService.GetAllEventWithPagination(ctx.Context(), req)
 	if err != nil {
@@ -94,21 +130,12 @@ func (c *eventController) GetAllEvent(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	var eventsWithAuthorName []dto.EventResponse
-	for _, event := range result.Data {
-		author, err := c.userService.GetUserById(ctx.Context(), event.AuthorID)
-		if err != nil {
-			author.Name = "Unknown"
-		}
-
-		event.AuthorName = author.Name
-		eventsWithAuthorName = append(eventsWithAuthorName, event)
-	}
+	result.SetLinks(ctx.BaseURL() + ctx.OriginalURL())
 
 	resp := utils.Response{
 		Status:  true,
 		Message: dto.MESSAGE_SUCCESS_GET_LIST_EVENT,
-		Data:    eventsWithAuthorName,
+		Data:    result.Data,
 		Meta:    result.PaginationResponse,
 	}
 
@@ -128,6 +155,14 @@ func (c *eventController) GetEventById(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
+	// Events that are not published look like they do not exist to users.
+	if result.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+		if _, err := authorizeAdmin(ctx, c.userService); err != nil {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_EVENT_BY_ID, dto.ErrGetEventById.Error(), nil)
+			return ctx.Status(http.StatusBadRequest).JSON(res)
+		}
+	}
+
 	author, err := c.userService.GetUserById(ctx.Context(), result.AuthorID)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_AUTHOR, err.Error(), nil)
@@ -148,6 +183,28 @@ func (c *eventController) GetEventById(ctx *fiber.Ctx) error {
 				Price:       result.Price,
 				Capacity:    result.Capacity,
 				Availabilty: result.Availabilty,
+				Currency:    result.Currency,
+				Tiers:       result.Tiers,
+
+				Status:      result.Status,
+				PublishedAt: result.PublishedAt,
+				CancelledAt: result.CancelledAt,
+
+				StartsAt:    result.StartsAt,
+				EndsAt:      result.EndsAt,
+				Timezone:    result.Timezone,
+				Venue:       result.Venue,
+				Address:     result.Address,
+				Description: result.Description,
+				MeetingURL:  result.MeetingURL,
+
+				RefundDeadline:   result.RefundDeadline,
+				RefundPercentage: result.RefundPercentage,
+
+				TransfersDisabled: result.TransfersDisabled,
+
+				MaxTicketsPerOrder: result.MaxTicketsPerOrder,
+				MaxTicketsPerUser:  result.MaxTicketsPerUser,
 			},
 			AuthorName: author.Name,
 		},
@@ -169,6 +226,11 @@ func (c *eventController) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
+	if status, err := c.authorizeOrganizer(ctx, req.ID); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
 	result, err := c.eventService.UpdateEvent(ctx.Context(), req, req.ID)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_EVENT, err.Error(), nil)
@@ -179,6 +241,52 @@ func (c *eventController) Update(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(res)
 }
 
+func (c *eventController) UpdateStatus(ctx *fiber.Ctx) error {
+	var req dto.EventStatusUpdateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.eventService.UpdateEventStatus(ctx.Context(), req, ctx.Params("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_EVENT_STATUS, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_EVENT_STATUS, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *eventController) Cancel(ctx *fiber.Ctx) error {
+	var req dto.EventCancelRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.transactionService.CancelEvent(ctx.Context(), req, ctx.Params("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_EVENT, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CANCEL_EVENT, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
 func (c *eventController) Delete(ctx *fiber.Ctx) error {
 	var req dto.EventByIdRequest
 
@@ -200,3 +308,29 @@ func (c *eventController) Delete(ctx *fiber.Ctx) error {
 	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_EVENT, nil)
 	return ctx.Status(http.StatusOK).JSON(res)
 }
+
+// authorizeOrganizer lets admins and the author of an event through,
+// returning the HTTP status to answer with otherwise.
+func (c *eventController) authorizeOrganizer(ctx *fiber.Ctx, eventId string) (int, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return http.StatusOK, nil
+	}
+
+	event, err := c.eventService.GetEventById(ctx.Context(), eventId)
+	if err != nil {
+		return http.StatusNotFound, dto.ErrEventNotFound
+	}
+
+	if event.AuthorID != userId {
+		return http.StatusForbidden, errors.New(dto.MESSAGE_FAILED_DENIED_ACCESS)
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	ExportController interface {
+		ExportUsers(ctx *fiber.Ctx) error
+		ExportEvents(ctx *fiber.Ctx) error
+		ExportTransactions(ctx *fiber.Ctx) error
+		ExportAttendees(ctx *fiber.Ctx) error
+	}
+
+	exportController struct {
+		exportService service.ExportService
+		userService   service.UserService
+	}
+)
+
+func NewExportController(exportService service.ExportService, userService service.UserService) ExportController {
+	return &exportController{
+		exportService: exportService,
+		userService:   userService,
+	}
+}
+
+func (c *exportController) ExportUsers(ctx *fiber.Ctx) error {
+	var req dto.UserExportRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	export, err := c.exportService.ExportUsers(ctx.Context(), req)
+	return c.stream(ctx, "users", req.Format, export, err)
+}
+
+// ExportEvents exports the events matching the filters of the event list:
+// any event for admins, only their own events for everyone else.
+func (c *exportController) ExportEvents(ctx *fiber.Ctx) error {
+	var req dto.EventExportRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	authorId, err := c.authorScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+	if authorId != "" {
+		req.AuthorID = authorId
+	}
+
+	export, err := c.exportService.ExportEvents(ctx.Context(), req)
+	return c.stream(ctx, "events", req.Format, export, err)
+}
+
+// ExportTransactions exports what the transaction list shows the caller:
+// every transaction for admins, those of their own events for organizers.
+func (c *exportController) ExportTransactions(ctx *fiber.Ctx) error {
+	var req dto.TransactionExportRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	authorId, err := c.authorScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+	req.AuthorID = authorId
+
+	export, err := c.exportService.ExportTransactions(ctx.Context(), req)
+	return c.stream(ctx, "transactions", req.Format, export, err)
+}
+
+func (c *exportController) ExportAttendees(ctx *fiber.Ctx) error {
+	var req dto.ExportRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	authorId, err := c.authorScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+	req.AuthorID = authorId
+
+	eventId := ctx.Params("id")
+	export, err := c.exportService.ExportAttendees(ctx.Context(), eventId, req)
+	return c.stream(ctx, "attendees-"+eventId, req.Format, export, err)
+}
+
+// stream answers with the error of a rejected export, or streams the export
+// as a download. Once streaming started the status is already sent, so
+// later errors can only be logged.
+func (c *exportController) stream(ctx *fiber.Ctx, name string, format string, export service.ExportFunc, err error) error {
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_EXPORT, err.Error(), nil)
+		if errors.Is(err, dto.ErrEventNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(res)
+		}
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	contentType, extension := utils.ExportContentType(format)
+	filename := fmt.Sprintf("%s-%s%s", name, time.Now().Format("20060102"), extension)
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export(context.Background(), w); err != nil {
+			log.Printf("failed to export %s: %v", name, err)
+		}
+	})
+
+	return nil
+}
+
+// authorScope returns the caller's id for regular users, whose exports are
+// limited to their own events, or an empty id for admins.
+func (c *exportController) authorScope(ctx *fiber.Ctx) (string, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return "", nil
+	}
+
+	return userId, nil
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	OrderController interface {
+		CreateOrder(ctx *fiber.Ctx) error
+		GetOrderById(ctx *fiber.Ctx) error
+		GetOrderReceipt(ctx *fiber.Ctx) error
+		CancelOrder(ctx *fiber.Ctx) error
+	}
+
+	orderController struct {
+		orderService service.OrderService
+		userService  service.UserService
+	}
+)
+
+func NewOrderController(orderService service.OrderService, userService service.UserService) OrderController {
+	return &orderController{
+		orderService: orderService,
+		userService:  userService,
+	}
+}
+
+func (c *orderController) CreateOrder(ctx *fiber.Ctx) error {
+	var req dto.OrderCreateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	req.BuyerID = ctx.Locals("user_id").(string)
+
+	result, err := c.orderService.CreateOrder(ctx.Context(), req)
+	var limitErr *dto.ErrPurchaseLimitExceeded
+	if errors.As(err, &limitErr) {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), limitErr)
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(res)
+	}
+	if errors.Is(err, dto.ErrPaymentsUnavailable) {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
+		return ctx.Status(http.StatusServiceUnavailable).JSON(res)
+	}
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ORDER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ORDER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *orderController) GetOrderById(ctx *fiber.Ctx) error {
+	orderId := ctx.Params("id")
+	if status, err := c.authorizeOrder(ctx, orderId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ORDER_BY_ID, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.orderService.GetOrderById(ctx.Context(), orderId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ORDER_BY_ID, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ORDER_BY_ID, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *orderController) GetOrderReceipt(ctx *fiber.Ctx) error {
+	orderId := ctx.Params("id")
+	if status, err := c.authorizeOrder(ctx, orderId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ORDER_RECEIPT, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.orderService.GetOrderReceipt(ctx.Context(), orderId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ORDER_RECEIPT, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ORDER_RECEIPT, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *orderController) CancelOrder(ctx *fiber.Ctx) error {
+	var req dto.OrderCancelRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	orderId := ctx.Params("id")
+	if status, err := c.authorizeOrder(ctx, orderId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_ORDER, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.orderService.CancelOrder(ctx.Context(), req, orderId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_ORDER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CANCEL_ORDER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// authorizeOrder lets the buyer of an order and admins through, returning
+// the HTTP status to answer with otherwise. Orders of other buyers are
+// answered as not found so their existence is not leaked.
+func (c *orderController) authorizeOrder(ctx *fiber.Ctx, orderId string) (int, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return http.StatusOK, nil
+	}
+
+	order, err := c.orderService.GetOrderById(ctx.Context(), orderId)
+	if err != nil || order.BuyerID != userId {
+		return http.StatusNotFound, dto.ErrOrderNotFound
+	}
+
+	return http.StatusOK, nil
+}
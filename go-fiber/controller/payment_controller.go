@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	PaymentController interface {
+		Webhook(ctx *fiber.Ctx) error
+	}
+
+	paymentController struct {
+		transactionService service.TransactionService
+	}
+)
+
+func NewPaymentController(transactionService service.TransactionService) PaymentController {
+	return &paymentController{
+		transactionService: transactionService,
+	}
+}
+
+func (c *paymentController) Webhook(ctx *fiber.Ctx) error {
+	provider := ctx.Params("provider")
+	signature := ctx.Get("X-Signature")
+
+	if err := c.transactionService.HandlePaymentWebhook(ctx.Context(), provider, ctx.Body(), signature); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_HANDLE_PAYMENT_WEBHOOK, err.Error(), nil)
+		if errors.Is(err, dto.ErrInvalidSignature) {
+			return ctx.Status(http.StatusUnauthorized).JSON(res)
+		}
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_HANDLE_PAYMENT_WEBHOOK, nil)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
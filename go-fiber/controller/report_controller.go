@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	ReportController interface {
+		GetSalesReport(ctx *fiber.Ctx) error
+	}
+
+	reportController struct {
+		reportService service.ReportService
+		userService   service.UserService
+	}
+)
+
+func NewReportController(reportService service.ReportService, userService service.UserService) ReportController {
+	return &reportController{
+		reportService: reportService,
+		userService:   userService,
+	}
+}
+
+// GetSalesReport reports the sales of every event to admins. Organizers
+// only get the sales of their own events.
+func (c *reportController) GetSalesReport(ctx *fiber.Ctx) error {
+	var req dto.SalesReportRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if user.Role != constants.ENUM_ROLE_ADMIN {
+		req.AuthorID = userId
+	}
+
+	result, err := c.reportService.GetSalesReport(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SALES_REPORT, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SALES_REPORT, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
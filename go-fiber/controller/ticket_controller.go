@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	TicketController interface {
+		GetMyTickets(ctx *fiber.Ctx) error
+		GetTicketByCode(ctx *fiber.Ctx) error
+		DownloadTicketQR(ctx *fiber.Ctx) error
+		CheckInTicket(ctx *fiber.Ctx) error
+		SyncCheckIns(ctx *fiber.Ctx) error
+		GetSigningKey(ctx *fiber.Ctx) error
+		TransferTicket(ctx *fiber.Ctx) error
+		TransferTickets(ctx *fiber.Ctx) error
+		GetMyTransfers(ctx *fiber.Ctx) error
+		AcceptTransfer(ctx *fiber.Ctx) error
+		DeclineTransfer(ctx *fiber.Ctx) error
+		CancelTransfer(ctx *fiber.Ctx) error
+		GetTicketHistory(ctx *fiber.Ctx) error
+	}
+
+	ticketController struct {
+		ticketService service.TicketService
+		userService   service.UserService
+	}
+)
+
+func NewTicketController(ticketService service.TicketService, userService service.UserService) TicketController {
+	return &ticketController{
+		ticketService: ticketService,
+		userService:   userService,
+	}
+}
+
+func (c *ticketController) GetMyTickets(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.GetTicketsByOwner(ctx.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_TICKET, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_TICKET, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) GetTicketByCode(ctx *fiber.Ctx) error {
+	ownerId, err := c.ownerScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	result, err := c.ticketService.GetTicketByCode(ctx.Context(), ctx.Params("code"), ownerId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TICKET, err.Error(), nil)
+		return ctx.Status(http.StatusNotFound).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TICKET, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) DownloadTicketQR(ctx *fiber.Ctx) error {
+	ownerId, err := c.ownerScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	code := ctx.Params("code")
+	png, err := c.ticketService.GenerateTicketQR(ctx.Context(), code, ownerId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GENERATE_TICKET_QR, err.Error(), nil)
+		switch {
+		case errors.Is(err, dto.ErrTicketNotFound):
+			return ctx.Status(http.StatusNotFound).JSON(res)
+		case errors.Is(err, dto.ErrTicketAlreadyUsed), errors.Is(err, dto.ErrTicketVoid):
+			return ctx.Status(http.StatusBadRequest).JSON(res)
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(res)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "image/png")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="ticket-%s.png"`, code))
+	return ctx.Status(http.StatusOK).Send(png)
+}
+
+func (c *ticketController) CheckInTicket(ctx *fiber.Ctx) error {
+	var req dto.TicketCheckInRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	staffId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.CheckInTicket(ctx.Context(), req, staffId)
+	if err != nil {
+		// The ticket is sent back on rejections so staff can see when it was scanned
+		var data any
+		if result.ID != "" {
+			data = result
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CHECK_IN_TICKET, err.Error(), data)
+		return ctx.Status(checkInErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CHECK_IN_TICKET, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) SyncCheckIns(ctx *fiber.Ctx) error {
+	var req dto.TicketBatchCheckInRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	staffId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.SyncCheckIns(ctx.Context(), req, staffId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_SYNC_CHECK_IN, err.Error(), nil)
+		return ctx.Status(checkInErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_SYNC_CHECK_IN, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) GetSigningKey(ctx *fiber.Ctx) error {
+	staffId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.GetSigningKey(ctx.Context(), ctx.Params("event_id"), staffId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TICKET_SIGN_KEY, err.Error(), nil)
+		return ctx.Status(checkInErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TICKET_SIGN_KEY, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func checkInErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrNotEventStaff):
+		return http.StatusForbidden
+	case errors.Is(err, dto.ErrTicketAlreadyUsed):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrTicketNotFound), errors.Is(err, dto.ErrEventNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (c *ticketController) TransferTicket(ctx *fiber.Ctx) error {
+	var req dto.TicketTransferRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	// Only the owner can give a ticket away, admins included.
+	req.Code = ctx.Params("code")
+	req.FromUserID = ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.TransferTicket(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_TRANSFER_TICKET, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_TRANSFER_TICKET, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) TransferTickets(ctx *fiber.Ctx) error {
+	var req dto.TicketTransferRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	req.FromUserID = ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.TransferTickets(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_TRANSFER_TICKET, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_TRANSFER_TICKET, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) GetMyTransfers(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.GetTransfersByUser(ctx.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_TICKET_TRANSFER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_TICKET_TRANSFER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) AcceptTransfer(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.AcceptTransfer(ctx.Context(), ctx.Params("id"), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ACCEPT_TICKET_TRANSFER, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ACCEPT_TICKET_TRANSFER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) DeclineTransfer(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.DeclineTransfer(ctx.Context(), ctx.Params("id"), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DECLINE_TICKET_TRANSFER, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DECLINE_TICKET_TRANSFER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) CancelTransfer(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.ticketService.CancelTransfer(ctx.Context(), ctx.Params("id"), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_TICKET_TRANSFER, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CANCEL_TICKET_TRANSFER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketController) GetTicketHistory(ctx *fiber.Ctx) error {
+	ownerId, err := c.ownerScope(ctx)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	result, err := c.ticketService.GetTicketHistory(ctx.Context(), ctx.Params("code"), ownerId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TICKET_HISTORY, err.Error(), nil)
+		return ctx.Status(transferErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TICKET_HISTORY, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrTicketNotFound), errors.Is(err, dto.ErrTicketTransferNotFound), errors.Is(err, dto.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrTicketTransfersDisabled), errors.Is(err, dto.ErrAccountNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, dto.ErrTicketTransferPending), errors.Is(err, dto.ErrTicketTransferNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ownerScope returns the caller's id for regular users, or an empty id for
+// admins who may look at every ticket.
+func (c *ticketController) ownerScope(ctx *fiber.Ctx) (string, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return "", nil
+	}
+
+	return userId, nil
+}
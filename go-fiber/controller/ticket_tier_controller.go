@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	TicketTierController interface {
+		GetTiers(ctx *fiber.Ctx) error
+		CreateTier(ctx *fiber.Ctx) error
+		UpdateTier(ctx *fiber.Ctx) error
+		DeleteTier(ctx *fiber.Ctx) error
+	}
+
+	ticketTierController struct {
+		ticketTierService service.TicketTierService
+		userService       service.UserService
+	}
+)
+
+func NewTicketTierController(ticketTierService service.TicketTierService, userService service.UserService) TicketTierController {
+	return &ticketTierController{
+		ticketTierService: ticketTierService,
+		userService:       userService,
+	}
+}
+
+func (c *ticketTierController) GetTiers(ctx *fiber.Ctx) error {
+	// Only admins see the tiers of events that are not on sale.
+	publishedOnly := false
+	if _, err := authorizeAdmin(ctx, c.userService); err != nil {
+		publishedOnly = true
+	}
+
+	result, err := c.ticketTierService.GetTiersByEvent(ctx.Context(), ctx.Params("event_id"), publishedOnly)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_TICKET_TIER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_TICKET_TIER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketTierController) CreateTier(ctx *fiber.Ctx) error {
+	var req dto.TicketTierRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.ticketTierService.CreateTier(ctx.Context(), ctx.Params("event_id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TICKET_TIER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TICKET_TIER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketTierController) UpdateTier(ctx *fiber.Ctx) error {
+	var req dto.TicketTierUpdateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.ticketTierService.UpdateTier(ctx.Context(), ctx.Params("event_id"), ctx.Params("tier_id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_TICKET_TIER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_TICKET_TIER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *ticketTierController) DeleteTier(ctx *fiber.Ctx) error {
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	if err := c.ticketTierService.DeleteTier(ctx.Context(), ctx.Params("event_id"), ctx.Params("tier_id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_TICKET_TIER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_TICKET_TIER, nil)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// authorizeAdmin lets only admins through, answering with the HTTP status to
+// use otherwise.
+func authorizeAdmin(ctx *fiber.Ctx, userService service.UserService) (int, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if user.Role != constants.ENUM_ROLE_ADMIN {
+		return http.StatusForbidden, errors.New(dto.MESSAGE_FAILED_DENIED_ACCESS)
+	}
+
+	return http.StatusOK, nil
+}
@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/dto"
 	"github.com/tapeds/go-fiber-template/service"
 	"github.com/tapeds/go-fiber-template/utils"
@@ -13,23 +16,26 @@ type (
 	TransactionController interface {
 		CreateTransaction(ctx *fiber.Ctx) error
 		GetAllTransactions(ctx *fiber.Ctx) error
+		GetMyTransactions(ctx *fiber.Ctx) error
 		GetTransactionById(ctx *fiber.Ctx) error
 		UpdateTransaction(ctx *fiber.Ctx) error
+		UpdateTransactionStatus(ctx *fiber.Ctx) error
+		CancelTransaction(ctx *fiber.Ctx) error
+		RefundTransaction(ctx *fiber.Ctx) error
+		GetTransactionInvoice(ctx *fiber.Ctx) error
 		DeleteTransaction(ctx *fiber.Ctx) error
 	}
 
 	transactionController struct {
 		transactionService service.TransactionService
 		userService        service.UserService
-		eventService       service.EventService
 	}
 )
 
-func NewTransactionController(transactionService service.TransactionService, userService service.UserService, eventService service.EventService) TransactionController {
+func NewTransactionController(transactionService service.TransactionService, userService service.UserService) TransactionController {
 	return &transactionController{
 		transactionService: transactionService,
 		userService:        userService,
-		eventService:       eventService,
 	}
 }
 
@@ -42,125 +48,94 @@ func (c *transactionController) CreateTransaction(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	userId := ctx.Locals("user_id").(string)
+	// Set the buyer ID for the transaction
+	req.BuyerID = ctx.Locals("user_id").(string)
 
-	// Validate Buyer
-	_, err := c.userService.GetUserById(ctx.Context(), userId)
-	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_BUYER, err.Error(), nil)
-		return ctx.Status(http.StatusBadRequest).JSON(res)
+	// Availability check, seat decrement and insert happen atomically in the service
+	result, err := c.transactionService.CreateTransaction(ctx.Context(), req)
+	if errors.Is(err, dto.ErrInsufficientAvailability) {
+		// Sold out purchases point the client at the waitlist of the tier.
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), dto.WaitlistHintResponse{
+			EventID:      req.EventID,
+			TierID:       req.TierID,
+			WaitlistOpen: true,
+		})
+		return ctx.Status(http.StatusConflict).JSON(res)
+	}
+	var limitErr *dto.ErrPurchaseLimitExceeded
+	if errors.As(err, &limitErr) {
+		// The remaining allowance lets clients lower the amount and retry.
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), limitErr)
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(res)
+	}
+	if errors.Is(err, dto.ErrPaymentsUnavailable) {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
+		return ctx.Status(http.StatusServiceUnavailable).JSON(res)
 	}
-
-	// Validate Event
-	event, err := c.eventService.GetEventById(ctx.Context(), req.EventID)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_EVENT, err.Error(), nil)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	// Check if event has enough availability
-	if event.Availabilty < req.Amount {
-		res := utils.BuildResponseFailed("Insufficient availability", "The event doesn't have enough spots available.", nil)
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TRANSACTION, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// GetAllTransactions lists every transaction for admins. Everyone else only
+// sees the transactions of the events they organize.
+func (c *transactionController) GetAllTransactions(ctx *fiber.Ctx) error {
+	var req dto.TransactionPaginationRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	// Decrease the event availability by the amount of the transaction
-	event.Availabilty -= req.Amount
-
-	// Prepare the EventUpdateRequest
-	eventUpdateRequest := dto.EventUpdateRequest{
-		ID:          event.ID,          // Keep the event name as it is
-		Name:        event.Name,        // Keep the event name as it is
-		Price:       event.Price,       // Keep the price as is
-		Capacity:    event.Capacity,    // Keep the capacity as it is
-		Availabilty: event.Availabilty, // Update the availability
+	if err := req.Validate(); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	// Update the event's availability
-	_, err = c.eventService.UpdateEvent(ctx.Context(), eventUpdateRequest, event.ID)
+	viewerId, err := c.viewerScope(ctx)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_EVENT, err.Error(), nil)
-		return ctx.Status(http.StatusInternalServerError).JSON(res)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
+	req.AuthorID = viewerId
 
-	// Set the buyer ID for the transaction
-	req.BuyerID = userId
+	return c.listTransactions(ctx, req)
+}
 
-	// Create the transaction
-	result, err := c.transactionService.CreateTransaction(ctx.Context(), req)
-	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_TRANSACTION, err.Error(), nil)
+// GetMyTransactions lists the purchases of the caller.
+func (c *transactionController) GetMyTransactions(ctx *fiber.Ctx) error {
+	var req dto.TransactionPaginationRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	// Fetch Event and Buyer details for the response
-	event, _ = c.eventService.GetEventById(ctx.Context(), req.EventID)
-	buyer, _ := c.userService.GetUserById(ctx.Context(), userId)
-
-	// Send response with transaction details
-	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_TRANSACTION, struct {
-		*dto.TransactionResponse
-		EventName  string `json:"event_name"`
-		BuyerName  string `json:"buyer_name"`
-		BuyerEmail string `json:"buyer_email"`
-	}{
-		TransactionResponse: &dto.TransactionResponse{
-			ID:         result.ID,
-			BuyerID:    buyer.ID,
-			BuyerName:  buyer.Name,
-			BuyerEmail: buyer.Email,
-			EventID:    result.EventID,
-			EventName:  event.Name,
-			EventPrice: event.Price,
-			Amount:     result.Amount,
-			BuyedAt:    result.BuyedAt,
-		},
-		EventName:  event.Name,
-		BuyerName:  buyer.Name,
-		BuyerEmail: buyer.Email,
-	})
-
-	return ctx.Status(http.StatusOK).JSON(res)
-}
-
-func (c *transactionController) GetAllTransactions(ctx *fiber.Ctx) error {
-	var req dto.PaginationRequest
-	if err := ctx.BodyParser(&req); err != nil {
+	if err := req.Validate(); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
+	req.BuyerID = ctx.Locals("user_id").(string)
+
+	return c.listTransactions(ctx, req)
+}
+
+func (c *transactionController) listTransactions(ctx *fiber.Ctx, req dto.TransactionPaginationRequest) error {
 	result, err := c.transactionService.GetAllTransactionsWithPagination(ctx.Context(), req)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_TRANSACTION, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	var transactionsWithDetails []dto.TransactionResponse
-	for _, transaction := range result.Data {
-		// Fetch event and buyer details for each transaction
-		event, err := c.eventService.GetEventById(ctx.Context(), transaction.EventID)
-		if err != nil {
-			event.Name = "Unknown"
-		} else {
-			transaction.EventName = event.Name
-		}
-
-		buyer, err := c.userService.GetUserById(ctx.Context(), transaction.BuyerID)
-		if err != nil {
-			buyer.Name = "Unknown"
-		}
-
-		// transaction.EventID = event.ID
-		transaction.EventID = transaction.EventID
-		transaction.BuyerName = buyer.Name
-		transactionsWithDetails = append(transactionsWithDetails, transaction)
-	}
+	result.SetLinks(ctx.BaseURL() + ctx.OriginalURL())
 
 	resp := utils.Response{
 		Status:  true,
 		Message: dto.MESSAGE_SUCCESS_GET_LIST_TRANSACTION,
-		Data:    transactionsWithDetails,
+		Data:    result.Data,
 		Meta:    result.PaginationResponse,
 	}
 
@@ -169,56 +144,29 @@ func (c *transactionController) GetAllTransactions(ctx *fiber.Ctx) error {
 
 func (c *transactionController) GetTransactionById(ctx *fiber.Ctx) error {
 	var req dto.TransactionByIdRequest
-	if err := ctx.BodyParser(&req); err != nil {
+	if err := ctx.QueryParser(&req); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	result, err := c.transactionService.GetTransactionById(ctx.Context(), req.ID)
+	viewerId, err := c.viewerScope(ctx)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRANSACTION_BY_ID, err.Error(), nil)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	event, err := c.eventService.GetEventById(ctx.Context(), result.EventID)
-	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_EVENT, err.Error(), nil)
-		return ctx.Status(http.StatusBadRequest).JSON(res)
+	result, err := c.transactionService.GetVisibleTransactionById(ctx.Context(), req.ID, viewerId)
+	if errors.Is(err, dto.ErrTransactionNotFound) {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRANSACTION_BY_ID, err.Error(), nil)
+		return ctx.Status(http.StatusNotFound).JSON(res)
 	}
-
-	buyer, err := c.userService.GetUserById(ctx.Context(), result.BuyerID)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_BUYER, err.Error(), nil)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_TRANSACTION_BY_ID, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
-	resp := utils.Response{
-		Status:  true,
-		Message: dto.MESSAGE_SUCCESS_GET_TRANSACTION_BY_ID,
-		Data: struct {
-			*dto.TransactionResponse
-			EventName  string `json:"event_name"`
-			BuyerName  string `json:"buyer_name"`
-			BuyerEmail string `json:"buyer_email"`
-		}{
-			TransactionResponse: &dto.TransactionResponse{
-				ID:         result.ID,
-				BuyerID:    result.BuyerID,
-				EventID:    result.EventID,
-				BuyerName:  buyer.Name,
-				BuyerEmail: buyer.Email,
-				EventName:  event.Name,
-				EventPrice: event.Price,
-				Amount:     result.Amount,
-				BuyedAt:    result.BuyedAt,
-			},
-			EventName:  event.Name,
-			BuyerName:  buyer.Name,
-			BuyerEmail: buyer.Email,
-		},
-	}
-
-	return ctx.Status(http.StatusOK).JSON(resp)
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_TRANSACTION_BY_ID, result)
+	return ctx.Status(http.StatusOK).JSON(res)
 }
 
 func (c *transactionController) UpdateTransaction(ctx *fiber.Ctx) error {
@@ -235,6 +183,11 @@ func (c *transactionController) UpdateTransaction(ctx *fiber.Ctx) error {
 	}
 
 	transactionId := ctx.Params("id")
+	if status, err := c.authorizeTransaction(ctx, transactionId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_TRANSACTION, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
 	result, err := c.transactionService.UpdateTransaction(ctx.Context(), req, transactionId)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_TRANSACTION, err.Error(), nil)
@@ -245,8 +198,154 @@ func (c *transactionController) UpdateTransaction(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(res)
 }
 
+func (c *transactionController) UpdateTransactionStatus(ctx *fiber.Ctx) error {
+	var req dto.TransactionStatusUpdateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if user.Role != constants.ENUM_ROLE_ADMIN {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, dto.ErrUserNotAdmin.Error(), nil)
+		return ctx.Status(http.StatusForbidden).JSON(res)
+	}
+
+	transactionId := ctx.Params("id")
+	result, err := c.transactionService.UpdateTransactionStatus(ctx.Context(), req, transactionId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_TRANSACTION_STATUS, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_TRANSACTION_STATUS, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *transactionController) CancelTransaction(ctx *fiber.Ctx) error {
+	var req dto.TransactionCancelRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	transactionId := ctx.Params("id")
+	if status, err := c.authorizeTransaction(ctx, transactionId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_TRANSACTION, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.transactionService.CancelTransaction(ctx.Context(), req, transactionId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CANCEL_TRANSACTION, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CANCEL_TRANSACTION, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *transactionController) RefundTransaction(ctx *fiber.Ctx) error {
+	var req dto.TransactionRefundRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	transactionId := ctx.Params("id")
+	if status, err := c.authorizeTransaction(ctx, transactionId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REFUND_TRANSACTION, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.transactionService.RefundTransaction(ctx.Context(), req, transactionId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REFUND_TRANSACTION, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REFUND_TRANSACTION, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *transactionController) GetTransactionInvoice(ctx *fiber.Ctx) error {
+	transactionId := ctx.Params("id")
+	if status, err := c.authorizeTransaction(ctx, transactionId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_INVOICE, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	pdf, filename, err := c.transactionService.GetTransactionInvoice(ctx.Context(), transactionId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_INVOICE, err.Error(), nil)
+		switch {
+		case errors.Is(err, dto.ErrTransactionNotFound), errors.Is(err, dto.ErrInvoiceNotFound):
+			return ctx.Status(http.StatusNotFound).JSON(res)
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(res)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "application/pdf")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return ctx.Status(http.StatusOK).Send(pdf)
+}
+
+// authorizeTransaction lets the buyer of a transaction and admins through,
+// returning the HTTP status to answer with otherwise. Transactions of other
+// buyers are answered as not found so their existence is not leaked.
+func (c *transactionController) authorizeTransaction(ctx *fiber.Ctx, transactionId string) (int, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return http.StatusOK, nil
+	}
+
+	transaction, err := c.transactionService.GetTransactionById(ctx.Context(), transactionId)
+	if err != nil || transaction.BuyerID != userId {
+		return http.StatusNotFound, dto.ErrTransactionNotFound
+	}
+
+	return http.StatusOK, nil
+}
+
+// viewerScope returns the caller's id for regular users, or an empty id for
+// admins who may look at every transaction.
+func (c *transactionController) viewerScope(ctx *fiber.Ctx) (string, error) {
+	userId := ctx.Locals("user_id").(string)
+
+	user, err := c.userService.GetUserById(ctx.Context(), userId)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Role == constants.ENUM_ROLE_ADMIN {
+		return "", nil
+	}
+
+	return userId, nil
+}
+
 func (c *transactionController) DeleteTransaction(ctx *fiber.Ctx) error {
 	transactionId := ctx.Params("id")
+	if status, err := c.authorizeTransaction(ctx, transactionId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_TRANSACTION, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
 
 	if err := c.transactionService.DeleteTransaction(ctx.Context(), transactionId); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_TRANSACTION, err.Error(), nil)
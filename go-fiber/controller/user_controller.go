@@ -52,7 +52,12 @@ func (c *userController) Register(ctx *fiber.Ctx) error {
 
 func (c *userController) GetAllUser(ctx *fiber.Ctx) error {
 	var req dto.PaginationRequest
-	if err := ctx.BodyParser(&req); err != nil {
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if err := req.Validate(); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
@@ -63,6 +68,8 @@ func (c *userController) GetAllUser(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(res)
 	}
 
+	result.SetLinks(ctx.BaseURL() + ctx.OriginalURL())
+
 	resp := utils.Response{
 		Status:  true,
 		Message: dto.MESSAGE_SUCCESS_GET_LIST_USER,
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	VoucherController interface {
+		CreateVoucher(ctx *fiber.Ctx) error
+		GetAllVouchers(ctx *fiber.Ctx) error
+		GetVoucherById(ctx *fiber.Ctx) error
+		UpdateVoucher(ctx *fiber.Ctx) error
+		DeleteVoucher(ctx *fiber.Ctx) error
+	}
+
+	voucherController struct {
+		voucherService service.VoucherService
+		userService    service.UserService
+	}
+)
+
+func NewVoucherController(voucherService service.VoucherService, userService service.UserService) VoucherController {
+	return &voucherController{
+		voucherService: voucherService,
+		userService:    userService,
+	}
+}
+
+func (c *voucherController) CreateVoucher(ctx *fiber.Ctx) error {
+	var req dto.VoucherCreateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.voucherService.CreateVoucher(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_VOUCHER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_VOUCHER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *voucherController) GetAllVouchers(ctx *fiber.Ctx) error {
+	var req dto.PaginationRequest
+
+	if err := ctx.QueryParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if err := req.Validate(); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.voucherService.GetAllVouchersWithPagination(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_VOUCHER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	result.SetLinks(ctx.BaseURL() + ctx.OriginalURL())
+
+	resp := utils.Response{
+		Status:  true,
+		Message: dto.MESSAGE_SUCCESS_GET_LIST_VOUCHER,
+		Data:    result.Data,
+		Meta:    result.PaginationResponse,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
+
+func (c *voucherController) GetVoucherById(ctx *fiber.Ctx) error {
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.voucherService.GetVoucherById(ctx.Context(), ctx.Params("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_VOUCHER_BY_ID, err.Error(), nil)
+		return ctx.Status(http.StatusNotFound).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_VOUCHER_BY_ID, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *voucherController) UpdateVoucher(ctx *fiber.Ctx) error {
+	var req dto.VoucherUpdateRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	result, err := c.voucherService.UpdateVoucher(ctx.Context(), req, ctx.Params("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_VOUCHER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_VOUCHER, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *voucherController) DeleteVoucher(ctx *fiber.Ctx) error {
+	if status, err := authorizeAdmin(ctx, c.userService); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+		return ctx.Status(status).JSON(res)
+	}
+
+	if err := c.voucherService.DeleteVoucher(ctx.Context(), ctx.Params("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_VOUCHER, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_VOUCHER, nil)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	WaitlistController interface {
+		JoinWaitlist(ctx *fiber.Ctx) error
+		GetMyWaitlist(ctx *fiber.Ctx) error
+		LeaveWaitlist(ctx *fiber.Ctx) error
+		ClaimOffer(ctx *fiber.Ctx) error
+	}
+
+	waitlistController struct {
+		waitlistService service.WaitlistService
+		orderService    service.OrderService
+	}
+)
+
+func NewWaitlistController(waitlistService service.WaitlistService, orderService service.OrderService) WaitlistController {
+	return &waitlistController{
+		waitlistService: waitlistService,
+		orderService:    orderService,
+	}
+}
+
+func (c *waitlistController) JoinWaitlist(ctx *fiber.Ctx) error {
+	var req dto.WaitlistJoinRequest
+
+	if err := ctx.BodyParser(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	req.UserID = ctx.Locals("user_id").(string)
+
+	result, err := c.waitlistService.JoinWaitlist(ctx.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_JOIN_WAITLIST, err.Error(), nil)
+		return ctx.Status(waitlistErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_JOIN_WAITLIST, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *waitlistController) GetMyWaitlist(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.waitlistService.GetWaitlistByUser(ctx.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_LIST_WAITLIST, err.Error(), nil)
+		return ctx.Status(http.StatusBadRequest).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_LIST_WAITLIST, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *waitlistController) LeaveWaitlist(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	if err := c.waitlistService.LeaveWaitlist(ctx.Context(), ctx.Params("id"), userId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LEAVE_WAITLIST, err.Error(), nil)
+		return ctx.Status(waitlistErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_LEAVE_WAITLIST, nil)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+func (c *waitlistController) ClaimOffer(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user_id").(string)
+
+	result, err := c.orderService.ClaimWaitlistOffer(ctx.Context(), ctx.Params("id"), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CLAIM_WAITLIST, err.Error(), nil)
+		return ctx.Status(waitlistErrorStatus(err)).JSON(res)
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CLAIM_WAITLIST, result)
+	return ctx.Status(http.StatusOK).JSON(res)
+}
+
+// waitlistErrorStatus answers entries of other users like missing ones, so
+// their ids cannot be probed.
+func waitlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrWaitlistEntryNotFound), errors.Is(err, dto.ErrEventNotFound), errors.Is(err, dto.ErrTierNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dto.ErrAlreadyOnWaitlist), errors.Is(err, dto.ErrWaitlistOfferExpired):
+		return http.StatusConflict
+	case errors.Is(err, dto.ErrPaymentsUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
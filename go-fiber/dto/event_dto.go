@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/tapeds/go-fiber-template/entity"
 )
 
@@ -11,6 +13,27 @@ type (
 		Price       int    `json:"price"`
 		Capacity    int    `json:"capacity"`
 		Availabilty int    `json:"availabilty"`
+		Currency    string `json:"currency"`
+
+		StartsAt    *time.Time `json:"starts_at"`
+		EndsAt      *time.Time `json:"ends_at"`
+		Timezone    string     `json:"timezone"`
+		Venue       string     `json:"venue"`
+		Address     string     `json:"address"`
+		Description string     `json:"description"`
+		MeetingURL  string     `json:"meeting_url"`
+
+		// Without tiers the event is sold through a single default tier
+		// built from Price and Capacity.
+		Tiers []TicketTierRequest `json:"tiers"`
+
+		RefundDeadline   *time.Time `json:"refund_deadline"`
+		RefundPercentage *int       `json:"refund_percentage"`
+
+		TransfersDisabled bool `json:"transfers_disabled"`
+
+		MaxTicketsPerOrder int `json:"max_tickets_per_order"`
+		MaxTicketsPerUser  int `json:"max_tickets_per_user"`
 	}
 
 	EventResponse struct {
@@ -21,6 +44,67 @@ type (
 		Price       int    `json:"price"`
 		Capacity    int    `json:"capacity"`
 		Availabilty int    `json:"availabilty"`
+		Currency    string `json:"currency"`
+
+		Status      string `json:"status"`
+		PublishedAt string `json:"published_at,omitempty"`
+		CancelledAt string `json:"cancelled_at,omitempty"`
+
+		StartsAt    string `json:"starts_at,omitempty"`
+		EndsAt      string `json:"ends_at,omitempty"`
+		Timezone    string `json:"timezone"`
+		Venue       string `json:"venue"`
+		Address     string `json:"address"`
+		Description string `json:"description"`
+		MeetingURL  string `json:"meeting_url"`
+
+		Tiers []TicketTierResponse `json:"tiers,omitempty"`
+
+		RefundDeadline   string `json:"refund_deadline,omitempty"`
+		RefundPercentage int    `json:"refund_percentage"`
+
+		TransfersDisabled bool `json:"transfers_disabled"`
+
+		MaxTicketsPerOrder int `json:"max_tickets_per_order"`
+		MaxTicketsPerUser  int `json:"max_tickets_per_user"`
+	}
+
+	// EventPaginationRequest searches and filters the event list. When picks
+	// events by where they are in their schedule, so events without one
+	// only show up without it. From and To are inclusive dates in UTC and
+	// keep the events taking place on any day between them. Sort is price,
+	// date or popularity, descending with a "-" prefix.
+	EventPaginationRequest struct {
+		PaginationRequest
+		When        string `json:"when" form:"when" query:"when"`
+		MinPrice    *int   `json:"min_price" form:"min_price" query:"min_price"`
+		MaxPrice    *int   `json:"max_price" form:"max_price" query:"max_price"`
+		From        string `json:"from" form:"from" query:"from"`
+		To          string `json:"to" form:"to" query:"to"`
+		AuthorID    string `json:"author_id" form:"author_id" query:"author_id"`
+		HideSoldOut bool   `json:"hide_sold_out" form:"hide_sold_out" query:"hide_sold_out"`
+		Sort        string `json:"sort" form:"sort" query:"sort"`
+
+		// PublishedOnly hides drafts, postponed and cancelled events from
+		// everyone but admins.
+		PublishedOnly bool `json:"-" form:"-" query:"-"`
+	}
+
+	// EventFilter is an EventPaginationRequest once validated, with its
+	// dates turned into the instants bounding them.
+	EventFilter struct {
+		PaginationRequest
+		When        string
+		MinPrice    *int
+		MaxPrice    *int
+		From        time.Time
+		To          time.Time
+		AuthorID    string
+		HideSoldOut bool
+		SortBy      string
+		Descending  bool
+
+		PublishedOnly bool
 	}
 
 	EventPaginationResponse struct {
@@ -34,17 +118,58 @@ type (
 	}
 
 	EventUpdateRequest struct {
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		Price       int    `json:"price"`
-		Capacity    int    `json:"capacity"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+
+		// Price and capacity are only changed when sent.
+		Price       *int   `json:"price"`
+		Capacity    *int   `json:"capacity"`
 		Availabilty int    `json:"availabilty"`
+		Currency    string `json:"currency"`
+
+		// Schedule fields are only changed when sent; an empty string
+		// clears a text field.
+		StartsAt    *time.Time `json:"starts_at"`
+		EndsAt      *time.Time `json:"ends_at"`
+		Timezone    *string    `json:"timezone"`
+		Venue       *string    `json:"venue"`
+		Address     *string    `json:"address"`
+		Description *string    `json:"description"`
+		MeetingURL  *string    `json:"meeting_url"`
+
+		RefundDeadline   *time.Time `json:"refund_deadline"`
+		RefundPercentage *int       `json:"refund_percentage"`
+
+		TransfersDisabled *bool `json:"transfers_disabled"`
+
+		MaxTicketsPerOrder *int `json:"max_tickets_per_order"`
+		MaxTicketsPerUser  *int `json:"max_tickets_per_user"`
 	}
 
 	EventByIdRequest struct {
 		ID string `json:"id"`
 	}
 
+	EventStatusUpdateRequest struct {
+		Status string `json:"status"`
+	}
+
+	EventCancelRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	// EventCancelResponse tells how many purchases were cancelled and how
+	// many refunds went out. Failed purchases are picked up again when the
+	// cancellation is repeated; refunds the provider refused stay failed on
+	// the refund for an admin to settle.
+	EventCancelResponse struct {
+		Event        EventResponse `json:"event"`
+		Cancelled    int           `json:"cancelled"`
+		Refunded     int           `json:"refunded"`
+		RefundFailed int           `json:"refund_failed"`
+		Failed       int           `json:"failed"`
+	}
+
 	EventUpdateResponse struct {
 		ID          string `json:"id"`
 		Name        string `json:"name"`
@@ -53,5 +178,28 @@ type (
 		Price       int    `json:"price"`
 		Capacity    int    `json:"capacity"`
 		Availabilty int    `json:"availabilty"`
+		Currency    string `json:"currency"`
+
+		Status      string `json:"status"`
+		PublishedAt string `json:"published_at,omitempty"`
+		CancelledAt string `json:"cancelled_at,omitempty"`
+
+		StartsAt    string `json:"starts_at,omitempty"`
+		EndsAt      string `json:"ends_at,omitempty"`
+		Timezone    string `json:"timezone"`
+		Venue       string `json:"venue"`
+		Address     string `json:"address"`
+		Description string `json:"description"`
+		MeetingURL  string `json:"meeting_url"`
+
+		Tiers []TicketTierResponse `json:"tiers,omitempty"`
+
+		RefundDeadline   string `json:"refund_deadline,omitempty"`
+		RefundPercentage int    `json:"refund_percentage"`
+
+		TransfersDisabled bool `json:"transfers_disabled"`
+
+		MaxTicketsPerOrder int `json:"max_tickets_per_order"`
+		MaxTicketsPerUser  int `json:"max_tickets_per_user"`
 	}
 )
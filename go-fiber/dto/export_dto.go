@@ -0,0 +1,41 @@
+package dto
+
+type (
+	// ExportRequest picks the file format of an export, csv unless asked
+	// otherwise. AuthorID is set by the controller to limit organizers to
+	// their own events.
+	ExportRequest struct {
+		Format   string `json:"format" query:"format"`
+		AuthorID string `json:"-" query:"-"`
+	}
+
+	// UserExportRequest takes the search of the user list.
+	UserExportRequest struct {
+		ExportRequest
+		Search string `json:"search" query:"search"`
+	}
+
+	// EventExportRequest takes the search and filters of the event list.
+	// Exports are neither paged nor sorted, so those parameters are ignored.
+	// AuthorID is forced to the caller for everyone but admins.
+	EventExportRequest struct {
+		EventPaginationRequest
+		Format string `json:"format" query:"format"`
+	}
+
+	// TransactionExportRequest takes the filters of the transaction list.
+	TransactionExportRequest struct {
+		ExportRequest
+		Status string `json:"status" query:"status"`
+	}
+
+	// AttendeeRow is one ticket holder of an event with how many of their
+	// tickets were checked in.
+	AttendeeRow struct {
+		OwnerID   string
+		Name      string
+		Email     string
+		Quantity  int
+		CheckedIn int
+	}
+)
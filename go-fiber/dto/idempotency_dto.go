@@ -0,0 +1,18 @@
+package dto
+
+type (
+	IdempotencyBeginRequest struct {
+		UserID      string
+		Key         string
+		Method      string
+		Path        string
+		Fingerprint string
+	}
+
+	// IdempotencyStoredResponse is the response recorded for an earlier
+	// request, replayed as-is when the same request is retried.
+	IdempotencyStoredResponse struct {
+		StatusCode int
+		Body       []byte
+	}
+)
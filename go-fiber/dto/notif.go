@@ -1,6 +1,9 @@
 package dto
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 const (
 	// Failed
@@ -33,6 +36,56 @@ const (
 	MESSAGE_FAILED_UPDATE_TRANSACTION      = "failed to update transaction"
 	MESSAGE_FAILED_DELETE_TRANSACTION      = "failed to delete transaction"
 
+	MESSAGE_FAILED_UPDATE_TRANSACTION_STATUS = "failed to update transaction status"
+	MESSAGE_FAILED_HANDLE_PAYMENT_WEBHOOK    = "failed to handle payment webhook"
+	MESSAGE_FAILED_CANCEL_TRANSACTION        = "failed to cancel transaction"
+	MESSAGE_FAILED_REFUND_TRANSACTION        = "failed to refund transaction"
+	MESSAGE_FAILED_IDEMPOTENCY_KEY           = "failed to process idempotency key"
+	MESSAGE_FAILED_GET_LIST_TICKET           = "failed to get list ticket"
+	MESSAGE_FAILED_GET_TICKET                = "failed to get ticket"
+	MESSAGE_FAILED_GENERATE_TICKET_QR        = "failed to generate ticket qr code"
+
+	MESSAGE_FAILED_CHECK_IN_TICKET     = "failed to check in ticket"
+	MESSAGE_FAILED_SYNC_CHECK_IN       = "failed to sync check-ins"
+	MESSAGE_FAILED_GET_TICKET_SIGN_KEY = "failed to get ticket signing key"
+
+	MESSAGE_FAILED_GET_LIST_TICKET_TIER = "failed to get list ticket tier"
+	MESSAGE_FAILED_CREATE_TICKET_TIER   = "failed to create ticket tier"
+	MESSAGE_FAILED_UPDATE_TICKET_TIER   = "failed to update ticket tier"
+	MESSAGE_FAILED_DELETE_TICKET_TIER   = "failed to delete ticket tier"
+
+	MESSAGE_FAILED_CREATE_VOUCHER    = "failed to create voucher"
+	MESSAGE_FAILED_GET_LIST_VOUCHER  = "failed to get list voucher"
+	MESSAGE_FAILED_GET_VOUCHER_BY_ID = "failed to get voucher by id"
+	MESSAGE_FAILED_UPDATE_VOUCHER    = "failed to update voucher"
+	MESSAGE_FAILED_DELETE_VOUCHER    = "failed to delete voucher"
+
+	MESSAGE_FAILED_CREATE_ORDER      = "failed to create order"
+	MESSAGE_FAILED_GET_ORDER_BY_ID   = "failed to get order by id"
+	MESSAGE_FAILED_GET_ORDER_RECEIPT = "failed to get order receipt"
+	MESSAGE_FAILED_CANCEL_ORDER      = "failed to cancel order"
+
+	MESSAGE_FAILED_JOIN_WAITLIST     = "failed to join waitlist"
+	MESSAGE_FAILED_GET_LIST_WAITLIST = "failed to get list waitlist"
+	MESSAGE_FAILED_LEAVE_WAITLIST    = "failed to leave waitlist"
+	MESSAGE_FAILED_CLAIM_WAITLIST    = "failed to claim waitlist offer"
+
+	MESSAGE_FAILED_TRANSFER_TICKET          = "failed to transfer ticket"
+	MESSAGE_FAILED_GET_LIST_TICKET_TRANSFER = "failed to get list ticket transfer"
+	MESSAGE_FAILED_ACCEPT_TICKET_TRANSFER   = "failed to accept ticket transfer"
+	MESSAGE_FAILED_DECLINE_TICKET_TRANSFER  = "failed to decline ticket transfer"
+	MESSAGE_FAILED_CANCEL_TICKET_TRANSFER   = "failed to cancel ticket transfer"
+	MESSAGE_FAILED_GET_TICKET_HISTORY       = "failed to get ticket history"
+
+	MESSAGE_FAILED_GET_INVOICE = "failed to get invoice"
+
+	MESSAGE_FAILED_GET_SALES_REPORT = "failed to get sales report"
+
+	MESSAGE_FAILED_EXPORT = "failed to export data"
+
+	MESSAGE_FAILED_UPDATE_EVENT_STATUS = "failed to update event status"
+	MESSAGE_FAILED_CANCEL_EVENT        = "failed to cancel event"
+
 	// Success
 	MESSAGE_SUCCESS_REGISTER_USER           = "success create user"
 	MESSAGE_SUCCESS_GET_LIST_USER           = "success get list user"
@@ -53,6 +106,50 @@ const (
 	MESSAGE_SUCCESS_GET_TRANSACTION_BY_ID   = "success to get transaction"
 	MESSAGE_SUCCESS_DELETE_TRANSACTION      = "success to delete transaction"
 	MESSAGE_SUCCESS_UPDATE_TRANSACTION      = "success to update transaction"
+
+	MESSAGE_SUCCESS_UPDATE_TRANSACTION_STATUS = "success to update transaction status"
+	MESSAGE_SUCCESS_HANDLE_PAYMENT_WEBHOOK    = "success to handle payment webhook"
+	MESSAGE_SUCCESS_CANCEL_TRANSACTION        = "success to cancel transaction"
+	MESSAGE_SUCCESS_REFUND_TRANSACTION        = "success to refund transaction"
+	MESSAGE_SUCCESS_GET_LIST_TICKET           = "success to get list ticket"
+	MESSAGE_SUCCESS_GET_TICKET                = "success to get ticket"
+
+	MESSAGE_SUCCESS_CHECK_IN_TICKET     = "success to check in ticket"
+	MESSAGE_SUCCESS_SYNC_CHECK_IN       = "success to sync check-ins"
+	MESSAGE_SUCCESS_GET_TICKET_SIGN_KEY = "success to get ticket signing key"
+
+	MESSAGE_SUCCESS_GET_LIST_TICKET_TIER = "success to get list ticket tier"
+	MESSAGE_SUCCESS_CREATE_TICKET_TIER   = "success to create ticket tier"
+	MESSAGE_SUCCESS_UPDATE_TICKET_TIER   = "success to update ticket tier"
+	MESSAGE_SUCCESS_DELETE_TICKET_TIER   = "success to delete ticket tier"
+
+	MESSAGE_SUCCESS_CREATE_VOUCHER    = "success to create voucher"
+	MESSAGE_SUCCESS_GET_LIST_VOUCHER  = "success to get list voucher"
+	MESSAGE_SUCCESS_GET_VOUCHER_BY_ID = "success to get voucher by id"
+	MESSAGE_SUCCESS_UPDATE_VOUCHER    = "success to update voucher"
+	MESSAGE_SUCCESS_DELETE_VOUCHER    = "success to delete voucher"
+
+	MESSAGE_SUCCESS_CREATE_ORDER      = "success to create order"
+	MESSAGE_SUCCESS_GET_ORDER_BY_ID   = "success to get order by id"
+	MESSAGE_SUCCESS_GET_ORDER_RECEIPT = "success to get order receipt"
+	MESSAGE_SUCCESS_CANCEL_ORDER      = "success to cancel order"
+
+	MESSAGE_SUCCESS_JOIN_WAITLIST     = "success to join waitlist"
+	MESSAGE_SUCCESS_GET_LIST_WAITLIST = "success to get list waitlist"
+	MESSAGE_SUCCESS_LEAVE_WAITLIST    = "success to leave waitlist"
+	MESSAGE_SUCCESS_CLAIM_WAITLIST    = "success to claim waitlist offer"
+
+	MESSAGE_SUCCESS_TRANSFER_TICKET          = "success to transfer ticket"
+	MESSAGE_SUCCESS_GET_LIST_TICKET_TRANSFER = "success to get list ticket transfer"
+	MESSAGE_SUCCESS_ACCEPT_TICKET_TRANSFER   = "success to accept ticket transfer"
+	MESSAGE_SUCCESS_DECLINE_TICKET_TRANSFER  = "success to decline ticket transfer"
+	MESSAGE_SUCCESS_CANCEL_TICKET_TRANSFER   = "success to cancel ticket transfer"
+	MESSAGE_SUCCESS_GET_TICKET_HISTORY       = "success to get ticket history"
+
+	MESSAGE_SUCCESS_GET_SALES_REPORT = "success to get sales report"
+
+	MESSAGE_SUCCESS_UPDATE_EVENT_STATUS = "success to update event status"
+	MESSAGE_SUCCESS_CANCEL_EVENT        = "success to cancel event"
 )
 
 var (
@@ -93,4 +190,156 @@ var (
 	ErrTransactionNotFound = errors.New("no transaction was found")
 	ErrDeleteTransaction   = errors.New("failed to delete transaction")
 	ErrBuyerNotFound       = errors.New("no buyer id was found")
+
+	ErrInvalidAmount            = errors.New("transaction amount must be greater than zero")
+	ErrInsufficientAvailability = errors.New("the event doesn't have enough spots available")
+	ErrInvalidTransactionStatus = errors.New("invalid transaction status")
+
+	ErrPaymentProviderNotFound = errors.New("payment provider not found")
+	ErrInvalidSignature        = errors.New("invalid webhook signature")
+	ErrInvalidPaymentStatus    = errors.New("invalid payment status")
+	ErrCreatePayment           = errors.New("failed to create payment")
+	ErrPaymentNotFound         = errors.New("no transaction matches this payment")
+
+	ErrPaymentMockSecretRequired = errors.New("PAYMENT_MOCK_SECRET must be set when the mock payment provider is enabled")
+	ErrPaymentsUnavailable       = errors.New("payments are unavailable, no payment provider is configured")
+
+	ErrTransactionNotEditable  = errors.New("only pending transactions can be changed")
+	ErrDeletePaidTransaction   = errors.New("paid transactions are cancelled or refunded instead of deleted")
+	ErrTicketsHandedOver       = errors.New("tickets that were transferred or checked in cannot be refunded")
+	ErrInvalidRefundQuantity   = errors.New("refund quantity must be between one and the number of tickets bought")
+	ErrInvalidRefundPercentage = errors.New("refund percentage must be between 0 and 100")
+	ErrRefundDeadlinePassed    = errors.New("the refund deadline for this event has passed")
+	ErrRefundPayment           = errors.New("payment provider failed to refund")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrIdempotencyKeyTooLong    = errors.New("idempotency key must be at most 255 characters")
+
+	ErrTicketNotFound   = errors.New("ticket not found")
+	ErrIssueTickets     = errors.New("failed to issue tickets")
+	ErrGenerateTicketQR = errors.New("failed to generate ticket qr code")
+
+	ErrTicketAlreadyUsed  = errors.New("ticket has already been checked in")
+	ErrTicketVoid         = errors.New("ticket is no longer valid")
+	ErrTicketNotForEvent  = errors.New("ticket does not belong to this event")
+	ErrInvalidTicketToken = errors.New("ticket signature is not valid")
+	ErrTicketCodeRequired = errors.New("ticket code or token is required")
+	ErrNotEventStaff      = errors.New("only admins and the event organizer can check in tickets")
+
+	ErrTierNotFound      = errors.New("ticket tier not found")
+	ErrTierRequired      = errors.New("this event sells several ticket tiers, tier_id is required")
+	ErrTierNotOnSale     = errors.New("this ticket tier is not on sale")
+	ErrInvalidTier       = errors.New("ticket tier needs a name and a price and capacity that are not negative")
+	ErrInvalidSaleWindow = errors.New("ticket tier sale must end after it starts")
+	ErrTierHasSales      = errors.New("cannot delete a ticket tier that has sold tickets")
+	ErrCapacityBelowSold = errors.New("capacity cannot be lower than the number of tickets already sold")
+	ErrEventHasTiers     = errors.New("price and capacity of an event with several tiers are managed per tier")
+	ErrLastTicketTier    = errors.New("an event needs at least one ticket tier")
+
+	ErrVoucherNotFound      = errors.New("voucher not found")
+	ErrInvalidVoucher       = errors.New("voucher needs a code and a positive discount, percentages at most 100")
+	ErrInvalidVoucherWindow = errors.New("voucher must be valid until after it becomes valid")
+	ErrCreateVoucher        = errors.New("failed to create voucher, the code may already be in use")
+	ErrVoucherNotActive     = errors.New("voucher is not valid at this time")
+	ErrVoucherNotApplicable = errors.New("voucher cannot be used for this event or ticket tier")
+	ErrVoucherExhausted     = errors.New("voucher has reached its usage limit")
+	ErrVoucherUserLimit     = errors.New("voucher was already used the maximum number of times by this user")
+	ErrVoucherNotStackable  = errors.New("voucher cannot be combined with other vouchers")
+
+	ErrInvalidCurrency = errors.New("currency must be a three letter ISO 4217 code")
+
+	ErrInvalidOrderID        = errors.New("invalid order id")
+	ErrOrderNotFound         = errors.New("order not found")
+	ErrCreateOrder           = errors.New("failed to create order")
+	ErrEmptyOrder            = errors.New("an order needs at least one item")
+	ErrDuplicateOrderItem    = errors.New("an order can hold each ticket tier only once")
+	ErrOrderCurrencyMismatch = errors.New("all items of an order must be priced in the same currency")
+
+	ErrInvalidWaitlistEntryID = errors.New("invalid waitlist entry id")
+	ErrWaitlistEntryNotFound  = errors.New("waitlist entry not found")
+	ErrSeatsStillAvailable    = errors.New("this ticket tier still has enough seats, buy them instead")
+	ErrAlreadyOnWaitlist      = errors.New("you are already on the waitlist for this ticket tier")
+	ErrWaitlistEntryNotActive = errors.New("this waitlist entry is no longer active")
+	ErrWaitlistNoOffer        = errors.New("this waitlist entry has no open offer")
+	ErrWaitlistOfferExpired   = errors.New("this waitlist offer has expired")
+
+	ErrInvalidTicketTransferID  = errors.New("invalid ticket transfer id")
+	ErrTicketTransferNotFound   = errors.New("ticket transfer not found")
+	ErrTicketTransfersDisabled  = errors.New("tickets of this event cannot be transferred")
+	ErrTicketTransferPending    = errors.New("this ticket already has a pending transfer")
+	ErrTicketTransferToSelf     = errors.New("cannot transfer a ticket to yourself")
+	ErrTicketTransferRecipient  = errors.New("a recipient user_id or email is required")
+	ErrTicketTransferNotPending = errors.New("this ticket transfer is no longer pending")
+	ErrTicketTransferNoTickets  = errors.New("name at least one ticket code to transfer")
+
+	ErrInvalidPurchaseLimit = errors.New("purchase limits cannot be negative")
+
+	ErrInvoiceNotFound = errors.New("invoice not found, only paid transactions have one")
+	ErrIssueInvoice    = errors.New("failed to issue invoice")
+	ErrRenderInvoice   = errors.New("failed to render invoice")
+
+	ErrInvalidReportGroup = errors.New("group_by must be event, tier or day")
+	ErrInvalidReportDate  = errors.New("report dates must look like 2006-01-02")
+	ErrInvalidReportRange = errors.New("report range ends before it starts")
+	ErrInvalidTimezone    = errors.New("unknown timezone")
+
+	ErrInvalidExportFormat = errors.New("export format must be csv or xlsx")
+
+	ErrEventScheduleRequired = errors.New("an event needs a start and an end time")
+	ErrInvalidEventSchedule  = errors.New("an event must end after it starts")
+	ErrInvalidMeetingURL     = errors.New("meeting url must be an http or https url")
+	ErrInvalidEventWhen      = errors.New("when must be upcoming, ongoing or past")
+	ErrEventEnded            = errors.New("this event has already ended")
+
+	ErrInvalidPriceRange     = errors.New("price range must not be negative or end before it starts")
+	ErrInvalidEventDate      = errors.New("event dates must look like 2006-01-02")
+	ErrInvalidEventDateRange = errors.New("event date range ends before it starts")
+	ErrInvalidEventSort      = errors.New("sort must be price, date or popularity, optionally prefixed with -")
+
+	ErrInvalidPagination = errors.New("page and per_page must not be negative")
+	ErrPerPageTooLarge   = errors.New("per_page must be at most 100")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrCursorWithPage    = errors.New("cursor cannot be combined with page")
+	ErrCursorWithSort    = errors.New("cursor pages are ordered by creation and cannot be sorted")
+
+	ErrInvalidEventStatus = errors.New("event status must be draft, published, postponed or cancelled")
+	ErrCancelEventAction  = errors.New("events are cancelled through the cancel action, which refunds their buyers")
+	ErrEventNotOnSale     = errors.New("this event is not on sale")
 )
+
+// ErrInvalidStatusTransition is returned when a transaction is asked to move
+// to a status that is not reachable from its current one.
+type ErrInvalidStatusTransition struct {
+	From string
+	To   string
+}
+
+func (e *ErrInvalidStatusTransition) Error() string {
+	return fmt.Sprintf("cannot change transaction status from %s to %s", e.From, e.To)
+}
+
+// ErrInvalidEventStatusTransition is returned when an event is asked to
+// move to a status that is not reachable from its current one.
+type ErrInvalidEventStatusTransition struct {
+	From string
+	To   string
+}
+
+func (e *ErrInvalidEventStatusTransition) Error() string {
+	return fmt.Sprintf("cannot change event status from %s to %s", e.From, e.To)
+}
+
+// ErrPurchaseLimitExceeded is returned when a purchase would go over the
+// per order or per user ticket limit of an event. Remaining is how many
+// tickets can still be bought under that limit.
+type ErrPurchaseLimitExceeded struct {
+	EventID   string `json:"event_id"`
+	Scope     string `json:"scope"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+}
+
+func (e *ErrPurchaseLimitExceeded) Error() string {
+	return fmt.Sprintf("at most %d tickets of this event can be bought per %s, %d remaining", e.Limit, e.Scope, e.Remaining)
+}
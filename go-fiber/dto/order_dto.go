@@ -0,0 +1,57 @@
+package dto
+
+type (
+	OrderItemRequest struct {
+		EventID string `json:"event_id"`
+		TierID  string `json:"tier_id"`
+		Amount  int    `json:"amount"`
+
+		// VoucherCode is a shorthand for a single entry of VoucherCodes.
+		VoucherCode  string   `json:"voucher_code"`
+		VoucherCodes []string `json:"voucher_codes"`
+	}
+
+	OrderCreateRequest struct {
+		BuyerID string             `json:"buyer_id"`
+		Items   []OrderItemRequest `json:"items"`
+	}
+
+	OrderCancelRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	OrderResponse struct {
+		ID         string                `json:"id"`
+		BuyerID    string                `json:"buyer_id"`
+		BuyerName  string                `json:"buyer_name"`
+		BuyerEmail string                `json:"buyer_email"`
+		Status     string                `json:"status"`
+		Items      []TransactionResponse `json:"items"`
+		Subtotal   int                   `json:"subtotal"`
+		Discount   int                   `json:"discount"`
+		Fees       int                   `json:"fees"`
+		Total      int                   `json:"total"`
+		Currency   string                `json:"currency"`
+		CreatedAt  string                `json:"created_at"`
+
+		PaidAt      string `json:"paid_at,omitempty"`
+		CancelledAt string `json:"cancelled_at,omitempty"`
+		FailedAt    string `json:"failed_at,omitempty"`
+
+		// HoldExpiresAt is when the seats of an unpaid order go back on sale.
+		HoldExpiresAt string `json:"hold_expires_at,omitempty"`
+
+		PaymentProvider  string `json:"payment_provider,omitempty"`
+		PaymentReference string `json:"payment_reference,omitempty"`
+		PaymentURL       string `json:"payment_url,omitempty"`
+	}
+
+	// OrderReceiptResponse is what the buyer paid for an order and what was
+	// given back since. Net is Total minus Refunded.
+	OrderReceiptResponse struct {
+		Order    OrderResponse    `json:"order"`
+		Refunds  []RefundResponse `json:"refunds"`
+		Refunded int              `json:"refunded"`
+		Net      int              `json:"net"`
+	}
+)
@@ -1,20 +1,66 @@
 package dto
 
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+)
+
 type (
+	// PaginationRequest pages by offset unless Cursor is sent. A cursor,
+	// empty for the first page, switches to keyset pagination: newest rows
+	// first, continuing after the row the cursor was issued for. The
+	// service decodes it into After.
 	PaginationRequest struct {
-		Search  string `form:"search"`
-		Page    int    `form:"page"`
-		PerPage int    `form:"per_page"`
+		Search  string  `json:"search" form:"search" query:"search"`
+		Page    int     `json:"page" form:"page" query:"page"`
+		PerPage int     `json:"per_page" form:"per_page" query:"per_page"`
+		Cursor  *string `json:"cursor" form:"cursor" query:"cursor"`
+		After   *Cursor `json:"-" form:"-" query:"-"`
 	}
 
+	// Cursor is the (created_at, id) position a keyset page continues from.
+	Cursor struct {
+		CreatedAt time.Time
+		ID        string
+	}
+
+	// PaginationResponse is the meta block of list responses. Next and Prev
+	// link to the neighbouring pages and are empty when there is none.
+	// Keyset pages are not counted, so they leave Page, MaxPage and Count
+	// at zero and give NextCursor instead.
 	PaginationResponse struct {
-		Page    int   `json:"page"`
-		PerPage int   `json:"per_page"`
-		MaxPage int64 `json:"max_page"`
-		Count   int64 `json:"count"`
+		Page       int    `json:"page"`
+		PerPage    int    `json:"per_page"`
+		MaxPage    int64  `json:"max_page"`
+		Count      int64  `json:"count"`
+		HasMore    bool   `json:"has_more"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		Next       string `json:"next,omitempty"`
+		Prev       string `json:"prev,omitempty"`
 	}
 )
 
+// Validate rejects negative pages and pages larger than the server serves.
+// Zero values are left for the repositories to default.
+func (p *PaginationRequest) Validate() error {
+	if p.Page < 0 || p.PerPage < 0 {
+		return ErrInvalidPagination
+	}
+
+	if p.PerPage > constants.ENUM_PAGINATION_MAX_PER_PAGE {
+		return ErrPerPageTooLarge
+	}
+
+	if p.Cursor != nil && p.Page != 0 {
+		return ErrCursorWithPage
+	}
+
+	return nil
+}
+
 func (p *PaginationRequest) GetOffset() int {
 	return (p.Page - 1) * p.PerPage
 }
@@ -26,3 +72,41 @@ func (p *PaginationResponse) GetLimit() int {
 func (p *PaginationResponse) GetPage() int {
 	return p.Page
 }
+
+// SetLinks fills Next and Prev from the URL the page was requested with,
+// keeping its other query parameters. A page past the end links back to the
+// last one. Keyset pages only link forward.
+func (p *PaginationResponse) SetLinks(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	link := func(key string, value string) string {
+		query := u.Query()
+		query.Del("page")
+		query.Del("cursor")
+		query.Set(key, value)
+		query.Set("per_page", strconv.Itoa(p.PerPage))
+
+		next := *u
+		next.RawQuery = query.Encode()
+		return next.String()
+	}
+
+	if p.Page == 0 {
+		if p.NextCursor != "" {
+			p.Next = link("cursor", p.NextCursor)
+		}
+		return
+	}
+
+	p.HasMore = int64(p.Page) < p.MaxPage
+	if p.HasMore {
+		p.Next = link("page", strconv.Itoa(p.Page+1))
+	}
+
+	if p.Page > 1 && p.MaxPage > 0 {
+		p.Prev = link("page", strconv.FormatInt(min(int64(p.Page)-1, p.MaxPage), 10))
+	}
+}
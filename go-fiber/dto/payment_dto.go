@@ -0,0 +1,23 @@
+package dto
+
+type (
+	PaymentChargeRequest struct {
+		Reference     string `json:"reference"`
+		Amount        int    `json:"amount"`
+		Currency      string `json:"currency"`
+		Description   string `json:"description"`
+		CustomerName  string `json:"customer_name"`
+		CustomerEmail string `json:"customer_email"`
+	}
+
+	PaymentChargeResponse struct {
+		Reference  string `json:"reference"`
+		PaymentURL string `json:"payment_url"`
+		Status     string `json:"status"`
+	}
+
+	PaymentNotification struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+	}
+)
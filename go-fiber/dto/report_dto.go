@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+type (
+	// SalesReportRequest picks how sales are grouped and which ones count.
+	// From and To are inclusive dates in Timezone, which also decides the
+	// day a sale falls on. AuthorID is set by the controller to limit
+	// organizers to their own events.
+	SalesReportRequest struct {
+		GroupBy  string `json:"group_by" query:"group_by"`
+		EventID  string `json:"event_id" query:"event_id"`
+		From     string `json:"from" query:"from"`
+		To       string `json:"to" query:"to"`
+		Timezone string `json:"timezone" query:"timezone"`
+		AuthorID string `json:"-" query:"-"`
+	}
+
+	// SalesReportFilter is a SalesReportRequest with its dates resolved to
+	// the instants the range starts and ends at. Zero times leave that side
+	// of the range open.
+	SalesReportFilter struct {
+		GroupBy  string
+		EventID  string
+		AuthorID string
+		From     time.Time
+		To       time.Time
+		Timezone string
+	}
+
+	// SalesReportRow sums the sales of one group. Sales count on the day
+	// they were paid and refunds on the day they were made, so a row can
+	// hold refunds of tickets sold outside the range.
+	SalesReportRow struct {
+		EventID         string `json:"event_id,omitempty"`
+		EventName       string `json:"event_name,omitempty"`
+		TierID          string `json:"tier_id,omitempty"`
+		TierName        string `json:"tier_name,omitempty"`
+		Date            string `json:"date,omitempty"`
+		Currency        string `json:"currency"`
+		TicketsSold     int    `json:"tickets_sold"`
+		TicketsRefunded int    `json:"tickets_refunded"`
+		Gross           int    `json:"gross"`
+		Refunds         int    `json:"refunds"`
+		Net             int    `json:"net"`
+	}
+
+	SalesReportResponse struct {
+		GroupBy  string           `json:"group_by"`
+		From     string           `json:"from,omitempty"`
+		To       string           `json:"to,omitempty"`
+		Timezone string           `json:"timezone"`
+		Rows     []SalesReportRow `json:"rows"`
+	}
+)
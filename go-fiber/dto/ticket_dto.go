@@ -0,0 +1,90 @@
+package dto
+
+import "time"
+
+type (
+	TicketResponse struct {
+		ID            string `json:"id"`
+		Code          string `json:"code"`
+		TransactionID string `json:"transaction_id"`
+		EventID       string `json:"event_id"`
+		EventName     string `json:"event_name"`
+		OwnerID       string `json:"owner_id"`
+		Status        string `json:"status"`
+		Token         string `json:"token,omitempty"`
+		IssuedAt      string `json:"issued_at"`
+		VoidedAt      string `json:"voided_at,omitempty"`
+		UsedAt        string `json:"used_at,omitempty"`
+		CheckedInBy   string `json:"checked_in_by,omitempty"`
+	}
+
+	// TicketCheckInRequest identifies a ticket either by its plain code or by
+	// the signed token encoded in its QR image. ScannedAt is only honoured for
+	// check-ins synced from an offline scanner.
+	TicketCheckInRequest struct {
+		EventID   string     `json:"event_id" form:"event_id"`
+		Code      string     `json:"code" form:"code"`
+		Token     string     `json:"token" form:"token"`
+		ScannedAt *time.Time `json:"scanned_at" form:"scanned_at"`
+	}
+
+	TicketBatchCheckInRequest struct {
+		EventID  string                 `json:"event_id" form:"event_id"`
+		CheckIns []TicketCheckInRequest `json:"check_ins" form:"check_ins"`
+	}
+
+	TicketCheckInResult struct {
+		Code   string          `json:"code"`
+		Result string          `json:"result"`
+		Error  string          `json:"error,omitempty"`
+		Ticket *TicketResponse `json:"ticket,omitempty"`
+	}
+
+	TicketBatchCheckInResponse struct {
+		Accepted int                   `json:"accepted"`
+		Rejected int                   `json:"rejected"`
+		Results  []TicketCheckInResult `json:"results"`
+	}
+
+	TicketSigningKeyResponse struct {
+		EventID   string `json:"event_id"`
+		Algorithm string `json:"algorithm"`
+		Key       string `json:"key"`
+	}
+
+	// TicketTransferRequest names the recipient either by user id or by
+	// email. An email without an account gets an invite to register.
+	// Several tickets are sent together by listing their Codes.
+	TicketTransferRequest struct {
+		Code       string   `json:"-"`
+		Codes      []string `json:"codes" form:"codes"`
+		FromUserID string   `json:"-"`
+		ToUserID   string   `json:"user_id" form:"user_id"`
+		Email      string   `json:"email" form:"email"`
+	}
+
+	// TicketTransferResponse leaves out the ticket code, which the recipient
+	// only gets once the ticket is reissued to them.
+	TicketTransferResponse struct {
+		ID           string `json:"id"`
+		TicketID     string `json:"ticket_id"`
+		EventID      string `json:"event_id"`
+		EventName    string `json:"event_name"`
+		FromUserID   string `json:"from_user_id"`
+		FromUserName string `json:"from_user_name"`
+		ToUserID     string `json:"to_user_id,omitempty"`
+		ToEmail      string `json:"to_email"`
+		Status       string `json:"status"`
+		CreatedAt    string `json:"created_at"`
+		AcceptedAt   string `json:"accepted_at,omitempty"`
+		ClosedAt     string `json:"closed_at,omitempty"`
+	}
+
+	TicketHistoryResponse struct {
+		TicketID  string                   `json:"ticket_id"`
+		IssuedTo  string                   `json:"issued_to"`
+		IssuedAt  string                   `json:"issued_at"`
+		OwnerID   string                   `json:"owner_id"`
+		Transfers []TicketTransferResponse `json:"transfers"`
+	}
+)
@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+type (
+	TicketTierRequest struct {
+		Name         string     `json:"name"`
+		Price        int        `json:"price"`
+		Capacity     int        `json:"capacity"`
+		SaleStartsAt *time.Time `json:"sale_starts_at"`
+		SaleEndsAt   *time.Time `json:"sale_ends_at"`
+	}
+
+	// TicketTierUpdateRequest only changes the fields that are sent.
+	TicketTierUpdateRequest struct {
+		Name         string     `json:"name"`
+		Price        *int       `json:"price"`
+		Capacity     *int       `json:"capacity"`
+		SaleStartsAt *time.Time `json:"sale_starts_at"`
+		SaleEndsAt   *time.Time `json:"sale_ends_at"`
+	}
+
+	TicketTierResponse struct {
+		ID           string `json:"id"`
+		EventID      string `json:"event_id"`
+		Name         string `json:"name"`
+		Price        int    `json:"price"`
+		Capacity     int    `json:"capacity"`
+		Availability int    `json:"availability"`
+		SaleStartsAt string `json:"sale_starts_at,omitempty"`
+		SaleEndsAt   string `json:"sale_ends_at,omitempty"`
+		OnSale       bool   `json:"on_sale"`
+	}
+)
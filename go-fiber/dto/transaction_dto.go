@@ -7,20 +7,55 @@ import (
 type (
 	TransactionCreateRequest struct {
 		EventID string `json:"event_id"`
+		TierID  string `json:"tier_id"`
 		BuyerID string `json:"buyer_id"`
 		Amount  int    `json:"amount"`
+
+		// VoucherCode is a shorthand for a single entry of VoucherCodes.
+		VoucherCode  string   `json:"voucher_code"`
+		VoucherCodes []string `json:"voucher_codes"`
 	}
 
 	TransactionResponse struct {
 		ID         string `json:"id"`
+		OrderID    string `json:"order_id"`
 		BuyerID    string `json:"buyer_id"`
 		BuyerName  string `json:"buyer_name"`
 		BuyerEmail string `json:"buyer_email"`
 		EventID    string `json:"event_id"`
 		EventName  string `json:"event_name"`
 		EventPrice int    `json:"event_price"`
+		TierID     string `json:"tier_id"`
+		TierName   string `json:"tier_name"`
 		Amount     int    `json:"amount"`
+		UnitPrice  int    `json:"unit_price"`
+		Subtotal   int    `json:"subtotal"`
+		Discount   int    `json:"discount"`
+		Fees       int    `json:"fees"`
+		Total      int    `json:"total"`
+		Currency   string `json:"currency"`
+		Status     string `json:"status"`
 		BuyedAt    string `json:"buyed_at"`
+
+		PaidAt      string `json:"paid_at,omitempty"`
+		CancelledAt string `json:"cancelled_at,omitempty"`
+		RefundedAt  string `json:"refunded_at,omitempty"`
+		ExpiredAt   string `json:"expired_at,omitempty"`
+		FailedAt    string `json:"failed_at,omitempty"`
+
+		PaymentProvider  string `json:"payment_provider,omitempty"`
+		PaymentReference string `json:"payment_reference,omitempty"`
+		PaymentURL       string `json:"payment_url,omitempty"`
+	}
+
+	// TransactionPaginationRequest narrows the listing to the purchases of
+	// BuyerID or to the events organized by AuthorID. Both are set by the
+	// controller from the caller, never from the request.
+	TransactionPaginationRequest struct {
+		PaginationRequest
+		Status   string `json:"status" form:"status" query:"status"`
+		BuyerID  string `json:"-" query:"-"`
+		AuthorID string `json:"-" query:"-"`
 	}
 
 	TransactionPaginationResponse struct {
@@ -37,8 +72,36 @@ type (
 		Amount int `json:"amount"`
 	}
 
+	TransactionStatusUpdateRequest struct {
+		Status string `json:"status"`
+	}
+
+	TransactionCancelRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	TransactionRefundRequest struct {
+		Quantity int    `json:"quantity"`
+		Reason   string `json:"reason"`
+	}
+
+	RefundResponse struct {
+		ID            string `json:"id"`
+		TransactionID string `json:"transaction_id"`
+		Quantity      int    `json:"quantity"`
+		Amount        int    `json:"amount"`
+		Reason        string `json:"reason"`
+		Status        string `json:"status"`
+		RefundedAt    string `json:"refunded_at"`
+	}
+
+	TransactionRefundResponse struct {
+		Transaction TransactionResponse `json:"transaction"`
+		Refund      *RefundResponse     `json:"refund,omitempty"`
+	}
+
 	TransactionByIdRequest struct {
-		ID string `json:"id"`
+		ID string `json:"id" query:"id"`
 	}
 
 	TransactionUpdateResponse struct {
@@ -47,7 +110,15 @@ type (
 		BuyerEmail string `json:"buyer_email"`
 		EventName  string `json:"event_name"`
 		EventPrice int    `json:"event_price"`
+		TierName   string `json:"tier_name"`
 		Amount     int    `json:"amount"`
+		UnitPrice  int    `json:"unit_price"`
+		Subtotal   int    `json:"subtotal"`
+		Discount   int    `json:"discount"`
+		Fees       int    `json:"fees"`
+		Total      int    `json:"total"`
+		Currency   string `json:"currency"`
+		Status     string `json:"status"`
 		BuyedAt    string `json:"buyed_at"`
 	}
 )
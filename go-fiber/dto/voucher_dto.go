@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/tapeds/go-fiber-template/entity"
+)
+
+type (
+	VoucherCreateRequest struct {
+		Code                  string     `json:"code"`
+		Description           string     `json:"description"`
+		DiscountType          string     `json:"discount_type"`
+		DiscountValue         int        `json:"discount_value"`
+		EventID               string     `json:"event_id"`
+		TierID                string     `json:"tier_id"`
+		MaxRedemptions        int        `json:"max_redemptions"`
+		MaxRedemptionsPerUser int        `json:"max_redemptions_per_user"`
+		ValidFrom             *time.Time `json:"valid_from"`
+		ValidUntil            *time.Time `json:"valid_until"`
+		Stackable             bool       `json:"stackable"`
+	}
+
+	// VoucherUpdateRequest only changes the fields that are sent. The code
+	// and scope of a voucher are fixed once it exists.
+	VoucherUpdateRequest struct {
+		Description           *string    `json:"description"`
+		DiscountType          *string    `json:"discount_type"`
+		DiscountValue         *int       `json:"discount_value"`
+		MaxRedemptions        *int       `json:"max_redemptions"`
+		MaxRedemptionsPerUser *int       `json:"max_redemptions_per_user"`
+		ValidFrom             *time.Time `json:"valid_from"`
+		ValidUntil            *time.Time `json:"valid_until"`
+		Stackable             *bool      `json:"stackable"`
+	}
+
+	VoucherResponse struct {
+		ID                    string `json:"id"`
+		Code                  string `json:"code"`
+		Description           string `json:"description"`
+		DiscountType          string `json:"discount_type"`
+		DiscountValue         int    `json:"discount_value"`
+		EventID               string `json:"event_id,omitempty"`
+		TierID                string `json:"tier_id,omitempty"`
+		MaxRedemptions        int    `json:"max_redemptions"`
+		MaxRedemptionsPerUser int    `json:"max_redemptions_per_user"`
+		Redemptions           int    `json:"redemptions"`
+		ValidFrom             string `json:"valid_from,omitempty"`
+		ValidUntil            string `json:"valid_until,omitempty"`
+		Stackable             bool   `json:"stackable"`
+	}
+
+	VoucherPaginationResponse struct {
+		Data               []VoucherResponse `json:"data"`
+		PaginationResponse `json:"meta"`
+	}
+
+	GetAllVoucherRepositoryResponse struct {
+		Vouchers []entity.Voucher
+		PaginationResponse
+	}
+)
@@ -0,0 +1,34 @@
+package dto
+
+type (
+	WaitlistJoinRequest struct {
+		EventID  string `json:"event_id"`
+		TierID   string `json:"tier_id"`
+		Quantity int    `json:"quantity"`
+		UserID   string `json:"user_id"`
+	}
+
+	WaitlistEntryResponse struct {
+		ID        string `json:"id"`
+		EventID   string `json:"event_id"`
+		EventName string `json:"event_name"`
+		TierID    string `json:"tier_id"`
+		TierName  string `json:"tier_name"`
+		Quantity  int    `json:"quantity"`
+		Status    string `json:"status"`
+		CreatedAt string `json:"created_at"`
+
+		// Position is the place in line of a waiting entry, starting at 1.
+		Position       int    `json:"position,omitempty"`
+		OfferExpiresAt string `json:"offer_expires_at,omitempty"`
+		OrderID        string `json:"order_id,omitempty"`
+	}
+
+	// WaitlistHintResponse is sent with a sold out purchase so clients can
+	// offer to join the waitlist of the tier instead.
+	WaitlistHintResponse struct {
+		EventID      string `json:"event_id"`
+		TierID       string `json:"tier_id,omitempty"`
+		WaitlistOpen bool   `json:"waitlist_open"`
+	}
+)
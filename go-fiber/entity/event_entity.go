@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,43 @@ type Event struct {
 	Price       int       `json:"price"`
 	Capacity    int       `json:"capacity"`
 	Availabilty int       `json:"availabilty"`
+	Currency    string    `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+
+	// Events start as drafts and only published events are listed to
+	// buyers and sold.
+	Status      string     `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	PublishedAt *time.Time `gorm:"type:timestamp with time zone" json:"published_at"`
+	CancelledAt *time.Time `gorm:"type:timestamp with time zone" json:"cancelled_at"`
+
+	// Schedule: StartsAt and EndsAt are instants, Timezone is the IANA zone
+	// the event takes place in and is used to show them in local time.
+	// Online events give a MeetingURL instead of or next to a venue.
+	StartsAt    *time.Time `gorm:"type:timestamp with time zone;index" json:"starts_at"`
+	EndsAt      *time.Time `gorm:"type:timestamp with time zone;index" json:"ends_at"`
+	Timezone    string     `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	Venue       string     `gorm:"type:varchar(255)" json:"venue"`
+	Address     string     `gorm:"type:text" json:"address"`
+	Description string     `gorm:"type:text" json:"description"`
+	MeetingURL  string     `gorm:"type:varchar(2048)" json:"meeting_url"`
+
+	// Price, Capacity and Availabilty summarize the tiers: the lowest tier
+	// price and the totals of their seats.
+	Tiers []TicketTier `gorm:"foreignkey:EventID;references:ID" json:"tiers"`
+
+	// Refund policy: paid tickets can be refunded until RefundDeadline (no
+	// deadline when nil) and RefundPercentage of the price is given back,
+	// all of it unless the organizer chose otherwise.
+	RefundDeadline   *time.Time `gorm:"type:timestamp with time zone" json:"refund_deadline"`
+	RefundPercentage int        `gorm:"not null;default:100" json:"refund_percentage"`
+
+	// Tickets can be handed to other users unless the organizer turned
+	// transfers off.
+	TransfersDisabled bool `gorm:"not null;default:false" json:"transfers_disabled"`
+
+	// Purchase limits, zero means unlimited: tickets of this event in one
+	// order and tickets one user may hold across all their purchases.
+	MaxTicketsPerOrder int `gorm:"not null;default:0" json:"max_tickets_per_order"`
+	MaxTicketsPerUser  int `gorm:"not null;default:0" json:"max_tickets_per_user"`
 
 	Timestamp
 }
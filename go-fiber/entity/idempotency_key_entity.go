@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey remembers the response given to a request sent with an
+// Idempotency-Key header. StatusCode stays zero while the request is running.
+type IdempotencyKey struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID      string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	Method      string    `gorm:"type:varchar(10);not null" json:"method"`
+	Path        string    `gorm:"not null" json:"path"`
+	Fingerprint string    `gorm:"type:varchar(64);not null" json:"fingerprint"`
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"`
+	Response    string    `gorm:"type:text" json:"response"`
+	ExpiresAt   time.Time `gorm:"type:timestamp with time zone;not null;index" json:"expires_at"`
+	Timestamp
+}
+
+func (e *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invoice is issued once for every paid transaction. Numbers run without
+// gaps per calendar year, e.g. INV/2024/000042.
+type Invoice struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TransactionID string      `gorm:"type:uuid;not null;uniqueIndex" json:"transaction_id"`
+	Transaction   Transaction `gorm:"foreignkey:TransactionID;references:ID" json:"transaction"`
+	Number        string      `gorm:"type:varchar(32);not null;uniqueIndex" json:"number"`
+	Year          int         `gorm:"not null" json:"year"`
+	Sequence      int         `gorm:"not null" json:"sequence"`
+	IssuedAt      time.Time   `gorm:"type:timestamp with time zone;not null" json:"issued_at"`
+
+	// The billed line is copied from the transaction when it is paid, in
+	// minor units of Currency, so refunds and later changes to the event do
+	// not rewrite the invoice.
+	Item      string `gorm:"type:varchar(255);not null;default:''" json:"item"`
+	Quantity  int    `gorm:"not null;default:0" json:"quantity"`
+	UnitPrice int    `gorm:"not null;default:0" json:"unit_price"`
+	Subtotal  int    `gorm:"not null;default:0" json:"subtotal"`
+	Discount  int    `gorm:"not null;default:0" json:"discount"`
+	Fees      int    `gorm:"not null;default:0" json:"fees"`
+	Total     int    `gorm:"not null;default:0" json:"total"`
+	Currency  string `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+
+	// FilePath is where the PDF lives under the private storage, which is
+	// not served. It stays empty until the PDF was rendered after the
+	// payment committed.
+	FilePath string `gorm:"type:varchar(255)" json:"-"`
+
+	Timestamp
+}
+
+func (e *Invoice) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
+
+// InvoiceSequence holds the last invoice number handed out in a year.
+type InvoiceSequence struct {
+	Year       int `gorm:"primary_key;autoIncrement:false" json:"year"`
+	LastNumber int `gorm:"not null;default:0" json:"last_number"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Order groups the transactions bought in one checkout. Every transaction is
+// a line item for one event and tier, and the order is what gets charged,
+// so its totals are the sums of its items. An order moves through the same
+// statuses as its items.
+type Order struct {
+	ID      uuid.UUID     `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	BuyerID string        `gorm:"type:uuid;not null;index" json:"buyer_id"`
+	Buyer   User          `gorm:"foreignkey:BuyerID;references:ID" json:"buyer"`
+	Status  string        `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Items   []Transaction `gorm:"foreignkey:OrderID;references:ID" json:"items"`
+
+	Subtotal int    `gorm:"not null;default:0" json:"subtotal"`
+	Discount int    `gorm:"not null;default:0" json:"discount"`
+	Fees     int    `gorm:"not null;default:0" json:"fees"`
+	Total    int    `gorm:"not null;default:0" json:"total"`
+	Currency string `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+
+	PaidAt      *time.Time `gorm:"type:timestamp with time zone" json:"paid_at"`
+	CancelledAt *time.Time `gorm:"type:timestamp with time zone" json:"cancelled_at"`
+	FailedAt    *time.Time `gorm:"type:timestamp with time zone" json:"failed_at"`
+
+	PaymentProvider  string `gorm:"type:varchar(50)" json:"payment_provider"`
+	PaymentReference string `gorm:"type:varchar(255);index" json:"payment_reference"`
+	PaymentURL       string `json:"payment_url"`
+	Timestamp
+}
+
+func (e *Order) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Refund struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TransactionID string      `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   Transaction `gorm:"foreignkey:TransactionID;references:ID" json:"transaction"`
+	Quantity      int         `gorm:"not null" json:"quantity"`
+	Amount        int         `gorm:"not null" json:"amount"`
+	Reason        string      `json:"reason"`
+
+	// Refunds are recorded as pending together with the change of the
+	// purchase and sent to the payment provider once that committed.
+	Status string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	SentAt *time.Time `gorm:"type:timestamp with time zone" json:"sent_at"`
+
+	Timestamp
+}
+
+func (e *Refund) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SeatHold keeps the seats of a pending purchase out of sale until ExpiresAt.
+// It is converted when the purchase is paid and released when it ends any
+// other way; holds still active after they expired are released by the
+// hold sweeper.
+type SeatHold struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TransactionID string      `gorm:"type:uuid;not null;uniqueIndex" json:"transaction_id"`
+	Transaction   Transaction `gorm:"foreignkey:TransactionID;references:ID" json:"transaction"`
+	OrderID       string      `gorm:"type:uuid;not null;index" json:"order_id"`
+	EventID       string      `gorm:"type:uuid;not null" json:"event_id"`
+	TierID        string      `gorm:"type:uuid" json:"tier_id"`
+	Quantity      int         `gorm:"not null" json:"quantity"`
+	Status        string      `gorm:"type:varchar(20);not null;default:'active';index:idx_seat_holds_status_expires_at,priority:1" json:"status"`
+	ExpiresAt     time.Time   `gorm:"type:timestamp with time zone;not null;index:idx_seat_holds_status_expires_at,priority:2" json:"expires_at"`
+	SettledAt     *time.Time  `gorm:"type:timestamp with time zone" json:"settled_at"`
+	Timestamp
+}
+
+func (e *SeatHold) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Ticket struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TransactionID string      `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   Transaction `gorm:"foreignkey:TransactionID;references:ID" json:"transaction"`
+	EventID       string      `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event         Event       `gorm:"foreignkey:EventID;references:ID" json:"event"`
+	OwnerID       string      `gorm:"type:uuid;not null;index" json:"owner_id"`
+	Owner         User        `gorm:"foreignkey:OwnerID;references:ID" json:"owner"`
+	Code          string      `gorm:"type:varchar(64);not null;uniqueIndex" json:"code"`
+	Status        string      `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	VoidedAt      *time.Time  `gorm:"type:timestamp with time zone" json:"voided_at"`
+	UsedAt        *time.Time  `gorm:"type:timestamp with time zone" json:"used_at"`
+	CheckedInBy   *string     `gorm:"type:uuid" json:"checked_in_by"`
+	Timestamp
+}
+
+func (e *Ticket) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
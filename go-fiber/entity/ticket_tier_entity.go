@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketTier is a category of tickets sold for an event, such as VIP or
+// Early Bird. The capacity and availability of an event are the totals of
+// its tiers.
+type TicketTier struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	EventID      string     `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event        Event      `gorm:"foreignkey:EventID;references:ID;constraint:OnDelete:CASCADE;" json:"event"`
+	Name         string     `gorm:"type:varchar(100);not null" json:"name"`
+	Price        int        `gorm:"not null" json:"price"`
+	Capacity     int        `gorm:"not null" json:"capacity"`
+	Availability int        `gorm:"not null" json:"availability"`
+	SaleStartsAt *time.Time `gorm:"type:timestamp with time zone" json:"sale_starts_at"`
+	SaleEndsAt   *time.Time `gorm:"type:timestamp with time zone" json:"sale_ends_at"`
+	Timestamp
+}
+
+func (e *TicketTier) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
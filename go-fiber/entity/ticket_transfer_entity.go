@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketTransfer hands a ticket from one owner to another. Recipients
+// without an account are invited by email and matched by that address once
+// they register. Accepted transfers make up the ownership history of a
+// ticket.
+type TicketTransfer struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TicketID   string     `gorm:"type:uuid;not null;index;uniqueIndex:idx_ticket_transfers_pending,where:status = 'pending'" json:"ticket_id"`
+	Ticket     Ticket     `gorm:"foreignkey:TicketID;references:ID" json:"ticket"`
+	EventID    string     `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event      Event      `gorm:"foreignkey:EventID;references:ID" json:"event"`
+	FromUserID string     `gorm:"type:uuid;not null;index" json:"from_user_id"`
+	FromUser   User       `gorm:"foreignkey:FromUserID;references:ID" json:"from_user"`
+	ToUserID   *string    `gorm:"type:uuid;index" json:"to_user_id"`
+	ToEmail    string     `gorm:"type:varchar(255);not null;index" json:"to_email"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	AcceptedAt *time.Time `gorm:"type:timestamp with time zone" json:"accepted_at"`
+	ClosedAt   *time.Time `gorm:"type:timestamp with time zone" json:"closed_at"`
+	Timestamp
+}
+
+func (e *TicketTransfer) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -1,17 +1,50 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Transaction struct {
-	ID      uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	BuyerID string    `gorm:"type:uuid;not null" json:"buyer_id"`
-	Buyer   User      `gorm:"foreignkey:BuyerID;references:ID" json:"buyer"`
-	EventID string    `gorm:"type:uuid;not null" json:"event_id"`
-	Event   Event     `gorm:"foreignkey:EventID;references:ID" json:"event"`
-	Amount  int       `gorm:"not null" json:"amount"`
+	ID      uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	OrderID string     `gorm:"type:uuid;index" json:"order_id"`
+	BuyerID string     `gorm:"type:uuid;not null" json:"buyer_id"`
+	Buyer   User       `gorm:"foreignkey:BuyerID;references:ID" json:"buyer"`
+	EventID string     `gorm:"type:uuid;not null" json:"event_id"`
+	Event   Event      `gorm:"foreignkey:EventID;references:ID" json:"event"`
+	TierID  string     `gorm:"type:uuid;index" json:"tier_id"`
+	Tier    TicketTier `gorm:"foreignkey:TierID;references:ID" json:"tier"`
+	Amount  int        `gorm:"not null" json:"amount"`
+	Status  string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+
+	// Prices are snapshotted in minor units of Currency when the purchase is
+	// made, so later changes to the event do not rewrite history. Amount is
+	// the quantity, Subtotal is UnitPrice times Amount and Total is what the
+	// buyer pays: Subtotal - Discount + Fees.
+	UnitPrice int    `gorm:"not null;default:0" json:"unit_price"`
+	Subtotal  int    `gorm:"not null;default:0" json:"subtotal"`
+	Discount  int    `gorm:"not null;default:0" json:"discount"`
+	Fees      int    `gorm:"not null;default:0" json:"fees"`
+	Total     int    `gorm:"not null;default:0" json:"total"`
+	Currency  string `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+
+	// PaidAmount and PaidTotal keep the quantity and total at the moment the
+	// transaction was paid, before partial refunds lowered Amount and Total.
+	// Sales reports count revenue from them.
+	PaidAmount int `gorm:"not null;default:0" json:"paid_amount"`
+	PaidTotal  int `gorm:"not null;default:0" json:"paid_total"`
+
+	PaidAt      *time.Time `gorm:"type:timestamp with time zone;index" json:"paid_at"`
+	CancelledAt *time.Time `gorm:"type:timestamp with time zone" json:"cancelled_at"`
+	RefundedAt  *time.Time `gorm:"type:timestamp with time zone" json:"refunded_at"`
+	ExpiredAt   *time.Time `gorm:"type:timestamp with time zone" json:"expired_at"`
+	FailedAt    *time.Time `gorm:"type:timestamp with time zone" json:"failed_at"`
+
+	PaymentProvider  string `gorm:"type:varchar(50)" json:"payment_provider"`
+	PaymentReference string `gorm:"type:varchar(255);index" json:"payment_reference"`
+	PaymentURL       string `json:"payment_url"`
 	Timestamp
 }
 
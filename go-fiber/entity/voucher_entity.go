@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Voucher is a promo code giving a percentage or fixed discount. A zero
+// limit means the voucher can be redeemed without limit, and a voucher
+// without an event or tier applies to every purchase.
+type Voucher struct {
+	ID                    uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Code                  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"code"`
+	Description           string     `json:"description"`
+	DiscountType          string     `gorm:"type:varchar(20);not null" json:"discount_type"`
+	DiscountValue         int        `gorm:"not null" json:"discount_value"`
+	EventID               *string    `gorm:"type:uuid;index" json:"event_id"`
+	TierID                *string    `gorm:"type:uuid;index" json:"tier_id"`
+	MaxRedemptions        int        `gorm:"not null;default:0" json:"max_redemptions"`
+	MaxRedemptionsPerUser int        `gorm:"not null;default:0" json:"max_redemptions_per_user"`
+	Redemptions           int        `gorm:"not null;default:0" json:"redemptions"`
+	ValidFrom             *time.Time `gorm:"type:timestamp with time zone" json:"valid_from"`
+	ValidUntil            *time.Time `gorm:"type:timestamp with time zone" json:"valid_until"`
+	Stackable             bool       `gorm:"not null;default:false" json:"stackable"`
+	Timestamp
+}
+
+func (e *Voucher) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
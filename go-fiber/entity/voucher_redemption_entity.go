@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VoucherRedemption records a voucher used on a transaction and the
+// discount it gave. Redemptions are released, not deleted, when the
+// purchase does not go through.
+type VoucherRedemption struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	VoucherID     string      `gorm:"type:uuid;not null;index:idx_voucher_redemptions_voucher_user" json:"voucher_id"`
+	Voucher       Voucher     `gorm:"foreignkey:VoucherID;references:ID" json:"voucher"`
+	UserID        string      `gorm:"type:uuid;not null;index:idx_voucher_redemptions_voucher_user" json:"user_id"`
+	TransactionID string      `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   Transaction `gorm:"foreignkey:TransactionID;references:ID" json:"transaction"`
+	Discount      int         `gorm:"not null" json:"discount"`
+	ReleasedAt    *time.Time  `gorm:"type:timestamp with time zone" json:"released_at"`
+	Timestamp
+}
+
+func (e *VoucherRedemption) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitlistEntry is a user waiting for seats of a sold out ticket tier.
+// Entries are served in the order they were created. When seats free up the
+// next entry that fits gets an offer: its seats are taken off sale until
+// OfferExpiresAt, and after that they pass to the next entry.
+type WaitlistEntry struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	EventID        string     `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event          Event      `gorm:"foreignkey:EventID;references:ID" json:"event"`
+	TierID         string     `gorm:"type:uuid;not null;index:idx_waitlist_entries_tier_status,priority:1" json:"tier_id"`
+	Tier           TicketTier `gorm:"foreignkey:TierID;references:ID" json:"tier"`
+	UserID         string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	User           User       `gorm:"foreignkey:UserID;references:ID" json:"user"`
+	Quantity       int        `gorm:"not null" json:"quantity"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'waiting';index:idx_waitlist_entries_tier_status,priority:2" json:"status"`
+	OfferedAt      *time.Time `gorm:"type:timestamp with time zone" json:"offered_at"`
+	OfferExpiresAt *time.Time `gorm:"type:timestamp with time zone;index" json:"offer_expires_at"`
+	ClaimedAt      *time.Time `gorm:"type:timestamp with time zone" json:"claimed_at"`
+	OrderID        *string    `gorm:"type:uuid" json:"order_id"`
+	Timestamp
+}
+
+func (e *WaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -23,9 +24,21 @@ func main() {
 		return
 	}
 
+	paymentProviders, err := service.NewPaymentProvidersFromEnv()
+	if err != nil {
+		log.Fatalf("error setting up payment providers: %v", err)
+	}
+	if len(paymentProviders) == 0 {
+		log.Println("no payment provider is enabled, checkout is unavailable")
+	}
+
 	var (
 		jwtService service.JWTService = service.NewJWTService()
 
+		//Idempotency
+		idempotencyRepository repository.IdempotencyRepository = repository.NewIdempotencyRepository(db)
+		idempotencyService    service.IdempotencyService       = service.NewIdempotencyService(idempotencyRepository)
+
 		//User Group
 		// Repository
 		userRepository repository.UserRepository = repository.NewUserRepository(db)
@@ -35,28 +48,71 @@ func main() {
 		userController controller.UserController = controller.NewUserController(userService)
 
 		//Event Group
-		eventRepository repository.EventRepository = repository.NewEventRepository(db)
+		eventRepository      repository.EventRepository      = repository.NewEventRepository(db)
+		ticketTierRepository repository.TicketTierRepository = repository.NewTicketTierRepository(db)
+		waitlistRepository   repository.WaitlistRepository   = repository.NewWaitlistRepository(db)
 		// Service
-		eventService service.EventService = service.NewEventService(eventRepository, jwtService)
+		waitlistService   service.WaitlistService   = service.NewWaitlistService(waitlistRepository, eventRepository, ticketTierRepository, userRepository, db)
+		eventService      service.EventService      = service.NewEventService(eventRepository, ticketTierRepository, jwtService, waitlistService, db)
+		ticketTierService service.TicketTierService = service.NewTicketTierService(ticketTierRepository, eventRepository, waitlistService, db)
 		// Controller
-		eventController controller.EventController = controller.NewEventController(eventService, userService)
+		ticketTierController controller.TicketTierController = controller.NewTicketTierController(ticketTierService, userService)
+
+		//Payment
+		paymentService service.PaymentService = service.NewPaymentService(paymentProviders...)
 
 		//Transaction
-		transactionRepository repository.TransactionRepository = repository.NewTransactionRepository(db)
+		transactionRepository    repository.TransactionRepository    = repository.NewTransactionRepository(db)
+		orderRepository          repository.OrderRepository          = repository.NewOrderRepository(db)
+		seatHoldRepository       repository.SeatHoldRepository       = repository.NewSeatHoldRepository(db)
+		refundRepository         repository.RefundRepository         = repository.NewRefundRepository(db)
+		ticketRepository         repository.TicketRepository         = repository.NewTicketRepository(db)
+		ticketTransferRepository repository.TicketTransferRepository = repository.NewTicketTransferRepository(db)
+		voucherRepository        repository.VoucherRepository        = repository.NewVoucherRepository(db)
+		invoiceRepository        repository.InvoiceRepository        = repository.NewInvoiceRepository(db)
 		// Service
-		transactionService service.TransactionService = service.NewTransactionService(transactionRepository, eventRepository, userRepository)
+		transactionService service.TransactionService = service.NewTransactionService(transactionRepository, orderRepository, seatHoldRepository, waitlistRepository, eventRepository, ticketTierRepository, userRepository, refundRepository, ticketRepository, voucherRepository, invoiceRepository, paymentService, waitlistService, jwtService, db)
+		ticketService      service.TicketService      = service.NewTicketService(ticketRepository, ticketTransferRepository, eventRepository, userRepository, jwtService, db)
+		voucherService     service.VoucherService     = service.NewVoucherService(voucherRepository, eventRepository, ticketTierRepository)
 		// Controller
-		transactionController controller.TransactionController = controller.NewTransactionController(transactionService, userService, eventService)
+		eventController       controller.EventController       = controller.NewEventController(eventService, userService, transactionService)
+		transactionController controller.TransactionController = controller.NewTransactionController(transactionService, userService)
+		paymentController     controller.PaymentController     = controller.NewPaymentController(transactionService)
+		ticketController      controller.TicketController      = controller.NewTicketController(ticketService, userService)
+		voucherController     controller.VoucherController     = controller.NewVoucherController(voucherService, userService)
+		orderController       controller.OrderController       = controller.NewOrderController(transactionService, userService)
+		waitlistController    controller.WaitlistController    = controller.NewWaitlistController(waitlistService, transactionService)
+
+		//Report
+		reportRepository repository.ReportRepository = repository.NewReportRepository(db)
+		reportService    service.ReportService       = service.NewReportService(reportRepository)
+		reportController controller.ReportController = controller.NewReportController(reportService, userService)
+
+		//Export
+		exportService    service.ExportService       = service.NewExportService(userRepository, eventRepository, transactionRepository, ticketRepository)
+		exportController controller.ExportController = controller.NewExportController(exportService, userService)
 	)
 
+	// Seats of checkouts that were never paid and of waitlist offers that
+	// were never claimed go back on sale in the background.
+	go service.NewHoldSweeper(transactionService, waitlistService, idempotencyService).Run(context.Background())
+
 	server := fiber.New()
 	server.Use(middleware.CORSMiddleware())
 	apiGroup := server.Group("/api")
 
 	// routes
 	routes.User(apiGroup, userController, jwtService)
-	routes.Event(apiGroup, eventController, jwtService)
-	routes.Transaction(apiGroup, transactionController, jwtService)
+	routes.Event(apiGroup, eventController, jwtService, idempotencyService)
+	routes.TicketTier(apiGroup, ticketTierController, jwtService, idempotencyService)
+	routes.Transaction(apiGroup, transactionController, jwtService, idempotencyService)
+	routes.Payment(apiGroup, paymentController)
+	routes.Ticket(apiGroup, ticketController, jwtService)
+	routes.Voucher(apiGroup, voucherController, jwtService, idempotencyService)
+	routes.Order(apiGroup, orderController, jwtService, idempotencyService)
+	routes.Waitlist(apiGroup, waitlistController, jwtService, idempotencyService)
+	routes.Report(apiGroup, reportController, jwtService)
+	routes.Export(apiGroup, exportController, jwtService)
 
 	server.Static("/assets", "./assets")
 
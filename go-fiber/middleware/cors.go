@@ -19,7 +19,7 @@ func CORSMiddleware() fiber.Handler {
 	return cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowCredentials: true,
-		AllowHeaders:     "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With",
+		AllowHeaders:     "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key",
 		AllowMethods:     "POST, HEAD, PATCH, OPTIONS, GET, PUT, DELETE",
 	})
 }
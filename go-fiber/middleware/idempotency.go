@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header and body. Requests without the header pass
+// through untouched. Register it after Authenticate so keys are scoped to the
+// caller.
+func Idempotency(idempotencyService service.IdempotencyService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get("Idempotency-Key")
+		if key == "" {
+			return ctx.Next()
+		}
+
+		if len(key) > 255 {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_IDEMPOTENCY_KEY, dto.ErrIdempotencyKeyTooLong.Error(), nil)
+			return ctx.Status(http.StatusBadRequest).JSON(response)
+		}
+
+		userId, _ := ctx.Locals("user_id").(string)
+
+		hash := sha256.New()
+		hash.Write([]byte(ctx.Method() + " " + ctx.Path() + "\n"))
+		hash.Write(ctx.Body())
+
+		stored, err := idempotencyService.Begin(ctx.Context(), dto.IdempotencyBeginRequest{
+			UserID:      userId,
+			Key:         key,
+			Method:      ctx.Method(),
+			Path:        ctx.Path(),
+			Fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, dto.ErrIdempotencyKeyReused):
+				status = http.StatusUnprocessableEntity
+			case errors.Is(err, dto.ErrIdempotencyKeyInProgress):
+				status = http.StatusConflict
+			}
+
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_IDEMPOTENCY_KEY, err.Error(), nil)
+			return ctx.Status(status).JSON(response)
+		}
+
+		if stored != nil {
+			ctx.Set("Idempotent-Replayed", "true")
+			ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return ctx.Status(stored.StatusCode).Send(stored.Body)
+		}
+
+		if err := ctx.Next(); err != nil {
+			idempotencyService.Release(ctx.Context(), userId, key)
+			return err
+		}
+
+		// Server errors are not final, so the client may retry them with
+		// the same key.
+		status := ctx.Response().StatusCode()
+		if status >= http.StatusInternalServerError {
+			idempotencyService.Release(ctx.Context(), userId, key)
+			return nil
+		}
+
+		if err := idempotencyService.Complete(ctx.Context(), userId, key, status, ctx.Response().Body()); err != nil {
+			idempotencyService.Release(ctx.Context(), userId, key)
+		}
+
+		return nil
+	}
+}
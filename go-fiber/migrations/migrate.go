@@ -3,6 +3,7 @@ package migrations
 import (
 	"fmt"
 
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/entity"
 	"gorm.io/gorm"
 )
@@ -21,13 +22,238 @@ func Migrate(db *gorm.DB) error {
 		}
 	}
 
+	// Transactions created before the status column existed were final
+	// purchases, so they are backfilled as paid instead of pending.
+	backfillTransactionStatus := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasColumn(&entity.Transaction{}, "status")
+
+	// Existing events keep the default policy of new events: a full refund
+	// with no deadline.
+	backfillRefundPolicy := db.Migrator().HasTable(&entity.Event{}) &&
+		!db.Migrator().HasColumn(&entity.Event{}, "refund_percentage")
+
+	// Events from before ticket tiers are sold through a single default tier
+	// holding their price and seats.
+	backfillTicketTiers := db.Migrator().HasTable(&entity.Event{}) &&
+		!db.Migrator().HasTable(&entity.TicketTier{})
+
+	// Purchases from before vouchers paid the full price of their tier.
+	backfillTransactionTotals := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasColumn(&entity.Transaction{}, "total")
+
+	// The unit price of older purchases is recovered from their subtotal,
+	// which already holds the price they were bought at.
+	backfillUnitPrice := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasColumn(&entity.Transaction{}, "unit_price")
+
+	// Purchases from before orders become orders with a single item that
+	// share the id, status and payment of the transaction.
+	backfillOrders := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasTable(&entity.Order{})
+
+	// Pending purchases from before seat holds get a fresh hold, so they
+	// are released like new checkouts if they are never paid.
+	backfillSeatHolds := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasTable(&entity.SeatHold{})
+
+	// Paid purchases from before the payment snapshot get it back from
+	// their refunds: those still paid only had partial refunds taken off,
+	// the others were refunded in full. Totals are scaled by the quantity,
+	// which is off by rounding at most.
+	backfillPaidSnapshot := db.Migrator().HasTable(&entity.Transaction{}) &&
+		!db.Migrator().HasColumn(&entity.Transaction{}, "paid_amount")
+
+	// Events from before publishing were live as soon as they were created.
+	backfillEventStatus := db.Migrator().HasTable(&entity.Event{}) &&
+		!db.Migrator().HasColumn(&entity.Event{}, "status")
+
+	// Refunds from before they were sent after the commit went out with it.
+	backfillRefundStatus := db.Migrator().HasTable(&entity.Refund{}) &&
+		!db.Migrator().HasColumn(&entity.Refund{}, "status")
+
+	// Invoices from before the billed line was kept get it back from the
+	// payment snapshot of their transaction. Discounts and fees that partial
+	// refunds lowered are scaled back up, which is off by rounding at most.
+	backfillInvoiceLines := db.Migrator().HasTable(&entity.Invoice{}) &&
+		!db.Migrator().HasColumn(&entity.Invoice{}, "quantity")
+
 	if err := db.AutoMigrate(
 		&entity.User{},
 		&entity.Event{},
+		&entity.TicketTier{},
+		&entity.Order{},
 		&entity.Transaction{},
+		&entity.SeatHold{},
+		&entity.WaitlistEntry{},
+		&entity.Refund{},
+		&entity.IdempotencyKey{},
+		&entity.Ticket{},
+		&entity.TicketTransfer{},
+		&entity.Voucher{},
+		&entity.VoucherRedemption{},
+		&entity.Invoice{},
+		&entity.InvoiceSequence{},
 	); err != nil {
 		return err
 	}
 
+	// Event search runs on a generated tsvector over the name and the
+	// description. GORM cannot declare generated columns, so it is added
+	// here and left out of the entity.
+	if err := db.Exec(`
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(name, '') || ' ' || COALESCE(description, ''))) STORED`,
+	).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_events_search_vector ON events USING GIN (search_vector)").Error; err != nil {
+		return err
+	}
+
+	// Keyset pages walk these tables by (created_at, id).
+	for _, table := range []string{"users", "events", "transactions"} {
+		if err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_created_at_id ON %s (created_at, id)", table, table)).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillTransactionStatus {
+		if err := db.Exec("UPDATE transactions SET status = ?, paid_at = created_at", constants.ENUM_TRANSACTION_STATUS_PAID).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillEventStatus {
+		if err := db.Exec("UPDATE events SET status = ?, published_at = created_at", constants.ENUM_EVENT_STATUS_PUBLISHED).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillRefundPolicy {
+		if err := db.Exec("UPDATE events SET refund_percentage = 100").Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillTicketTiers {
+		if err := db.Exec(`
+			INSERT INTO ticket_tiers (event_id, name, price, capacity, availability, created_at, updated_at)
+			SELECT id, ?, price, capacity, availabilty, created_at, NOW() FROM events`,
+			constants.ENUM_TICKET_TIER_DEFAULT,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec(`
+			UPDATE transactions SET tier_id = ticket_tiers.id
+			FROM ticket_tiers
+			WHERE ticket_tiers.event_id = transactions.event_id AND transactions.tier_id IS NULL`,
+		).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillTransactionTotals {
+		if err := db.Exec(`
+			UPDATE transactions SET
+				subtotal = amount * COALESCE(
+					(SELECT price FROM ticket_tiers WHERE ticket_tiers.id = transactions.tier_id),
+					(SELECT price FROM events WHERE events.id = transactions.event_id),
+					0),
+				discount = 0`,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec("UPDATE transactions SET total = subtotal").Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillUnitPrice {
+		if err := db.Exec("UPDATE transactions SET unit_price = COALESCE(subtotal / NULLIF(amount, 0), 0)").Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillOrders {
+		if err := db.Exec(`
+			INSERT INTO orders (id, buyer_id, status, subtotal, discount, fees, total, currency,
+				paid_at, cancelled_at, failed_at, payment_provider, payment_reference, payment_url,
+				created_at, updated_at, deleted_at)
+			SELECT id, buyer_id, status, subtotal, discount, fees, total, currency,
+				paid_at, cancelled_at, failed_at, payment_provider, payment_reference, payment_url,
+				created_at, updated_at, deleted_at
+			FROM transactions`,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec("UPDATE transactions SET order_id = id WHERE order_id IS NULL").Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillSeatHolds {
+		if err := db.Exec(`
+			INSERT INTO seat_holds (transaction_id, order_id, event_id, tier_id, quantity, status, expires_at, created_at, updated_at)
+			SELECT id, order_id, event_id, tier_id, amount, ?, NOW() + make_interval(mins => ?), NOW(), NOW()
+			FROM transactions
+			WHERE status = ? AND deleted_at IS NULL`,
+			constants.ENUM_SEAT_HOLD_STATUS_ACTIVE,
+			constants.ENUM_SEAT_HOLD_MINUTES_DEFAULT,
+			constants.ENUM_TRANSACTION_STATUS_PENDING,
+		).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillPaidSnapshot {
+		if err := db.Exec(`
+			UPDATE transactions SET
+				paid_amount = CASE WHEN transactions.status = ? THEN transactions.amount + refunded.quantity
+					ELSE GREATEST(transactions.amount, refunded.quantity) END,
+				paid_total = COALESCE(transactions.total * (CASE WHEN transactions.status = ? THEN transactions.amount + refunded.quantity
+					ELSE GREATEST(transactions.amount, refunded.quantity) END) / NULLIF(transactions.amount, 0), transactions.total)
+			FROM (
+				SELECT transactions.id, COALESCE(SUM(refunds.quantity), 0) AS quantity
+				FROM transactions LEFT JOIN refunds ON refunds.transaction_id = transactions.id AND refunds.deleted_at IS NULL
+				GROUP BY transactions.id
+			) AS refunded
+			WHERE refunded.id = transactions.id AND transactions.paid_at IS NOT NULL`,
+			constants.ENUM_TRANSACTION_STATUS_PAID,
+			constants.ENUM_TRANSACTION_STATUS_PAID,
+		).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillInvoiceLines {
+		if err := db.Exec(`
+			UPDATE invoices SET
+				item = events.name || COALESCE(' - ' || ticket_tiers.name, ''),
+				quantity = transactions.paid_amount,
+				unit_price = transactions.unit_price,
+				subtotal = transactions.unit_price * transactions.paid_amount,
+				discount = COALESCE(transactions.discount * transactions.paid_amount / NULLIF(transactions.amount, 0), transactions.discount),
+				fees = COALESCE(transactions.fees * transactions.paid_amount / NULLIF(transactions.amount, 0), transactions.fees),
+				total = transactions.paid_total,
+				currency = transactions.currency
+			FROM transactions
+			JOIN events ON events.id = transactions.event_id
+			LEFT JOIN ticket_tiers ON ticket_tiers.id = transactions.tier_id
+			WHERE transactions.id = invoices.transaction_id`,
+		).Error; err != nil {
+			return err
+		}
+	}
+
+	if backfillRefundStatus {
+		if err := db.Exec("UPDATE refunds SET status = ?, sent_at = created_at", constants.ENUM_REFUND_STATUS_SENT).Error; err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -1,6 +1,9 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"github.com/tapeds/go-fiber-template/dto"
+	"gorm.io/gorm"
+)
 
 func Paginate(page, perPage int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -8,3 +11,15 @@ func Paginate(page, perPage int) func(db *gorm.DB) *gorm.DB {
 		return db.Offset(offset).Limit(perPage)
 	}
 }
+
+// PaginateAfter pages by (created_at, id), newest first, continuing after
+// the row at after. It fetches one row more than perPage so callers can
+// tell whether another page follows.
+func PaginateAfter(after *dto.Cursor, perPage int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if after != nil {
+			db = db.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+		}
+		return db.Order("created_at DESC").Order("id DESC").Limit(perPage + 1)
+	}
+}
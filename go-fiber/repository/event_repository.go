@@ -3,20 +3,33 @@ package repository
 import (
 	"context"
 	"math"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/dto"
 	"github.com/tapeds/go-fiber-template/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
 	EventRepository interface {
-		CreateEvent(ctx context.Context, event entity.Event) (entity.Event, error)
-		GetAllEventWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllEventRepositoryResponse, error)
-		GetEventById(ctx context.Context, eventId string) (entity.Event, error)
-		UpdateEvent(ctx context.Context, event entity.Event) (entity.Event, error)
-		DeleteEvent(ctx context.Context, eventId string) error
+		CreateEvent(ctx context.Context, tx *gorm.DB, event entity.Event) (entity.Event, error)
+		GetAllEventWithPagination(ctx context.Context, tx *gorm.DB, req dto.EventFilter) (dto.GetAllEventRepositoryResponse, error)
+		FindEventsInBatches(ctx context.Context, tx *gorm.DB, filter dto.EventFilter, batchSize int, fn func([]entity.Event) error) error
+		GetEventById(ctx context.Context, tx *gorm.DB, eventId string) (entity.Event, error)
+		GetEventByIdForUpdate(ctx context.Context, tx *gorm.DB, eventId string) (entity.Event, error)
+		UpdateEvent(ctx context.Context, tx *gorm.DB, event entity.Event) (entity.Event, error)
+		DeleteEvent(ctx context.Context, tx *gorm.DB, eventId string) error
+		DecreaseAvailability(ctx context.Context, tx *gorm.DB, eventId string, amount int) error
+		IncreaseAvailability(ctx context.Context, tx *gorm.DB, eventId string, amount int) error
+		UpdateRefundPolicy(ctx context.Context, tx *gorm.DB, event entity.Event) error
+		UpdateTransferPolicy(ctx context.Context, tx *gorm.DB, event entity.Event) error
+		UpdatePurchaseLimits(ctx context.Context, tx *gorm.DB, event entity.Event) error
+		UpdateSchedule(ctx context.Context, tx *gorm.DB, event entity.Event) error
+		UpdateStatus(ctx context.Context, tx *gorm.DB, event entity.Event) error
+		SyncTierTotals(ctx context.Context, tx *gorm.DB, eventId string) error
 	}
 
 	eventRepository struct {
@@ -30,22 +43,37 @@ func NewEventRepository(db *gorm.DB) EventRepository {
 	}
 }
 
-func (r *eventRepository) CreateEvent(ctx context.Context, event entity.Event) (entity.Event, error) {
-	tx := r.db
+func (r *eventRepository) CreateEvent(ctx context.Context, tx *gorm.DB, event entity.Event) (entity.Event, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if event.AuthorID == uuid.Nil {
 		return entity.Event{}, dto.ErrAuthorIDNotProvided
 	}
 
-	if err := tx.WithContext(ctx).Create(&event).Error; err != nil {
+	// Create leaves zero values to the column default, which would turn a
+	// policy of no refunds into a full refund.
+	noRefund := event.RefundPercentage == 0
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&event).Error; err != nil {
 		return entity.Event{}, err
 	}
 
+	if noRefund {
+		event.RefundPercentage = 0
+		if err := r.UpdateRefundPolicy(ctx, tx, event); err != nil {
+			return entity.Event{}, err
+		}
+	}
+
 	return event, nil
 }
 
-func (r *eventRepository) GetAllEventWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllEventRepositoryResponse, error) {
-	tx := r.db
+func (r *eventRepository) GetAllEventWithPagination(ctx context.Context, tx *gorm.DB, req dto.EventFilter) (dto.GetAllEventRepositoryResponse, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var events []entity.Event
 	var err error
@@ -55,15 +83,37 @@ func (r *eventRepository) GetAllEventWithPagination(ctx context.Context, req dto
 		req.PerPage = 10
 	}
 
+	query := filterEvents(ctx, tx, req, time.Now())
+
+	// Keyset pages skip the count, which is what makes them cheap.
+	if req.Cursor != nil {
+		if err := query.Preload("Author").Preload("Tiers", orderTiers).Scopes(PaginateAfter(req.After, req.PerPage)).Find(&events).Error; err != nil {
+			return dto.GetAllEventRepositoryResponse{}, err
+		}
+
+		hasMore := len(events) > req.PerPage
+		if hasMore {
+			events = events[:req.PerPage]
+		}
+
+		return dto.GetAllEventRepositoryResponse{
+			Events: events,
+			PaginationResponse: dto.PaginationResponse{
+				PerPage: req.PerPage,
+				HasMore: hasMore,
+			},
+		}, nil
+	}
+
 	if req.Page == 0 {
 		req.Page = 1
 	}
 
-	if err := tx.WithContext(ctx).Model(&entity.Event{}).Count(&count).Error; err != nil {
+	if err := query.Count(&count).Error; err != nil {
 		return dto.GetAllEventRepositoryResponse{}, err
 	}
 
-	if err := tx.WithContext(ctx).Scopes(Paginate(req.Page, req.PerPage)).Find(&events).Error; err != nil {
+	if err := orderEvents(query, req).Preload("Author").Preload("Tiers", orderTiers).Scopes(Paginate(req.Page, req.PerPage)).Find(&events).Error; err != nil {
 		return dto.GetAllEventRepositoryResponse{}, err
 	}
 
@@ -80,8 +130,130 @@ func (r *eventRepository) GetAllEventWithPagination(ctx context.Context, req dto
 	}, err
 }
 
-func (r *eventRepository) GetEventById(ctx context.Context, eventId string) (entity.Event, error) {
-	tx := r.db
+// filterEvents applies the search and the filters of the event list. When
+// keeps the events upcoming, ongoing or past at now.
+func filterEvents(ctx context.Context, tx *gorm.DB, filter dto.EventFilter, now time.Time) *gorm.DB {
+	query := tx.WithContext(ctx).Model(&entity.Event{})
+
+	if filter.Search != "" {
+		query = query.Where("search_vector @@ websearch_to_tsquery(?, ?)", constants.ENUM_EVENT_SEARCH_CONFIG, filter.Search)
+	}
+
+	switch filter.When {
+	case constants.ENUM_EVENT_WHEN_UPCOMING:
+		query = query.Where("starts_at > ?", now)
+	case constants.ENUM_EVENT_WHEN_ONGOING:
+		query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+	case constants.ENUM_EVENT_WHEN_PAST:
+		query = query.Where("ends_at <= ?", now)
+	}
+
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+
+	// Events overlapping the range take place on one of its days.
+	if !filter.From.IsZero() {
+		query = query.Where("ends_at > ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		query = query.Where("starts_at < ?", filter.To)
+	}
+
+	if filter.AuthorID != "" {
+		query = query.Where("author_id = ?", filter.AuthorID)
+	}
+
+	if filter.HideSoldOut {
+		query = query.Where("availabilty > 0")
+	}
+
+	if filter.PublishedOnly {
+		query = query.Where("status = ?", constants.ENUM_EVENT_STATUS_PUBLISHED)
+	}
+
+	return query
+}
+
+// orderEvents sorts the event list. Without a sort, search results come
+// best match first, upcoming events soonest first and past events most
+// recent first. Newer events go first among equals.
+func orderEvents(query *gorm.DB, filter dto.EventFilter) *gorm.DB {
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	switch filter.SortBy {
+	case constants.ENUM_EVENT_SORT_PRICE:
+		query = query.Order("price " + direction)
+	case constants.ENUM_EVENT_SORT_DATE:
+		query = query.Order("starts_at " + direction + " NULLS LAST")
+	case constants.ENUM_EVENT_SORT_POPULARITY:
+		query = query.Order("capacity - availabilty " + direction)
+	default:
+		if filter.Search != "" {
+			query = query.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "ts_rank(search_vector, websearch_to_tsquery(?, ?)) DESC",
+				Vars: []any{constants.ENUM_EVENT_SEARCH_CONFIG, filter.Search},
+			}})
+		}
+
+		switch filter.When {
+		case constants.ENUM_EVENT_WHEN_UPCOMING:
+			query = query.Order("starts_at ASC")
+		case constants.ENUM_EVENT_WHEN_ONGOING:
+			query = query.Order("ends_at ASC")
+		case constants.ENUM_EVENT_WHEN_PAST:
+			query = query.Order("ends_at DESC")
+		}
+	}
+
+	return query.Order("created_at DESC").Order("id ASC")
+}
+
+// FindEventsInBatches hands every event passing the filters of the event
+// list to fn, batchSize events at a time.
+func (r *eventRepository) FindEventsInBatches(ctx context.Context, tx *gorm.DB, filter dto.EventFilter, batchSize int, fn func([]entity.Event) error) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var events []entity.Event
+	return filterEvents(ctx, tx, filter, time.Now()).FindInBatches(&events, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(events)
+	}).Error
+}
+
+func (r *eventRepository) GetEventById(ctx context.Context, tx *gorm.DB, eventId string) (entity.Event, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	eventUUID, err := uuid.Parse(eventId)
+	if err != nil {
+		return entity.Event{}, dto.ErrInvalidEventID
+	}
+
+	var event entity.Event
+	if err := tx.WithContext(ctx).Preload("Tiers", orderTiers).Where("id = ?", eventUUID).Take(&event).Error; err != nil {
+		return entity.Event{}, err
+	}
+
+	return event, nil
+}
+
+// GetEventByIdForUpdate locks the event row. Changes to its tiers take this
+// lock first so the totals on the event stay consistent with them.
+func (r *eventRepository) GetEventByIdForUpdate(ctx context.Context, tx *gorm.DB, eventId string) (entity.Event, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	eventUUID, err := uuid.Parse(eventId)
 	if err != nil {
@@ -89,29 +261,33 @@ func (r *eventRepository) GetEventById(ctx context.Context, eventId string) (ent
 	}
 
 	var event entity.Event
-	if err := tx.WithContext(ctx).Where("id = ?", eventUUID).Take(&event).Error; err != nil {
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", eventUUID).Take(&event).Error; err != nil {
 		return entity.Event{}, err
 	}
 
 	return event, nil
 }
 
-func (r *eventRepository) UpdateEvent(ctx context.Context, event entity.Event) (entity.Event, error) {
-	tx := r.db
+func (r *eventRepository) UpdateEvent(ctx context.Context, tx *gorm.DB, event entity.Event) (entity.Event, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if event.AuthorID == uuid.Nil {
 		return entity.Event{}, dto.ErrAuthorIDNotProvided
 	}
 
-	if err := tx.WithContext(ctx).Updates(&event).Error; err != nil {
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Updates(&event).Error; err != nil {
 		return entity.Event{}, err
 	}
 
 	return event, nil
 }
 
-func (r *eventRepository) DeleteEvent(ctx context.Context, eventId string) error {
-	tx := r.db
+func (r *eventRepository) DeleteEvent(ctx context.Context, tx *gorm.DB, eventId string) error {
+	if tx == nil {
+		tx = r.db
+	}
 
 	eventUUID, err := uuid.Parse(eventId)
 	if err != nil {
@@ -124,3 +300,142 @@ func (r *eventRepository) DeleteEvent(ctx context.Context, eventId string) error
 
 	return nil
 }
+
+// DecreaseAvailability takes seats from an event with a single conditional
+// UPDATE, so concurrent buyers can never push availability below zero.
+func (r *eventRepository) DecreaseAvailability(ctx context.Context, tx *gorm.DB, eventId string, amount int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	eventUUID, err := uuid.Parse(eventId)
+	if err != nil {
+		return dto.ErrInvalidEventID
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.Event{}).
+		Where("id = ? AND availabilty >= ?", eventUUID, amount).
+		UpdateColumn("availabilty", gorm.Expr("availabilty - ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return dto.ErrInsufficientAvailability
+	}
+
+	return nil
+}
+
+// IncreaseAvailability gives seats back to an event, never beyond its capacity.
+func (r *eventRepository) IncreaseAvailability(ctx context.Context, tx *gorm.DB, eventId string, amount int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	eventUUID, err := uuid.Parse(eventId)
+	if err != nil {
+		return dto.ErrInvalidEventID
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{}).
+		Where("id = ?", eventUUID).
+		UpdateColumn("availabilty", gorm.Expr("LEAST(availabilty + ?, capacity)", amount)).
+		Error
+}
+
+// UpdateRefundPolicy writes the refund columns even when they hold zero
+// values, which Updates would otherwise skip.
+func (r *eventRepository) UpdateRefundPolicy(ctx context.Context, tx *gorm.DB, event entity.Event) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{ID: event.ID}).
+		Select("refund_deadline", "refund_percentage").
+		Updates(&event).
+		Error
+}
+
+// UpdateTransferPolicy writes transfers_disabled even when it is false.
+func (r *eventRepository) UpdateTransferPolicy(ctx context.Context, tx *gorm.DB, event entity.Event) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{ID: event.ID}).
+		Select("transfers_disabled").
+		Updates(&event).
+		Error
+}
+
+// UpdatePurchaseLimits writes the limit columns even when they are zero,
+// which turns a limit off.
+func (r *eventRepository) UpdatePurchaseLimits(ctx context.Context, tx *gorm.DB, event entity.Event) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{ID: event.ID}).
+		Select("max_tickets_per_order", "max_tickets_per_user").
+		Updates(&event).
+		Error
+}
+
+// UpdateSchedule writes the schedule, venue and description columns even
+// when they are empty, so an update can clear them.
+func (r *eventRepository) UpdateSchedule(ctx context.Context, tx *gorm.DB, event entity.Event) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{ID: event.ID}).
+		Select("starts_at", "ends_at", "timezone", "venue", "address", "description", "meeting_url").
+		Updates(&event).
+		Error
+}
+
+// UpdateStatus writes the status of an event with the timestamps stamped
+// by it.
+func (r *eventRepository) UpdateStatus(ctx context.Context, tx *gorm.DB, event entity.Event) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Event{ID: event.ID}).
+		Select("status", "published_at", "cancelled_at").
+		Updates(&event).
+		Error
+}
+
+// SyncTierTotals recomputes the price, capacity and availability of an event
+// from its tiers.
+func (r *eventRepository) SyncTierTotals(ctx context.Context, tx *gorm.DB, eventId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	eventUUID, err := uuid.Parse(eventId)
+	if err != nil {
+		return dto.ErrInvalidEventID
+	}
+
+	return tx.WithContext(ctx).Exec(`
+		UPDATE events SET
+			price = COALESCE((SELECT MIN(price) FROM ticket_tiers WHERE event_id = events.id AND deleted_at IS NULL), 0),
+			capacity = COALESCE((SELECT SUM(capacity) FROM ticket_tiers WHERE event_id = events.id AND deleted_at IS NULL), 0),
+			availabilty = COALESCE((SELECT SUM(availability) FROM ticket_tiers WHERE event_id = events.id AND deleted_at IS NULL), 0),
+			updated_at = NOW()
+		WHERE id = ?`, eventUUID).Error
+}
+
+func orderTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("price ASC, created_at ASC")
+}
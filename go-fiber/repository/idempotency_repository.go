@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	IdempotencyRepository interface {
+		CreateIdempotencyKey(ctx context.Context, tx *gorm.DB, key entity.IdempotencyKey) (entity.IdempotencyKey, bool, error)
+		GetIdempotencyKey(ctx context.Context, tx *gorm.DB, userId string, key string) (entity.IdempotencyKey, error)
+		UpdateIdempotencyKey(ctx context.Context, tx *gorm.DB, key entity.IdempotencyKey) (entity.IdempotencyKey, error)
+		DeleteIdempotencyKey(ctx context.Context, tx *gorm.DB, userId string, key string) error
+		DeleteExpiredIdempotencyKeys(ctx context.Context, tx *gorm.DB, now time.Time, limit int) (int, error)
+	}
+
+	idempotencyRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// CreateIdempotencyKey inserts the key unless the same user already holds it.
+// The boolean reports whether this call created the row.
+func (r *idempotencyRepository) CreateIdempotencyKey(ctx context.Context, tx *gorm.DB, key entity.IdempotencyKey) (entity.IdempotencyKey, bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&key)
+	if result.Error != nil {
+		return entity.IdempotencyKey{}, false, result.Error
+	}
+
+	return key, result.RowsAffected > 0, nil
+}
+
+func (r *idempotencyRepository) GetIdempotencyKey(ctx context.Context, tx *gorm.DB, userId string, key string) (entity.IdempotencyKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var idempotencyKey entity.IdempotencyKey
+	if err := tx.WithContext(ctx).Where("user_id = ? AND key = ?", userId, key).Take(&idempotencyKey).Error; err != nil {
+		return entity.IdempotencyKey{}, err
+	}
+
+	return idempotencyKey, nil
+}
+
+func (r *idempotencyRepository) UpdateIdempotencyKey(ctx context.Context, tx *gorm.DB, key entity.IdempotencyKey) (entity.IdempotencyKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Updates(&key).Error; err != nil {
+		return entity.IdempotencyKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *idempotencyRepository) DeleteIdempotencyKey(ctx context.Context, tx *gorm.DB, userId string, key string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	// Hard delete so the unique index lets the key be used again.
+	return tx.WithContext(ctx).Unscoped().Where("user_id = ? AND key = ?", userId, key).Delete(&entity.IdempotencyKey{}).Error
+}
+
+// DeleteExpiredIdempotencyKeys removes up to limit keys that expired before
+// now and reports how many went.
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, tx *gorm.DB, now time.Time, limit int) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	expired := tx.WithContext(ctx).
+		Model(&entity.IdempotencyKey{}).
+		Select("id").
+		Where("expires_at <= ?", now).
+		Order("expires_at").
+		Limit(limit)
+
+	result := tx.WithContext(ctx).Unscoped().Where("id IN (?)", expired).Delete(&entity.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return int(result.RowsAffected), nil
+}
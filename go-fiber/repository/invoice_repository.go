@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	InvoiceRepository interface {
+		NextInvoiceNumber(ctx context.Context, tx *gorm.DB, year int) (int, error)
+		CreateInvoice(ctx context.Context, tx *gorm.DB, invoice entity.Invoice) (entity.Invoice, error)
+		GetInvoiceByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Invoice, error)
+		UpdateInvoiceFile(ctx context.Context, tx *gorm.DB, invoiceId string, filePath string) error
+	}
+
+	invoiceRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{
+		db: db,
+	}
+}
+
+// NextInvoiceNumber hands out the next number of a year. The counter row
+// stays locked until tx commits, so concurrent payments get consecutive
+// numbers and a rolled back payment does not leave a gap.
+func (r *invoiceRepository) NextInvoiceNumber(ctx context.Context, tx *gorm.DB, year int) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var number int
+	if err := tx.WithContext(ctx).Raw(`
+		INSERT INTO invoice_sequences (year, last_number) VALUES (?, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, year).
+		Scan(&number).Error; err != nil {
+		return 0, err
+	}
+
+	return number, nil
+}
+
+func (r *invoiceRepository) CreateInvoice(ctx context.Context, tx *gorm.DB, invoice entity.Invoice) (entity.Invoice, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&invoice).Error; err != nil {
+		return entity.Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+func (r *invoiceRepository) GetInvoiceByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Invoice, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var invoice entity.Invoice
+	if err := tx.WithContext(ctx).Where("transaction_id = ?", transactionId).Take(&invoice).Error; err != nil {
+		return entity.Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+func (r *invoiceRepository) UpdateInvoiceFile(ctx context.Context, tx *gorm.DB, invoiceId string, filePath string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Invoice{}).
+		Where("id = ?", invoiceId).
+		Update("file_path", filePath).
+		Error
+}
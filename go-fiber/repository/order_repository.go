@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	OrderRepository interface {
+		CreateOrder(ctx context.Context, tx *gorm.DB, order entity.Order) (entity.Order, error)
+		GetOrderById(ctx context.Context, tx *gorm.DB, orderId string) (entity.Order, error)
+		GetOrderByIdForUpdate(ctx context.Context, tx *gorm.DB, orderId string) (entity.Order, error)
+		GetOrderByPaymentReferenceForUpdate(ctx context.Context, tx *gorm.DB, provider string, reference string) (entity.Order, error)
+		UpdateOrder(ctx context.Context, tx *gorm.DB, order entity.Order) (entity.Order, error)
+		UpdateOrderTotals(ctx context.Context, tx *gorm.DB, order entity.Order) error
+		DeleteOrder(ctx context.Context, tx *gorm.DB, orderId string) error
+	}
+
+	orderRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{
+		db: db,
+	}
+}
+
+func (r *orderRepository) CreateOrder(ctx context.Context, tx *gorm.DB, order entity.Order) (entity.Order, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&order).Error; err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// GetOrderById loads an order together with its items in the order they
+// were added.
+func (r *orderRepository) GetOrderById(ctx context.Context, tx *gorm.DB, orderId string) (entity.Order, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	orderUUID, err := uuid.Parse(orderId)
+	if err != nil {
+		return entity.Order{}, dto.ErrInvalidOrderID
+	}
+
+	var order entity.Order
+	if err := tx.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at, id")
+		}).
+		Where("id = ?", orderUUID).
+		Take(&order).Error; err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// GetOrderByIdForUpdate locks an order row until tx commits. Every change to
+// an order or its items takes this lock first, before the item rows.
+func (r *orderRepository) GetOrderByIdForUpdate(ctx context.Context, tx *gorm.DB, orderId string) (entity.Order, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	orderUUID, err := uuid.Parse(orderId)
+	if err != nil {
+		return entity.Order{}, dto.ErrInvalidOrderID
+	}
+
+	var order entity.Order
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orderUUID).Take(&order).Error; err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+func (r *orderRepository) GetOrderByPaymentReferenceForUpdate(ctx context.Context, tx *gorm.DB, provider string, reference string) (entity.Order, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var order entity.Order
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_provider = ? AND payment_reference = ?", provider, reference).Take(&order).Error; err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+func (r *orderRepository) UpdateOrder(ctx context.Context, tx *gorm.DB, order entity.Order) (entity.Order, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Updates(&order).Error; err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// UpdateOrderTotals writes the price columns even when they drop to zero,
+// which Updates would otherwise skip.
+func (r *orderRepository) UpdateOrderTotals(ctx context.Context, tx *gorm.DB, order entity.Order) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Order{ID: order.ID}).
+		Select("subtotal", "discount", "fees", "total").
+		Updates(&order).
+		Error
+}
+
+func (r *orderRepository) DeleteOrder(ctx context.Context, tx *gorm.DB, orderId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	orderUUID, err := uuid.Parse(orderId)
+	if err != nil {
+		return dto.ErrInvalidOrderID
+	}
+
+	return tx.WithContext(ctx).Delete(&entity.Order{}, "id = ?", orderUUID).Error
+}
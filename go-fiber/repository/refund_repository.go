@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+)
+
+type (
+	RefundRepository interface {
+		CreateRefund(ctx context.Context, tx *gorm.DB, refund entity.Refund) (entity.Refund, error)
+		GetRefundsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) ([]entity.Refund, error)
+		UpdateRefundStatus(ctx context.Context, tx *gorm.DB, refund entity.Refund) error
+	}
+
+	refundRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{
+		db: db,
+	}
+}
+
+func (r *refundRepository) CreateRefund(ctx context.Context, tx *gorm.DB, refund entity.Refund) (entity.Refund, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&refund).Error; err != nil {
+		return entity.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+func (r *refundRepository) GetRefundsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) ([]entity.Refund, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var refunds []entity.Refund
+	if err := tx.WithContext(ctx).Where("transaction_id = ?", transactionId).Order("created_at").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+// UpdateRefundStatus writes whether a refund reached the payment provider.
+func (r *refundRepository) UpdateRefundStatus(ctx context.Context, tx *gorm.DB, refund entity.Refund) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Refund{ID: refund.ID}).
+		Select("status", "sent_at").
+		Updates(&refund).
+		Error
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"gorm.io/gorm"
+)
+
+type (
+	ReportRepository interface {
+		GetSalesReport(ctx context.Context, tx *gorm.DB, filter dto.SalesReportFilter) ([]dto.SalesReportRow, error)
+	}
+
+	reportRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{
+		db: db,
+	}
+}
+
+// salesReportGroups holds, for every grouping, the columns selected from the
+// sales, the joins they need and how the rows are grouped and ordered.
+var salesReportGroups = map[string]struct {
+	columns string
+	joins   string
+	groupBy string
+	orderBy string
+}{
+	constants.ENUM_REPORT_GROUP_EVENT: {
+		columns: "sales.event_id, events.name AS event_name",
+		joins:   "JOIN events ON events.id = sales.event_id",
+		groupBy: "sales.event_id, events.name",
+		orderBy: "events.name, sales.event_id",
+	},
+	constants.ENUM_REPORT_GROUP_TIER: {
+		columns: "sales.event_id, events.name AS event_name, COALESCE(CAST(sales.tier_id AS TEXT), '') AS tier_id, COALESCE(ticket_tiers.name, '') AS tier_name",
+		joins:   "JOIN events ON events.id = sales.event_id LEFT JOIN ticket_tiers ON ticket_tiers.id = sales.tier_id",
+		groupBy: "sales.event_id, events.name, sales.tier_id, ticket_tiers.name",
+		orderBy: "events.name, sales.event_id, ticket_tiers.name",
+	},
+	constants.ENUM_REPORT_GROUP_DAY: {
+		columns: "TO_CHAR(sales.day, 'YYYY-MM-DD') AS date",
+		groupBy: "sales.day",
+		orderBy: "sales.day",
+	},
+}
+
+// GetSalesReport sums paid tickets and refunds in the database. Payments are
+// counted from the snapshot taken when a transaction was paid and bucketed
+// by paid_at, refunds by when they were made, both on the day they fall on
+// in the filter's timezone. Refunds of late payments for purchases that were
+// never paid are not sales and are left out. Amounts in different currencies are never
+// added together.
+func (r *reportRepository) GetSalesReport(ctx context.Context, tx *gorm.DB, filter dto.SalesReportFilter) ([]dto.SalesReportRow, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	group, ok := salesReportGroups[filter.GroupBy]
+	if !ok {
+		return nil, dto.ErrInvalidReportGroup
+	}
+
+	paidWhere, paidArgs := salesReportWhere("transactions.paid_at", filter)
+	refundWhere, refundArgs := salesReportWhere("refunds.created_at", filter)
+
+	args := []any{filter.Timezone}
+	args = append(args, paidArgs...)
+	args = append(args, filter.Timezone)
+	args = append(args, refundArgs...)
+
+	query := fmt.Sprintf(`
+		WITH sales AS (
+			SELECT transactions.event_id, transactions.tier_id, transactions.currency,
+				DATE(transactions.paid_at AT TIME ZONE ?) AS day,
+				transactions.paid_amount AS tickets_sold, transactions.paid_total AS gross,
+				0 AS tickets_refunded, 0 AS refunds
+			FROM transactions
+			JOIN events ON events.id = transactions.event_id
+			WHERE transactions.paid_at IS NOT NULL AND transactions.deleted_at IS NULL %s
+			UNION ALL
+			SELECT transactions.event_id, transactions.tier_id, transactions.currency,
+				DATE(refunds.created_at AT TIME ZONE ?) AS day,
+				0, 0, refunds.quantity, refunds.amount
+			FROM refunds
+			JOIN transactions ON transactions.id = refunds.transaction_id
+			JOIN events ON events.id = transactions.event_id
+			WHERE refunds.deleted_at IS NULL AND transactions.paid_at IS NOT NULL %s
+		)
+		SELECT %s, sales.currency,
+			SUM(sales.tickets_sold) AS tickets_sold,
+			SUM(sales.tickets_refunded) AS tickets_refunded,
+			SUM(sales.gross) AS gross,
+			SUM(sales.refunds) AS refunds,
+			SUM(sales.gross) - SUM(sales.refunds) AS net
+		FROM sales %s
+		GROUP BY %s, sales.currency
+		ORDER BY %s, sales.currency`,
+		paidWhere, refundWhere,
+		group.columns, group.joins, group.groupBy, group.orderBy,
+	)
+
+	rows := []dto.SalesReportRow{}
+	if err := tx.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// salesReportWhere narrows one side of the sales to the filter, with column
+// being the time that side is bucketed by.
+func salesReportWhere(column string, filter dto.SalesReportFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if filter.EventID != "" {
+		conditions = append(conditions, "transactions.event_id = ?")
+		args = append(args, filter.EventID)
+	}
+
+	if filter.AuthorID != "" {
+		conditions = append(conditions, "events.author_id = ?")
+		args = append(args, filter.AuthorID)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, column+" < ?")
+		args = append(args, filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return "AND " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	SeatHoldRepository interface {
+		CreateHold(ctx context.Context, tx *gorm.DB, hold entity.SeatHold) (entity.SeatHold, error)
+		GetActiveHoldsByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]entity.SeatHold, error)
+		GetExpiredHolds(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.SeatHold, error)
+		UpdateHoldQuantity(ctx context.Context, tx *gorm.DB, transactionId string, quantity int) error
+		SettleHold(ctx context.Context, tx *gorm.DB, transactionId string, status string) error
+	}
+
+	seatHoldRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewSeatHoldRepository(db *gorm.DB) SeatHoldRepository {
+	return &seatHoldRepository{
+		db: db,
+	}
+}
+
+func (r *seatHoldRepository) CreateHold(ctx context.Context, tx *gorm.DB, hold entity.SeatHold) (entity.SeatHold, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&hold).Error; err != nil {
+		return entity.SeatHold{}, err
+	}
+
+	return hold, nil
+}
+
+func (r *seatHoldRepository) GetActiveHoldsByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]entity.SeatHold, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var holds []entity.SeatHold
+	if err := tx.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderId, constants.ENUM_SEAT_HOLD_STATUS_ACTIVE).
+		Order("expires_at").
+		Find(&holds).Error; err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// GetExpiredHolds returns up to limit active holds that expired before now,
+// oldest first.
+func (r *seatHoldRepository) GetExpiredHolds(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.SeatHold, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var holds []entity.SeatHold
+	if err := tx.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", constants.ENUM_SEAT_HOLD_STATUS_ACTIVE, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&holds).Error; err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+func (r *seatHoldRepository) UpdateHoldQuantity(ctx context.Context, tx *gorm.DB, transactionId string, quantity int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.SeatHold{}).
+		Where("transaction_id = ? AND status = ?", transactionId, constants.ENUM_SEAT_HOLD_STATUS_ACTIVE).
+		Update("quantity", quantity).
+		Error
+}
+
+// SettleHold ends the active hold of a transaction with the given status.
+// Holds that were already settled are left alone.
+func (r *seatHoldRepository) SettleHold(ctx context.Context, tx *gorm.DB, transactionId string, status string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.SeatHold{}).
+		Where("transaction_id = ? AND status = ?", transactionId, constants.ENUM_SEAT_HOLD_STATUS_ACTIVE).
+		Updates(map[string]any{
+			"status":     status,
+			"settled_at": time.Now(),
+		}).
+		Error
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TicketRepository interface {
+		CreateTickets(ctx context.Context, tx *gorm.DB, tickets []entity.Ticket) ([]entity.Ticket, error)
+		GetTicketsByOwnerId(ctx context.Context, tx *gorm.DB, ownerId string) ([]entity.Ticket, error)
+		FindAttendeesByEventId(ctx context.Context, tx *gorm.DB, eventId string, fn func(dto.AttendeeRow) error) error
+		GetTicketByCode(ctx context.Context, tx *gorm.DB, code string) (entity.Ticket, error)
+		GetTicketByCodeForUpdate(ctx context.Context, tx *gorm.DB, code string) (entity.Ticket, error)
+		GetTicketByIdForUpdate(ctx context.Context, tx *gorm.DB, ticketId string) (entity.Ticket, error)
+		ReissueTicket(ctx context.Context, tx *gorm.DB, ticketId string, fromOwnerId string, toOwnerId string, code string) (bool, error)
+		VoidTicketsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string, ownerId string, limit int) error
+		CountHandedOverTickets(ctx context.Context, tx *gorm.DB, transactionId string, buyerId string) (int, error)
+		CheckInTicket(ctx context.Context, tx *gorm.DB, eventId string, code string, staffId string, usedAt time.Time) (bool, error)
+	}
+
+	ticketRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTicketRepository(db *gorm.DB) TicketRepository {
+	return &ticketRepository{
+		db: db,
+	}
+}
+
+func (r *ticketRepository) CreateTickets(ctx context.Context, tx *gorm.DB, tickets []entity.Ticket) ([]entity.Ticket, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if len(tickets) == 0 {
+		return tickets, nil
+	}
+
+	if err := tx.WithContext(ctx).Create(&tickets).Error; err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+func (r *ticketRepository) GetTicketsByOwnerId(ctx context.Context, tx *gorm.DB, ownerId string) ([]entity.Ticket, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var tickets []entity.Ticket
+	if err := tx.WithContext(ctx).Preload("Event").Where("owner_id = ?", ownerId).Order("created_at DESC").Find(&tickets).Error; err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// FindAttendeesByEventId hands fn one row per holder of valid tickets of an
+// event, reading them from a cursor instead of loading the whole list.
+// Transferred tickets count for their current owner, who is the one
+// checking in.
+func (r *ticketRepository) FindAttendeesByEventId(ctx context.Context, tx *gorm.DB, eventId string, fn func(dto.AttendeeRow) error) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	rows, err := tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Select(`tickets.owner_id, users.name, users.email,
+			COUNT(*) AS quantity,
+			COUNT(*) FILTER (WHERE tickets.status = ?) AS checked_in`, constants.ENUM_TICKET_STATUS_USED).
+		Joins("JOIN users ON users.id = tickets.owner_id").
+		Where("tickets.event_id = ? AND tickets.status IN ?", eventId, []string{
+			constants.ENUM_TICKET_STATUS_ACTIVE,
+			constants.ENUM_TICKET_STATUS_USED,
+		}).
+		Group("tickets.owner_id, users.name, users.email").
+		Order("users.name, users.email").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attendee dto.AttendeeRow
+		if err := tx.ScanRows(rows, &attendee); err != nil {
+			return err
+		}
+
+		if err := fn(attendee); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *ticketRepository) GetTicketByCode(ctx context.Context, tx *gorm.DB, code string) (entity.Ticket, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var ticket entity.Ticket
+	if err := tx.WithContext(ctx).Preload("Event").Where("code = ?", code).Take(&ticket).Error; err != nil {
+		return entity.Ticket{}, err
+	}
+
+	return ticket, nil
+}
+
+func (r *ticketRepository) GetTicketByCodeForUpdate(ctx context.Context, tx *gorm.DB, code string) (entity.Ticket, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var ticket entity.Ticket
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).Take(&ticket).Error; err != nil {
+		return entity.Ticket{}, err
+	}
+
+	return ticket, nil
+}
+
+func (r *ticketRepository) GetTicketByIdForUpdate(ctx context.Context, tx *gorm.DB, ticketId string) (entity.Ticket, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var ticket entity.Ticket
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", ticketId).Take(&ticket).Error; err != nil {
+		return entity.Ticket{}, err
+	}
+
+	return ticket, nil
+}
+
+// ReissueTicket hands an active ticket of fromOwnerId to toOwnerId under a
+// new code, which invalidates the old code and every QR image signed with
+// it. It reports false when the ticket changed owner or status meanwhile.
+func (r *ticketRepository) ReissueTicket(ctx context.Context, tx *gorm.DB, ticketId string, fromOwnerId string, toOwnerId string, code string) (bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Where("id = ? AND owner_id = ? AND status = ?", ticketId, fromOwnerId, constants.ENUM_TICKET_STATUS_ACTIVE).
+		Updates(map[string]any{
+			"owner_id": toOwnerId,
+			"code":     code,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// VoidTicketsByTransactionId invalidates the newest active tickets of a
+// transaction, or all of them when limit is zero. A non-empty ownerId only
+// voids tickets that owner still holds.
+func (r *ticketRepository) VoidTicketsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string, ownerId string, limit int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	active := tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Select("id").
+		Where("transaction_id = ? AND status = ?", transactionId, constants.ENUM_TICKET_STATUS_ACTIVE).
+		Order("created_at DESC")
+	if ownerId != "" {
+		active = active.Where("owner_id = ?", ownerId)
+	}
+	if limit > 0 {
+		active = active.Limit(limit)
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Where("id IN (?)", active).
+		Updates(map[string]any{
+			"status":    constants.ENUM_TICKET_STATUS_VOID,
+			"voided_at": time.Now(),
+		}).
+		Error
+}
+
+// CountHandedOverTickets counts the tickets of a transaction that left the
+// hands of its buyer: those checked in and those transferred to someone else.
+func (r *ticketRepository) CountHandedOverTickets(ctx context.Context, tx *gorm.DB, transactionId string, buyerId string) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Where("transaction_id = ?", transactionId).
+		Where("status = ? OR (status = ? AND owner_id <> ?)", constants.ENUM_TICKET_STATUS_USED, constants.ENUM_TICKET_STATUS_ACTIVE, buyerId).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// CheckInTicket marks an active ticket of the event as used. The status check
+// is part of the update so two scanners can never admit the same ticket; it
+// reports false when no ticket was changed.
+func (r *ticketRepository) CheckInTicket(ctx context.Context, tx *gorm.DB, eventId string, code string, staffId string, usedAt time.Time) (bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.Ticket{}).
+		Where("code = ? AND event_id = ? AND status = ?", code, eventId, constants.ENUM_TICKET_STATUS_ACTIVE).
+		Updates(map[string]any{
+			"status":        constants.ENUM_TICKET_STATUS_USED,
+			"used_at":       usedAt,
+			"checked_in_by": staffId,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TicketTierRepository interface {
+		CreateTier(ctx context.Context, tx *gorm.DB, tier entity.TicketTier) (entity.TicketTier, error)
+		GetTiersByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.TicketTier, error)
+		GetTierById(ctx context.Context, tx *gorm.DB, tierId string) (entity.TicketTier, error)
+		GetTierByIdForUpdate(ctx context.Context, tx *gorm.DB, tierId string) (entity.TicketTier, error)
+		UpdateTier(ctx context.Context, tx *gorm.DB, tier entity.TicketTier) (entity.TicketTier, error)
+		DeleteTier(ctx context.Context, tx *gorm.DB, tierId string) error
+		DecreaseTierAvailability(ctx context.Context, tx *gorm.DB, tierId string, amount int) error
+		IncreaseTierAvailability(ctx context.Context, tx *gorm.DB, tierId string, amount int) error
+	}
+
+	ticketTierRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTicketTierRepository(db *gorm.DB) TicketTierRepository {
+	return &ticketTierRepository{
+		db: db,
+	}
+}
+
+func (r *ticketTierRepository) CreateTier(ctx context.Context, tx *gorm.DB, tier entity.TicketTier) (entity.TicketTier, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&tier).Error; err != nil {
+		return entity.TicketTier{}, err
+	}
+
+	return tier, nil
+}
+
+func (r *ticketTierRepository) GetTiersByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.TicketTier, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var tiers []entity.TicketTier
+	if err := tx.WithContext(ctx).Where("event_id = ?", eventId).Order("price ASC, created_at ASC").Find(&tiers).Error; err != nil {
+		return nil, err
+	}
+
+	return tiers, nil
+}
+
+func (r *ticketTierRepository) GetTierById(ctx context.Context, tx *gorm.DB, tierId string) (entity.TicketTier, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	tierUUID, err := uuid.Parse(tierId)
+	if err != nil {
+		return entity.TicketTier{}, dto.ErrTierNotFound
+	}
+
+	var tier entity.TicketTier
+	if err := tx.WithContext(ctx).Where("id = ?", tierUUID).Take(&tier).Error; err != nil {
+		return entity.TicketTier{}, err
+	}
+
+	return tier, nil
+}
+
+func (r *ticketTierRepository) GetTierByIdForUpdate(ctx context.Context, tx *gorm.DB, tierId string) (entity.TicketTier, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	tierUUID, err := uuid.Parse(tierId)
+	if err != nil {
+		return entity.TicketTier{}, dto.ErrTierNotFound
+	}
+
+	var tier entity.TicketTier
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", tierUUID).Take(&tier).Error; err != nil {
+		return entity.TicketTier{}, err
+	}
+
+	return tier, nil
+}
+
+// UpdateTier writes every editable column, including zero prices and
+// cleared sale windows.
+func (r *ticketTierRepository) UpdateTier(ctx context.Context, tx *gorm.DB, tier entity.TicketTier) (entity.TicketTier, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entity.TicketTier{ID: tier.ID}).
+		Select("name", "price", "capacity", "availability", "sale_starts_at", "sale_ends_at").
+		Updates(&tier).
+		Error; err != nil {
+		return entity.TicketTier{}, err
+	}
+
+	return tier, nil
+}
+
+func (r *ticketTierRepository) DeleteTier(ctx context.Context, tx *gorm.DB, tierId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	tierUUID, err := uuid.Parse(tierId)
+	if err != nil {
+		return dto.ErrTierNotFound
+	}
+
+	return tx.WithContext(ctx).Delete(&entity.TicketTier{}, "id = ?", tierUUID).Error
+}
+
+// DecreaseTierAvailability takes seats from a tier with a single conditional
+// UPDATE, like EventRepository.DecreaseAvailability does for the event.
+func (r *ticketTierRepository) DecreaseTierAvailability(ctx context.Context, tx *gorm.DB, tierId string, amount int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	tierUUID, err := uuid.Parse(tierId)
+	if err != nil {
+		return dto.ErrTierNotFound
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.TicketTier{}).
+		Where("id = ? AND availability >= ?", tierUUID, amount).
+		UpdateColumn("availability", gorm.Expr("availability - ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return dto.ErrInsufficientAvailability
+	}
+
+	return nil
+}
+
+// IncreaseTierAvailability gives seats back to a tier, never beyond its
+// capacity.
+func (r *ticketTierRepository) IncreaseTierAvailability(ctx context.Context, tx *gorm.DB, tierId string, amount int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	tierUUID, err := uuid.Parse(tierId)
+	if err != nil {
+		return dto.ErrTierNotFound
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.TicketTier{}).
+		Where("id = ?", tierUUID).
+		UpdateColumn("availability", gorm.Expr("LEAST(availability + ?, capacity)", amount)).
+		Error
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TicketTransferRepository interface {
+		CreateTransfer(ctx context.Context, tx *gorm.DB, transfer entity.TicketTransfer) (entity.TicketTransfer, error)
+		GetTransferById(ctx context.Context, tx *gorm.DB, transferId string) (entity.TicketTransfer, error)
+		GetTransferByIdForUpdate(ctx context.Context, tx *gorm.DB, transferId string) (entity.TicketTransfer, error)
+		GetTransfersByUser(ctx context.Context, tx *gorm.DB, userId string, email string) ([]entity.TicketTransfer, error)
+		GetAcceptedTransfersByTicketId(ctx context.Context, tx *gorm.DB, ticketId string) ([]entity.TicketTransfer, error)
+		HasPendingTransfer(ctx context.Context, tx *gorm.DB, ticketId string) (bool, error)
+		UpdateTransfer(ctx context.Context, tx *gorm.DB, transfer entity.TicketTransfer) (entity.TicketTransfer, error)
+	}
+
+	ticketTransferRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTicketTransferRepository(db *gorm.DB) TicketTransferRepository {
+	return &ticketTransferRepository{
+		db: db,
+	}
+}
+
+func (r *ticketTransferRepository) CreateTransfer(ctx context.Context, tx *gorm.DB, transfer entity.TicketTransfer) (entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&transfer).Error; err != nil {
+		return entity.TicketTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (r *ticketTransferRepository) GetTransferById(ctx context.Context, tx *gorm.DB, transferId string) (entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	transferUUID, err := uuid.Parse(transferId)
+	if err != nil {
+		return entity.TicketTransfer{}, dto.ErrInvalidTicketTransferID
+	}
+
+	var transfer entity.TicketTransfer
+	if err := tx.WithContext(ctx).
+		Preload("Event").
+		Preload("FromUser").
+		Where("id = ?", transferUUID).
+		Take(&transfer).Error; err != nil {
+		return entity.TicketTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// GetTransferByIdForUpdate locks a transfer until tx commits. Transfers are
+// always locked before the ticket they move.
+func (r *ticketTransferRepository) GetTransferByIdForUpdate(ctx context.Context, tx *gorm.DB, transferId string) (entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	transferUUID, err := uuid.Parse(transferId)
+	if err != nil {
+		return entity.TicketTransfer{}, dto.ErrInvalidTicketTransferID
+	}
+
+	var transfer entity.TicketTransfer
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transferUUID).Take(&transfer).Error; err != nil {
+		return entity.TicketTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// GetTransfersByUser returns the transfers a user sent or received,
+// including invites sent to their email before they had an account.
+func (r *ticketTransferRepository) GetTransfersByUser(ctx context.Context, tx *gorm.DB, userId string, email string) ([]entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var transfers []entity.TicketTransfer
+	if err := tx.WithContext(ctx).
+		Preload("Event").
+		Preload("FromUser").
+		Where("from_user_id = ? OR to_user_id = ? OR (to_user_id IS NULL AND LOWER(to_email) = LOWER(?))", userId, userId, email).
+		Order("created_at DESC").
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// GetAcceptedTransfersByTicketId returns the ownership changes of a ticket,
+// oldest first.
+func (r *ticketTransferRepository) GetAcceptedTransfersByTicketId(ctx context.Context, tx *gorm.DB, ticketId string) ([]entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var transfers []entity.TicketTransfer
+	if err := tx.WithContext(ctx).
+		Where("ticket_id = ? AND status = ?", ticketId, constants.ENUM_TICKET_TRANSFER_STATUS_ACCEPTED).
+		Order("accepted_at").
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+func (r *ticketTransferRepository) HasPendingTransfer(ctx context.Context, tx *gorm.DB, ticketId string) (bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entity.TicketTransfer{}).
+		Where("ticket_id = ? AND status = ?", ticketId, constants.ENUM_TICKET_TRANSFER_STATUS_PENDING).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *ticketTransferRepository) UpdateTransfer(ctx context.Context, tx *gorm.DB, transfer entity.TicketTransfer) (entity.TicketTransfer, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Updates(&transfer).Error; err != nil {
+		return entity.TicketTransfer{}, err
+	}
+
+	return transfer, nil
+}
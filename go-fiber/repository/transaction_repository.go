@@ -5,18 +5,27 @@ import (
 	"math"
 
 	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/dto"
 	"github.com/tapeds/go-fiber-template/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
 	TransactionRepository interface {
-		CreateTransaction(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error)
-		GetAllTransactionsWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllTransactionRepositoryResponse, error)
-		GetTransactionById(ctx context.Context, transactionId string) (entity.Transaction, error)
-		UpdateTransaction(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error)
-		DeleteTransaction(ctx context.Context, transactionId string) error
+		CreateTransaction(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) (entity.Transaction, error)
+		GetAllTransactionsWithPagination(ctx context.Context, tx *gorm.DB, req dto.TransactionPaginationRequest) (dto.GetAllTransactionRepositoryResponse, error)
+		FindTransactionsInBatches(ctx context.Context, tx *gorm.DB, req dto.TransactionPaginationRequest, batchSize int, fn func([]entity.Transaction) error) error
+		GetTransactionById(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Transaction, error)
+		GetTransactionByIdForUpdate(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Transaction, error)
+		GetTransactionsByOrderIdForUpdate(ctx context.Context, tx *gorm.DB, orderId string) ([]entity.Transaction, error)
+		SumLiveAmount(ctx context.Context, tx *gorm.DB, buyerId string, eventId string, orderId string) (int, error)
+		GetLiveTransactionsByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.Transaction, error)
+		UpdateTransaction(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) (entity.Transaction, error)
+		UpdateTransactionTotals(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) error
+		UpdatePaymentByOrderId(ctx context.Context, tx *gorm.DB, orderId string, provider string, reference string, url string) error
+		DeleteTransaction(ctx context.Context, tx *gorm.DB, transactionId string) error
 	}
 
 	transactionRepository struct {
@@ -30,8 +39,10 @@ func NewTransactionRepository(db *gorm.DB) TransactionRepository {
 	}
 }
 
-func (r *transactionRepository) CreateTransaction(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error) {
-	tx := r.db
+func (r *transactionRepository) CreateTransaction(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) (entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	// Ensure the buyer and event IDs are valid
 	if transaction.EventID == "" {
@@ -45,8 +56,10 @@ func (r *transactionRepository) CreateTransaction(ctx context.Context, transacti
 	return transaction, nil
 }
 
-func (r *transactionRepository) GetAllTransactionsWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllTransactionRepositoryResponse, error) {
-	tx := r.db
+func (r *transactionRepository) GetAllTransactionsWithPagination(ctx context.Context, tx *gorm.DB, req dto.TransactionPaginationRequest) (dto.GetAllTransactionRepositoryResponse, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var transactions []entity.Transaction
 	var err error
@@ -56,15 +69,37 @@ func (r *transactionRepository) GetAllTransactionsWithPagination(ctx context.Con
 		req.PerPage = 10
 	}
 
+	query := filterTransactions(ctx, tx, req)
+
+	// Keyset pages skip the count, which is what makes them cheap.
+	if req.Cursor != nil {
+		if err := preloadTransactionDetails(query).Scopes(PaginateAfter(req.After, req.PerPage)).Find(&transactions).Error; err != nil {
+			return dto.GetAllTransactionRepositoryResponse{}, err
+		}
+
+		hasMore := len(transactions) > req.PerPage
+		if hasMore {
+			transactions = transactions[:req.PerPage]
+		}
+
+		return dto.GetAllTransactionRepositoryResponse{
+			Transactions: transactions,
+			PaginationResponse: dto.PaginationResponse{
+				PerPage: req.PerPage,
+				HasMore: hasMore,
+			},
+		}, nil
+	}
+
 	if req.Page == 0 {
 		req.Page = 1
 	}
 
-	if err := tx.WithContext(ctx).Model(&entity.Transaction{}).Count(&count).Error; err != nil {
+	if err := query.Count(&count).Error; err != nil {
 		return dto.GetAllTransactionRepositoryResponse{}, err
 	}
 
-	if err := tx.WithContext(ctx).Scopes(Paginate(req.Page, req.PerPage)).Find(&transactions).Error; err != nil {
+	if err := preloadTransactionDetails(query).Scopes(Paginate(req.Page, req.PerPage)).Find(&transactions).Error; err != nil {
 		return dto.GetAllTransactionRepositoryResponse{}, err
 	}
 
@@ -81,8 +116,50 @@ func (r *transactionRepository) GetAllTransactionsWithPagination(ctx context.Con
 	}, err
 }
 
-func (r *transactionRepository) GetTransactionById(ctx context.Context, transactionId string) (entity.Transaction, error) {
-	tx := r.db
+// FindTransactionsInBatches hands the transactions matching the filters of
+// the list to fn, batchSize at a time, with their buyer, event and tier
+// loaded.
+func (r *transactionRepository) FindTransactionsInBatches(ctx context.Context, tx *gorm.DB, req dto.TransactionPaginationRequest, batchSize int, fn func([]entity.Transaction) error) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var transactions []entity.Transaction
+	return preloadTransactionDetails(filterTransactions(ctx, tx, req)).
+		FindInBatches(&transactions, batchSize, func(_ *gorm.DB, _ int) error {
+			return fn(transactions)
+		}).Error
+}
+
+// preloadTransactionDetails loads the buyer, event and tier of listed
+// transactions with one query each instead of one per row.
+func preloadTransactionDetails(query *gorm.DB) *gorm.DB {
+	return query.Preload("Buyer").Preload("Event").Preload("Tier")
+}
+
+// filterTransactions applies the filters shared by the transaction list and
+// its export.
+func filterTransactions(ctx context.Context, tx *gorm.DB, req dto.TransactionPaginationRequest) *gorm.DB {
+	query := tx.WithContext(ctx).Model(&entity.Transaction{})
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	if req.BuyerID != "" {
+		query = query.Where("buyer_id = ?", req.BuyerID)
+	}
+
+	if req.AuthorID != "" {
+		query = query.Where("event_id IN (?)", tx.WithContext(ctx).Model(&entity.Event{}).Select("id").Where("author_id = ?", req.AuthorID))
+	}
+
+	return query
+}
+
+func (r *transactionRepository) GetTransactionById(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	transactionUUID, err := uuid.Parse(transactionId)
 	if err != nil {
@@ -97,8 +174,91 @@ func (r *transactionRepository) GetTransactionById(ctx context.Context, transact
 	return transaction, nil
 }
 
-func (r *transactionRepository) UpdateTransaction(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error) {
-	tx := r.db
+// GetTransactionByIdForUpdate loads a transaction and locks its row until tx
+// commits, so status changes on the same transaction are serialized.
+func (r *transactionRepository) GetTransactionByIdForUpdate(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	transactionUUID, err := uuid.Parse(transactionId)
+	if err != nil {
+		return entity.Transaction{}, dto.ErrInvalidTransactionID
+	}
+
+	var transaction entity.Transaction
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transactionUUID).Take(&transaction).Error; err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// GetTransactionsByOrderIdForUpdate locks the items of an order, always in
+// the same order so two requests on one order cannot deadlock.
+func (r *transactionRepository) GetTransactionsByOrderIdForUpdate(ctx context.Context, tx *gorm.DB, orderId string) ([]entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var transactions []entity.Transaction
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderId).Order("id").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// SumLiveAmount counts the tickets of an event a buyer holds in pending and
+// paid purchases, limited to one order when orderId is not empty.
+func (r *transactionRepository) SumLiveAmount(ctx context.Context, tx *gorm.DB, buyerId string, eventId string, orderId string) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	query := tx.WithContext(ctx).
+		Model(&entity.Transaction{}).
+		Where("buyer_id = ? AND event_id = ? AND status IN ?", buyerId, eventId, []string{
+			constants.ENUM_TRANSACTION_STATUS_PENDING,
+			constants.ENUM_TRANSACTION_STATUS_PAID,
+		})
+	if orderId != "" {
+		query = query.Where("order_id = ?", orderId)
+	}
+
+	var amount int
+	if err := query.Select("COALESCE(SUM(amount), 0)").Scan(&amount).Error; err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// GetLiveTransactionsByEventId returns the pending and paid transactions of
+// an event, the ones still holding seats.
+func (r *transactionRepository) GetLiveTransactionsByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var transactions []entity.Transaction
+	if err := tx.WithContext(ctx).
+		Where("event_id = ? AND status IN ?", eventId, []string{
+			constants.ENUM_TRANSACTION_STATUS_PENDING,
+			constants.ENUM_TRANSACTION_STATUS_PAID,
+		}).
+		Order("created_at ASC").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (r *transactionRepository) UpdateTransaction(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) (entity.Transaction, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if transaction.EventID == "" {
 		return entity.Transaction{}, dto.ErrBuyerIDNotProvided
@@ -111,8 +271,42 @@ func (r *transactionRepository) UpdateTransaction(ctx context.Context, transacti
 	return transaction, nil
 }
 
-func (r *transactionRepository) DeleteTransaction(ctx context.Context, transactionId string) error {
-	tx := r.db
+// UpdateTransactionTotals writes the price columns even when a discount
+// brings them down to zero, which Updates would otherwise skip.
+func (r *transactionRepository) UpdateTransactionTotals(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Transaction{ID: transaction.ID}).
+		Select("unit_price", "subtotal", "discount", "fees", "total").
+		Updates(&transaction).
+		Error
+}
+
+// UpdatePaymentByOrderId copies the charge of an order onto its items, so
+// refunds of a single item can be sent to the same payment.
+func (r *transactionRepository) UpdatePaymentByOrderId(ctx context.Context, tx *gorm.DB, orderId string, provider string, reference string, url string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Transaction{}).
+		Where("order_id = ?", orderId).
+		Updates(map[string]any{
+			"payment_provider":  provider,
+			"payment_reference": reference,
+			"payment_url":       url,
+		}).
+		Error
+}
+
+func (r *transactionRepository) DeleteTransaction(ctx context.Context, tx *gorm.DB, transactionId string) error {
+	if tx == nil {
+		tx = r.db
+	}
 
 	transactionUUID, err := uuid.Parse(transactionId)
 	if err != nil {
@@ -11,13 +11,14 @@ import (
 
 type (
 	UserRepository interface {
-		RegisterUser(ctx context.Context, user entity.User) (entity.User, error)
-		GetAllUserWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllUserRepositoryResponse, error)
-		GetUserById(ctx context.Context, userId string) (entity.User, error)
-		GetUserByEmail(ctx context.Context, email string) (entity.User, error)
-		CheckEmail(ctx context.Context, email string) (entity.User, bool, error)
-		UpdateUser(ctx context.Context, user entity.User) (entity.User, error)
-		DeleteUser(ctx context.Context, userId string) error
+		RegisterUser(ctx context.Context, tx *gorm.DB, user entity.User) (entity.User, error)
+		GetAllUserWithPagination(ctx context.Context, tx *gorm.DB, req dto.PaginationRequest) (dto.GetAllUserRepositoryResponse, error)
+		FindUsersInBatches(ctx context.Context, tx *gorm.DB, search string, batchSize int, fn func([]entity.User) error) error
+		GetUserById(ctx context.Context, tx *gorm.DB, userId string) (entity.User, error)
+		GetUserByEmail(ctx context.Context, tx *gorm.DB, email string) (entity.User, error)
+		CheckEmail(ctx context.Context, tx *gorm.DB, email string) (entity.User, bool, error)
+		UpdateUser(ctx context.Context, tx *gorm.DB, user entity.User) (entity.User, error)
+		DeleteUser(ctx context.Context, tx *gorm.DB, userId string) error
 	}
 
 	userRepository struct {
@@ -31,8 +32,10 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
-func (r *userRepository) RegisterUser(ctx context.Context, user entity.User) (entity.User, error) {
-	tx := r.db
+func (r *userRepository) RegisterUser(ctx context.Context, tx *gorm.DB, user entity.User) (entity.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if err := tx.WithContext(ctx).Create(&user).Error; err != nil {
 		return entity.User{}, err
@@ -41,8 +44,10 @@ func (r *userRepository) RegisterUser(ctx context.Context, user entity.User) (en
 	return user, nil
 }
 
-func (r *userRepository) GetAllUserWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.GetAllUserRepositoryResponse, error) {
-	tx := r.db
+func (r *userRepository) GetAllUserWithPagination(ctx context.Context, tx *gorm.DB, req dto.PaginationRequest) (dto.GetAllUserRepositoryResponse, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var users []entity.User
 	var err error
@@ -52,15 +57,35 @@ func (r *userRepository) GetAllUserWithPagination(ctx context.Context, req dto.P
 		req.PerPage = 10
 	}
 
+	// Keyset pages skip the count, which is what makes them cheap.
+	if req.Cursor != nil {
+		if err := filterUsers(ctx, tx, req.Search).Scopes(PaginateAfter(req.After, req.PerPage)).Find(&users).Error; err != nil {
+			return dto.GetAllUserRepositoryResponse{}, err
+		}
+
+		hasMore := len(users) > req.PerPage
+		if hasMore {
+			users = users[:req.PerPage]
+		}
+
+		return dto.GetAllUserRepositoryResponse{
+			Users: users,
+			PaginationResponse: dto.PaginationResponse{
+				PerPage: req.PerPage,
+				HasMore: hasMore,
+			},
+		}, nil
+	}
+
 	if req.Page == 0 {
 		req.Page = 1
 	}
 
-	if err := tx.WithContext(ctx).Model(&entity.User{}).Count(&count).Error; err != nil {
+	if err := filterUsers(ctx, tx, req.Search).Count(&count).Error; err != nil {
 		return dto.GetAllUserRepositoryResponse{}, err
 	}
 
-	if err := tx.WithContext(ctx).Scopes(Paginate(req.Page, req.PerPage)).Find(&users).Error; err != nil {
+	if err := filterUsers(ctx, tx, req.Search).Scopes(Paginate(req.Page, req.PerPage)).Find(&users).Error; err != nil {
 		return dto.GetAllUserRepositoryResponse{}, err
 	}
 
@@ -77,8 +102,34 @@ func (r *userRepository) GetAllUserWithPagination(ctx context.Context, req dto.P
 	}, err
 }
 
-func (r *userRepository) GetUserById(ctx context.Context, userId string) (entity.User, error) {
-	tx := r.db
+// filterUsers applies the search of the user list, which matches the name
+// or the email.
+func filterUsers(ctx context.Context, tx *gorm.DB, search string) *gorm.DB {
+	query := tx.WithContext(ctx).Model(&entity.User{})
+	if search != "" {
+		query = query.Where("name ILIKE ? OR email ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	return query
+}
+
+// FindUsersInBatches hands every user matching search to fn, batchSize users
+// at a time, so exports never hold the whole table in memory.
+func (r *userRepository) FindUsersInBatches(ctx context.Context, tx *gorm.DB, search string, batchSize int, fn func([]entity.User) error) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var users []entity.User
+	return filterUsers(ctx, tx, search).FindInBatches(&users, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(users)
+	}).Error
+}
+
+func (r *userRepository) GetUserById(ctx context.Context, tx *gorm.DB, userId string) (entity.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var user entity.User
 	if err := tx.WithContext(ctx).Where("id = ?", userId).Take(&user).Error; err != nil {
@@ -88,8 +139,10 @@ func (r *userRepository) GetUserById(ctx context.Context, userId string) (entity
 	return user, nil
 }
 
-func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
-	tx := r.db
+func (r *userRepository) GetUserByEmail(ctx context.Context, tx *gorm.DB, email string) (entity.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var user entity.User
 	if err := tx.WithContext(ctx).Where("email = ?", email).Take(&user).Error; err != nil {
@@ -99,8 +152,10 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (enti
 	return user, nil
 }
 
-func (r *userRepository) CheckEmail(ctx context.Context, email string) (entity.User, bool, error) {
-	tx := r.db
+func (r *userRepository) CheckEmail(ctx context.Context, tx *gorm.DB, email string) (entity.User, bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	var user entity.User
 	if err := tx.WithContext(ctx).Where("email = ?", email).Take(&user).Error; err != nil {
@@ -110,8 +165,10 @@ func (r *userRepository) CheckEmail(ctx context.Context, email string) (entity.U
 	return user, true, nil
 }
 
-func (r *userRepository) UpdateUser(ctx context.Context, user entity.User) (entity.User, error) {
-	tx := r.db
+func (r *userRepository) UpdateUser(ctx context.Context, tx *gorm.DB, user entity.User) (entity.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if err := tx.WithContext(ctx).Updates(&user).Error; err != nil {
 		return entity.User{}, err
@@ -120,8 +177,10 @@ func (r *userRepository) UpdateUser(ctx context.Context, user entity.User) (enti
 	return user, nil
 }
 
-func (r *userRepository) DeleteUser(ctx context.Context, userId string) error {
-	tx := r.db
+func (r *userRepository) DeleteUser(ctx context.Context, tx *gorm.DB, userId string) error {
+	if tx == nil {
+		tx = r.db
+	}
 
 	if err := tx.WithContext(ctx).Delete(&entity.User{}, "id = ?", userId).Error; err != nil {
 		return err
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	VoucherRepository interface {
+		CreateVoucher(ctx context.Context, tx *gorm.DB, voucher entity.Voucher) (entity.Voucher, error)
+		GetAllVouchersWithPagination(ctx context.Context, tx *gorm.DB, req dto.PaginationRequest) (dto.GetAllVoucherRepositoryResponse, error)
+		GetVoucherById(ctx context.Context, tx *gorm.DB, voucherId string) (entity.Voucher, error)
+		GetVoucherByCode(ctx context.Context, tx *gorm.DB, code string) (entity.Voucher, error)
+		UpdateVoucher(ctx context.Context, tx *gorm.DB, voucher entity.Voucher) (entity.Voucher, error)
+		DeleteVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error
+		RedeemVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error
+		ReleaseVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error
+		CountUserRedemptions(ctx context.Context, tx *gorm.DB, voucherId string, userId string) (int64, error)
+		CreateRedemption(ctx context.Context, tx *gorm.DB, redemption entity.VoucherRedemption) (entity.VoucherRedemption, error)
+		GetActiveRedemptionsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) ([]entity.VoucherRedemption, error)
+		UpdateRedemptionDiscount(ctx context.Context, tx *gorm.DB, redemptionId string, discount int) error
+		ReleaseRedemption(ctx context.Context, tx *gorm.DB, redemptionId string) error
+	}
+
+	voucherRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewVoucherRepository(db *gorm.DB) VoucherRepository {
+	return &voucherRepository{
+		db: db,
+	}
+}
+
+func (r *voucherRepository) CreateVoucher(ctx context.Context, tx *gorm.DB, voucher entity.Voucher) (entity.Voucher, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&voucher).Error; err != nil {
+		return entity.Voucher{}, err
+	}
+
+	return voucher, nil
+}
+
+func (r *voucherRepository) GetAllVouchersWithPagination(ctx context.Context, tx *gorm.DB, req dto.PaginationRequest) (dto.GetAllVoucherRepositoryResponse, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var vouchers []entity.Voucher
+	var count int64
+
+	if req.PerPage == 0 {
+		req.PerPage = 10
+	}
+
+	if req.Page == 0 {
+		req.Page = 1
+	}
+
+	query := tx.WithContext(ctx).Model(&entity.Voucher{})
+	if req.Search != "" {
+		query = query.Where("code ILIKE ?", "%"+req.Search+"%")
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return dto.GetAllVoucherRepositoryResponse{}, err
+	}
+
+	if err := query.Order("created_at DESC").Scopes(Paginate(req.Page, req.PerPage)).Find(&vouchers).Error; err != nil {
+		return dto.GetAllVoucherRepositoryResponse{}, err
+	}
+
+	totalPage := int64(math.Ceil(float64(count) / float64(req.PerPage)))
+
+	return dto.GetAllVoucherRepositoryResponse{
+		Vouchers: vouchers,
+		PaginationResponse: dto.PaginationResponse{
+			Page:    req.Page,
+			PerPage: req.PerPage,
+			Count:   count,
+			MaxPage: totalPage,
+		},
+	}, nil
+}
+
+func (r *voucherRepository) GetVoucherById(ctx context.Context, tx *gorm.DB, voucherId string) (entity.Voucher, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	voucherUUID, err := uuid.Parse(voucherId)
+	if err != nil {
+		return entity.Voucher{}, dto.ErrVoucherNotFound
+	}
+
+	var voucher entity.Voucher
+	if err := tx.WithContext(ctx).Where("id = ?", voucherUUID).Take(&voucher).Error; err != nil {
+		return entity.Voucher{}, err
+	}
+
+	return voucher, nil
+}
+
+func (r *voucherRepository) GetVoucherByCode(ctx context.Context, tx *gorm.DB, code string) (entity.Voucher, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var voucher entity.Voucher
+	if err := tx.WithContext(ctx).Where("code = ?", code).Take(&voucher).Error; err != nil {
+		return entity.Voucher{}, err
+	}
+
+	return voucher, nil
+}
+
+// UpdateVoucher writes every editable column, so limits can be set back to
+// zero and validity windows cleared.
+func (r *voucherRepository) UpdateVoucher(ctx context.Context, tx *gorm.DB, voucher entity.Voucher) (entity.Voucher, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entity.Voucher{ID: voucher.ID}).
+		Select("description", "discount_type", "discount_value", "max_redemptions", "max_redemptions_per_user", "valid_from", "valid_until", "stackable").
+		Updates(&voucher).
+		Error; err != nil {
+		return entity.Voucher{}, err
+	}
+
+	return voucher, nil
+}
+
+func (r *voucherRepository) DeleteVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	voucherUUID, err := uuid.Parse(voucherId)
+	if err != nil {
+		return dto.ErrVoucherNotFound
+	}
+
+	return tx.WithContext(ctx).Delete(&entity.Voucher{}, "id = ?", voucherUUID).Error
+}
+
+// RedeemVoucher counts one use of a voucher with a conditional UPDATE, so
+// concurrent purchases can never go past its global limit. The row stays
+// locked until tx commits, which also serializes the per user check.
+func (r *voucherRepository) RedeemVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.Voucher{}).
+		Where("id = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", voucherId).
+		UpdateColumn("redemptions", gorm.Expr("redemptions + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return dto.ErrVoucherExhausted
+	}
+
+	return nil
+}
+
+func (r *voucherRepository) ReleaseVoucher(ctx context.Context, tx *gorm.DB, voucherId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Voucher{}).
+		Where("id = ?", voucherId).
+		UpdateColumn("redemptions", gorm.Expr("GREATEST(redemptions - 1, 0)")).
+		Error
+}
+
+func (r *voucherRepository) CountUserRedemptions(ctx context.Context, tx *gorm.DB, voucherId string, userId string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entity.VoucherRedemption{}).
+		Where("voucher_id = ? AND user_id = ? AND released_at IS NULL", voucherId, userId).
+		Count(&count).
+		Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *voucherRepository) CreateRedemption(ctx context.Context, tx *gorm.DB, redemption entity.VoucherRedemption) (entity.VoucherRedemption, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&redemption).Error; err != nil {
+		return entity.VoucherRedemption{}, err
+	}
+
+	return redemption, nil
+}
+
+func (r *voucherRepository) GetActiveRedemptionsByTransactionId(ctx context.Context, tx *gorm.DB, transactionId string) ([]entity.VoucherRedemption, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var redemptions []entity.VoucherRedemption
+	if err := tx.WithContext(ctx).
+		Preload("Voucher", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("transaction_id = ? AND released_at IS NULL", transactionId).
+		Order("created_at ASC").
+		Find(&redemptions).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+func (r *voucherRepository) UpdateRedemptionDiscount(ctx context.Context, tx *gorm.DB, redemptionId string, discount int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.VoucherRedemption{}).
+		Where("id = ?", redemptionId).
+		UpdateColumn("discount", discount).
+		Error
+}
+
+func (r *voucherRepository) ReleaseRedemption(ctx context.Context, tx *gorm.DB, redemptionId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.VoucherRedemption{}).
+		Where("id = ?", redemptionId).
+		UpdateColumn("released_at", time.Now()).
+		Error
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	WaitlistRepository interface {
+		CreateEntry(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (entity.WaitlistEntry, error)
+		GetEntryByIdForUpdate(ctx context.Context, tx *gorm.DB, entryId string) (entity.WaitlistEntry, error)
+		GetEntriesByUserId(ctx context.Context, tx *gorm.DB, userId string) ([]entity.WaitlistEntry, error)
+		GetWaitingEntriesByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.WaitlistEntry, error)
+		GetOpenEntriesByEventIdForUpdate(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.WaitlistEntry, error)
+		GetExpiredOffers(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.WaitlistEntry, error)
+		GetEventIdsWithFreeSeats(ctx context.Context, tx *gorm.DB) ([]string, error)
+		CountActiveEntries(ctx context.Context, tx *gorm.DB, userId string, tierId string) (int64, error)
+		CountEntriesAhead(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (int64, error)
+		OfferEntry(ctx context.Context, tx *gorm.DB, entryId string, offeredAt time.Time, expiresAt time.Time) (bool, error)
+		UpdateEntry(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (entity.WaitlistEntry, error)
+	}
+
+	waitlistRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewWaitlistRepository(db *gorm.DB) WaitlistRepository {
+	return &waitlistRepository{
+		db: db,
+	}
+}
+
+func (r *waitlistRepository) CreateEntry(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Create(&entry).Error; err != nil {
+		return entity.WaitlistEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// GetEntryByIdForUpdate locks an entry until tx commits. Offered entries are
+// always locked before the event and tier their seats belong to.
+func (r *waitlistRepository) GetEntryByIdForUpdate(ctx context.Context, tx *gorm.DB, entryId string) (entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	entryUUID, err := uuid.Parse(entryId)
+	if err != nil {
+		return entity.WaitlistEntry{}, dto.ErrInvalidWaitlistEntryID
+	}
+
+	var entry entity.WaitlistEntry
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", entryUUID).Take(&entry).Error; err != nil {
+		return entity.WaitlistEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (r *waitlistRepository) GetEntriesByUserId(ctx context.Context, tx *gorm.DB, userId string) ([]entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var entries []entity.WaitlistEntry
+	if err := tx.WithContext(ctx).
+		Preload("Event").
+		Preload("Tier").
+		Where("user_id = ?", userId).
+		Order("created_at DESC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetWaitingEntriesByEventId returns the entries of an event still waiting
+// for an offer, first come first served.
+func (r *waitlistRepository) GetWaitingEntriesByEventId(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var entries []entity.WaitlistEntry
+	if err := tx.WithContext(ctx).
+		Where("event_id = ? AND status = ?", eventId, constants.ENUM_WAITLIST_STATUS_WAITING).
+		Order("created_at, id").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetOpenEntriesByEventIdForUpdate locks the entries of an event that are
+// still waiting or hold an offer until tx commits.
+func (r *waitlistRepository) GetOpenEntriesByEventIdForUpdate(ctx context.Context, tx *gorm.DB, eventId string) ([]entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var entries []entity.WaitlistEntry
+	if err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND status IN ?", eventId, []string{
+			constants.ENUM_WAITLIST_STATUS_WAITING,
+			constants.ENUM_WAITLIST_STATUS_OFFERED,
+		}).
+		Order("created_at, id").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *waitlistRepository) GetExpiredOffers(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var entries []entity.WaitlistEntry
+	if err := tx.WithContext(ctx).
+		Where("status = ? AND offer_expires_at <= ?", constants.ENUM_WAITLIST_STATUS_OFFERED, now).
+		Order("offer_expires_at").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetEventIdsWithFreeSeats returns the published events that have waiting
+// entries on a tier with seats on sale, which happens when seats were freed
+// without an offer being made right away.
+func (r *waitlistRepository) GetEventIdsWithFreeSeats(ctx context.Context, tx *gorm.DB) ([]string, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var eventIds []string
+	if err := tx.WithContext(ctx).
+		Model(&entity.WaitlistEntry{}).
+		Distinct("waitlist_entries.event_id").
+		Joins("JOIN ticket_tiers ON ticket_tiers.id = waitlist_entries.tier_id").
+		Joins("JOIN events ON events.id = waitlist_entries.event_id").
+		Where("waitlist_entries.status = ? AND ticket_tiers.availability > 0 AND events.status = ?",
+			constants.ENUM_WAITLIST_STATUS_WAITING, constants.ENUM_EVENT_STATUS_PUBLISHED).
+		Pluck("waitlist_entries.event_id", &eventIds).Error; err != nil {
+		return nil, err
+	}
+
+	return eventIds, nil
+}
+
+// CountActiveEntries counts the entries of a user for a tier that are still
+// waiting or hold an offer.
+func (r *waitlistRepository) CountActiveEntries(ctx context.Context, tx *gorm.DB, userId string, tierId string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entity.WaitlistEntry{}).
+		Where("user_id = ? AND tier_id = ? AND status IN ?", userId, tierId, []string{
+			constants.ENUM_WAITLIST_STATUS_WAITING,
+			constants.ENUM_WAITLIST_STATUS_OFFERED,
+		}).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *waitlistRepository) CountEntriesAhead(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entity.WaitlistEntry{}).
+		Where("tier_id = ? AND status = ? AND created_at < ?", entry.TierID, constants.ENUM_WAITLIST_STATUS_WAITING, entry.CreatedAt).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// OfferEntry moves a waiting entry to offered. It reports false when the
+// entry stopped waiting in the meantime, for example because its user left.
+func (r *waitlistRepository) OfferEntry(ctx context.Context, tx *gorm.DB, entryId string, offeredAt time.Time, expiresAt time.Time) (bool, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entity.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entryId, constants.ENUM_WAITLIST_STATUS_WAITING).
+		Updates(map[string]any{
+			"status":           constants.ENUM_WAITLIST_STATUS_OFFERED,
+			"offered_at":       offeredAt,
+			"offer_expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *waitlistRepository) UpdateEntry(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) (entity.WaitlistEntry, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Omit(clause.Associations).Updates(&entry).Error; err != nil {
+		return entity.WaitlistEntry{}, err
+	}
+
+	return entry, nil
+}
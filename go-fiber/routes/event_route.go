@@ -7,12 +7,14 @@ import (
 	"github.com/tapeds/go-fiber-template/service"
 )
 
-func Event(route fiber.Router, eventController controller.EventController, jwtService service.JWTService) {
+func Event(route fiber.Router, eventController controller.EventController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
 	routes := route.Group("/event")
 
-	routes.Post("add-event", middleware.Authenticate(jwtService), eventController.CreateEvent)
+	routes.Post("add-event", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), eventController.CreateEvent)
 	routes.Get("", middleware.Authenticate(jwtService), eventController.GetAllEvent)
 	routes.Get("by-id", middleware.Authenticate(jwtService), eventController.GetEventById)
 	routes.Delete("", middleware.Authenticate(jwtService), eventController.Delete)
 	routes.Put("", middleware.Authenticate(jwtService), eventController.Update)
+	routes.Put(":id/status", middleware.Authenticate(jwtService), eventController.UpdateStatus)
+	routes.Post(":id/cancel", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), eventController.Cancel)
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Export(route fiber.Router, exportController controller.ExportController, jwtService service.JWTService) {
+	routes := route.Group("/export")
+
+	routes.Get("users", middleware.Authenticate(jwtService), exportController.ExportUsers)
+	routes.Get("events", middleware.Authenticate(jwtService), exportController.ExportEvents)
+	routes.Get("events/:id/attendees", middleware.Authenticate(jwtService), exportController.ExportAttendees)
+	routes.Get("transactions", middleware.Authenticate(jwtService), exportController.ExportTransactions)
+}
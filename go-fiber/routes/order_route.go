@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Order(route fiber.Router, orderController controller.OrderController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
+	routes := route.Group("/order")
+
+	routes.Post("", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), orderController.CreateOrder)
+	routes.Get(":id", middleware.Authenticate(jwtService), orderController.GetOrderById)
+	routes.Get(":id/receipt", middleware.Authenticate(jwtService), orderController.GetOrderReceipt)
+	routes.Post(":id/cancel", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), orderController.CancelOrder)
+}
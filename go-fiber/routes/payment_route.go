@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+)
+
+func Payment(route fiber.Router, paymentController controller.PaymentController) {
+	routes := route.Group("/payment")
+
+	// Webhooks are authenticated by the provider signature, not by a user token.
+	routes.Post("webhook/:provider", paymentController.Webhook)
+}
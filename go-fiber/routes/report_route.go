@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Report(route fiber.Router, reportController controller.ReportController, jwtService service.JWTService) {
+	routes := route.Group("/report")
+
+	routes.Get("sales", middleware.Authenticate(jwtService), reportController.GetSalesReport)
+}
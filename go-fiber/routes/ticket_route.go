@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Ticket(route fiber.Router, ticketController controller.TicketController, jwtService service.JWTService) {
+	routes := route.Group("/ticket")
+
+	routes.Get("", middleware.Authenticate(jwtService), ticketController.GetMyTickets)
+	routes.Post("check-in", middleware.Authenticate(jwtService), ticketController.CheckInTicket)
+	routes.Post("check-in/batch", middleware.Authenticate(jwtService), ticketController.SyncCheckIns)
+	routes.Get("check-in/key/:event_id", middleware.Authenticate(jwtService), ticketController.GetSigningKey)
+	routes.Get("transfer", middleware.Authenticate(jwtService), ticketController.GetMyTransfers)
+	routes.Post("transfer", middleware.Authenticate(jwtService), ticketController.TransferTickets)
+	routes.Post("transfer/:id/accept", middleware.Authenticate(jwtService), ticketController.AcceptTransfer)
+	routes.Post("transfer/:id/decline", middleware.Authenticate(jwtService), ticketController.DeclineTransfer)
+	routes.Post("transfer/:id/cancel", middleware.Authenticate(jwtService), ticketController.CancelTransfer)
+	routes.Get(":code", middleware.Authenticate(jwtService), ticketController.GetTicketByCode)
+	routes.Get(":code/qr", middleware.Authenticate(jwtService), ticketController.DownloadTicketQR)
+	routes.Get(":code/history", middleware.Authenticate(jwtService), ticketController.GetTicketHistory)
+	routes.Post(":code/transfer", middleware.Authenticate(jwtService), ticketController.TransferTicket)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func TicketTier(route fiber.Router, ticketTierController controller.TicketTierController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
+	routes := route.Group("/event/:event_id/tier")
+
+	routes.Get("", middleware.Authenticate(jwtService), ticketTierController.GetTiers)
+	routes.Post("", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), ticketTierController.CreateTier)
+	routes.Put(":tier_id", middleware.Authenticate(jwtService), ticketTierController.UpdateTier)
+	routes.Delete(":tier_id", middleware.Authenticate(jwtService), ticketTierController.DeleteTier)
+}
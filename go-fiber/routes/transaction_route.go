@@ -7,12 +7,17 @@ import (
 	"github.com/tapeds/go-fiber-template/service"
 )
 
-func Transaction(route fiber.Router, transactionController controller.TransactionController, jwtService service.JWTService) {
+func Transaction(route fiber.Router, transactionController controller.TransactionController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
 	routes := route.Group("/transaction")
 
-	routes.Post("add-transaction", middleware.Authenticate(jwtService), transactionController.CreateTransaction)
+	routes.Post("add-transaction", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), transactionController.CreateTransaction)
 	routes.Get("", middleware.Authenticate(jwtService), transactionController.GetAllTransactions)
+	routes.Get("me", middleware.Authenticate(jwtService), transactionController.GetMyTransactions)
 	routes.Get("by-id", middleware.Authenticate(jwtService), transactionController.GetTransactionById)
 	routes.Delete(":id", middleware.Authenticate(jwtService), transactionController.DeleteTransaction)
 	routes.Put(":id", middleware.Authenticate(jwtService), transactionController.UpdateTransaction)
+	routes.Patch(":id/status", middleware.Authenticate(jwtService), transactionController.UpdateTransactionStatus)
+	routes.Post(":id/cancel", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), transactionController.CancelTransaction)
+	routes.Post(":id/refund", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), transactionController.RefundTransaction)
+	routes.Get(":id/invoice", middleware.Authenticate(jwtService), transactionController.GetTransactionInvoice)
 }
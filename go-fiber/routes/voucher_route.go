@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Voucher(route fiber.Router, voucherController controller.VoucherController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
+	routes := route.Group("/voucher")
+
+	routes.Post("", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), voucherController.CreateVoucher)
+	routes.Get("", middleware.Authenticate(jwtService), voucherController.GetAllVouchers)
+	routes.Get(":id", middleware.Authenticate(jwtService), voucherController.GetVoucherById)
+	routes.Put(":id", middleware.Authenticate(jwtService), voucherController.UpdateVoucher)
+	routes.Delete(":id", middleware.Authenticate(jwtService), voucherController.DeleteVoucher)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func Waitlist(route fiber.Router, waitlistController controller.WaitlistController, jwtService service.JWTService, idempotencyService service.IdempotencyService) {
+	routes := route.Group("/waitlist")
+
+	routes.Post("", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), waitlistController.JoinWaitlist)
+	routes.Get("", middleware.Authenticate(jwtService), waitlistController.GetMyWaitlist)
+	routes.Delete(":id", middleware.Authenticate(jwtService), waitlistController.LeaveWaitlist)
+	routes.Post(":id/claim", middleware.Authenticate(jwtService), middleware.Idempotency(idempotencyService), waitlistController.ClaimOffer)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+type EventCancellationService interface {
+	CancelEvent(ctx context.Context, req dto.EventCancelRequest, eventId string) (dto.EventCancelResponse, error)
+}
+
+// CancelEvent takes an event off sale for good, cancels every pending and
+// paid purchase of it and refunds what was paid in full, whatever the refund
+// policy of the event says, and closes its waitlist. Each purchase is
+// cancelled in its own database transaction so one failing refund does not
+// hold back the others; running the cancellation again picks up the
+// purchases that failed.
+func (s *transactionService) CancelEvent(ctx context.Context, req dto.EventCancelRequest, eventId string) (dto.EventCancelResponse, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId)
+		if err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		if event.Status == constants.ENUM_EVENT_STATUS_CANCELLED {
+			return nil
+		}
+
+		if err := transitionEvent(&event, constants.ENUM_EVENT_STATUS_CANCELLED); err != nil {
+			return err
+		}
+
+		return s.eventRepo.UpdateStatus(ctx, tx, event)
+	})
+	if err != nil {
+		return dto.EventCancelResponse{}, err
+	}
+
+	// Nobody is offered seats of a cancelled event anymore; entries still in
+	// line are closed here and picked up again if the cancellation is rerun.
+	if err := s.closeWaitlist(ctx, eventId); err != nil {
+		log.Printf("failed to close the waitlist of cancelled event %s: %v", eventId, err)
+	}
+
+	transactions, err := s.transactionRepo.GetLiveTransactionsByEventId(ctx, nil, eventId)
+	if err != nil {
+		return dto.EventCancelResponse{}, err
+	}
+
+	var res dto.EventCancelResponse
+	buyers := make(map[string]bool)
+
+	for _, transaction := range transactions {
+		refund, err := s.cancelEventTransaction(ctx, transaction.ID.String(), req.Reason)
+		if err != nil {
+			log.Printf("failed to cancel transaction %s of cancelled event %s: %v", transaction.ID, eventId, err)
+			res.Failed++
+			continue
+		}
+
+		res.Cancelled++
+		buyers[transaction.BuyerID] = true
+
+		if refund == nil {
+			continue
+		}
+
+		s.sendRefunds(ctx, refund)
+		if refund.Status == constants.ENUM_REFUND_STATUS_SENT {
+			res.Refunded++
+		} else {
+			res.RefundFailed++
+		}
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
+	if err != nil {
+		return dto.EventCancelResponse{}, dto.ErrEventNotFound
+	}
+	res.Event = toEventResponse(event)
+
+	// Emails go out after the cancellations committed, so failures are only
+	// logged.
+	for buyerId := range buyers {
+		if err := s.sendEventCancelledEmail(ctx, buyerId, event, req.Reason); err != nil {
+			log.Printf("failed to send cancellation email for event %s to %s: %v", eventId, buyerId, err)
+		}
+	}
+
+	return res, nil
+}
+
+// cancelEventTransaction cancels one purchase of a cancelled event and
+// records a refund of its total when it was paid. The refund is sent by the
+// caller once the cancellation committed.
+func (s *transactionService) cancelEventTransaction(ctx context.Context, transactionId string, reason string) (*entity.Refund, error) {
+	var refund *entity.Refund
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return err
+		}
+
+		// The purchase may have changed since it was listed.
+		if releasesSeats(transaction.Status) {
+			return nil
+		}
+
+		if transaction.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+			refund, err = s.recordRefund(ctx, tx, transaction, transaction.Amount, transaction.Total, reason)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.transitionTransaction(ctx, tx, transaction, constants.ENUM_TRANSACTION_STATUS_CANCELLED); err != nil {
+			return err
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// closeWaitlist cancels the waiting entries and open offers of an event.
+// Entries are locked before the event and tiers the seats of an offer go
+// back to, in the same order waitlist offers use.
+func (s *transactionService) closeWaitlist(ctx context.Context, eventId string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entries, err := s.waitlistRepo.GetOpenEntriesByEventIdForUpdate(ctx, tx, eventId)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.Status == constants.ENUM_WAITLIST_STATUS_OFFERED {
+				if err := s.eventRepo.IncreaseAvailability(ctx, tx, entry.EventID, entry.Quantity); err != nil {
+					return err
+				}
+
+				if err := s.tierRepo.IncreaseTierAvailability(ctx, tx, entry.TierID, entry.Quantity); err != nil {
+					return err
+				}
+			}
+
+			entry.Status = constants.ENUM_WAITLIST_STATUS_CANCELLED
+			if _, err := s.waitlistRepo.UpdateEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *transactionService) sendEventCancelledEmail(ctx context.Context, buyerId string, event entity.Event, reason string) error {
+	user, err := s.userRepo.GetUserById(ctx, nil, buyerId)
+	if err != nil {
+		return err
+	}
+
+	readHtml, err := os.ReadFile("utils/email-template/event_cancelled.html")
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name   string
+		Event  string
+		Reason string
+	}{
+		Name:   user.Name,
+		Event:  event.Name,
+		Reason: reason,
+	}
+
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, data); err != nil {
+		return err
+	}
+
+	return utils.SendMail(user.Email, fmt.Sprintf("%s has been cancelled", event.Name), strMail.String())
+}
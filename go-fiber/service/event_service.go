@@ -3,32 +3,63 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/dto"
 	"github.com/tapeds/go-fiber-template/entity"
 	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
 )
 
 type (
 	EventService interface {
 		CreateEvent(ctx context.Context, req dto.EventCreateRequest) (dto.EventResponse, error)
-		GetAllEventWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.EventPaginationResponse, error)
+		GetAllEventWithPagination(ctx context.Context, req dto.EventPaginationRequest) (dto.EventPaginationResponse, error)
 		GetEventById(ctx context.Context, eventId string) (dto.EventResponse, error)
 		UpdateEvent(ctx context.Context, req dto.EventUpdateRequest, eventId string) (dto.EventUpdateResponse, error)
+		UpdateEventStatus(ctx context.Context, req dto.EventStatusUpdateRequest, eventId string) (dto.EventResponse, error)
 		DeleteEvent(ctx context.Context, eventId string) error
 	}
 
 	eventService struct {
-		eventRepo  repository.EventRepository
-		jwtService JWTService
+		eventRepo       repository.EventRepository
+		tierRepo        repository.TicketTierRepository
+		jwtService      JWTService
+		waitlistService WaitlistService
+		db              *gorm.DB
 	}
 )
 
-func NewEventService(eventRepo repository.EventRepository, jwtService JWTService) EventService {
+// eventStatusTransitions lists, for every status, the statuses an event may
+// move to next. Cancelled events stay cancelled.
+var eventStatusTransitions = map[string][]string{
+	constants.ENUM_EVENT_STATUS_DRAFT: {
+		constants.ENUM_EVENT_STATUS_PUBLISHED,
+		constants.ENUM_EVENT_STATUS_CANCELLED,
+	},
+	constants.ENUM_EVENT_STATUS_PUBLISHED: {
+		constants.ENUM_EVENT_STATUS_POSTPONED,
+		constants.ENUM_EVENT_STATUS_CANCELLED,
+	},
+	constants.ENUM_EVENT_STATUS_POSTPONED: {
+		constants.ENUM_EVENT_STATUS_PUBLISHED,
+		constants.ENUM_EVENT_STATUS_CANCELLED,
+	},
+}
+
+func NewEventService(eventRepo repository.EventRepository, tierRepo repository.TicketTierRepository, jwtService JWTService, waitlistService WaitlistService, db *gorm.DB) EventService {
 	return &eventService{
-		eventRepo:  eventRepo,
-		jwtService: jwtService,
+		eventRepo:       eventRepo,
+		tierRepo:        tierRepo,
+		jwtService:      jwtService,
+		waitlistService: waitlistService,
+		db:              db,
 	}
 }
 
@@ -41,98 +72,517 @@ func (s *eventService) CreateEvent(ctx context.Context, req dto.EventCreateReque
 		return dto.EventResponse{}, dto.ErrInvalidAuthorID
 	}
 
+	refundPercentage := 100
+	if req.RefundPercentage != nil {
+		refundPercentage = *req.RefundPercentage
+	}
+
+	if refundPercentage < 0 || refundPercentage > 100 {
+		return dto.EventResponse{}, dto.ErrInvalidRefundPercentage
+	}
+
+	currency := constants.ENUM_CURRENCY_DEFAULT
+	if req.Currency != "" {
+		currency = strings.ToUpper(req.Currency)
+	}
+
+	if !isValidCurrency(currency) {
+		return dto.EventResponse{}, dto.ErrInvalidCurrency
+	}
+
+	if req.MaxTicketsPerOrder < 0 || req.MaxTicketsPerUser < 0 {
+		return dto.EventResponse{}, dto.ErrInvalidPurchaseLimit
+	}
+
+	timezone := constants.ENUM_EVENT_TIMEZONE_DEFAULT
+	if req.Timezone != "" {
+		timezone = req.Timezone
+	}
+
+	if err := validateEventSchedule(req.StartsAt, req.EndsAt, timezone, req.MeetingURL); err != nil {
+		return dto.EventResponse{}, err
+	}
+
+	tiers, err := buildEventTiers(req)
+	if err != nil {
+		return dto.EventResponse{}, err
+	}
+
 	event := entity.Event{
-		Name:        req.Name,
-		AuthorID:    authorID,
-		Price:       req.Price,
-		Capacity:    req.Capacity,
-		Availabilty: req.Availabilty,
+		Name:     req.Name,
+		AuthorID: authorID,
+		Currency: currency,
+		Status:   constants.ENUM_EVENT_STATUS_DRAFT,
+
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Timezone:    timezone,
+		Venue:       req.Venue,
+		Address:     req.Address,
+		Description: req.Description,
+		MeetingURL:  req.MeetingURL,
+
+		RefundDeadline:   req.RefundDeadline,
+		RefundPercentage: refundPercentage,
+
+		TransfersDisabled: req.TransfersDisabled,
+
+		MaxTicketsPerOrder: req.MaxTicketsPerOrder,
+		MaxTicketsPerUser:  req.MaxTicketsPerUser,
 	}
 
-	eventReg, err := s.eventRepo.CreateEvent(ctx, event)
+	// The event, its tiers and the totals derived from them are created
+	// together.
+	var eventReg entity.Event
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created, err := s.eventRepo.CreateEvent(ctx, tx, event)
+		if err != nil {
+			return dto.ErrCreateEvent
+		}
+
+		for _, tier := range tiers {
+			tier.EventID = created.ID.String()
+			if _, err := s.tierRepo.CreateTier(ctx, tx, tier); err != nil {
+				return dto.ErrCreateEvent
+			}
+		}
+
+		if err := s.eventRepo.SyncTierTotals(ctx, tx, created.ID.String()); err != nil {
+			return dto.ErrCreateEvent
+		}
+
+		eventReg, err = s.eventRepo.GetEventById(ctx, tx, created.ID.String())
+		return err
+	})
 	if err != nil {
-		return dto.EventResponse{}, dto.ErrCreateEvent
+		return dto.EventResponse{}, err
 	}
 
+	return toEventResponse(eventReg), nil
+}
+
+func toEventResponse(event entity.Event) dto.EventResponse {
 	return dto.EventResponse{
-		ID:          eventReg.ID.String(),
-		Name:        eventReg.Name,
-		AuthorID:    eventReg.AuthorID.String(),
-		Price:       eventReg.Price,
-		Capacity:    eventReg.Capacity,
-		Availabilty: eventReg.Availabilty,
-	}, nil
+		ID:          event.ID.String(),
+		Name:        event.Name,
+		AuthorID:    event.AuthorID.String(),
+		AuthorName:  event.Author.Name,
+		Price:       event.Price,
+		Capacity:    event.Capacity,
+		Availabilty: event.Availabilty,
+		Currency:    event.Currency,
+		Tiers:       toTicketTierResponses(event.Tiers),
+
+		Status:      event.Status,
+		PublishedAt: formatTimestamp(event.PublishedAt),
+		CancelledAt: formatTimestamp(event.CancelledAt),
+
+		StartsAt:    formatEventTimestamp(event.StartsAt, event.Timezone),
+		EndsAt:      formatEventTimestamp(event.EndsAt, event.Timezone),
+		Timezone:    event.Timezone,
+		Venue:       event.Venue,
+		Address:     event.Address,
+		Description: event.Description,
+		MeetingURL:  event.MeetingURL,
+
+		RefundDeadline:   formatTimestamp(event.RefundDeadline),
+		RefundPercentage: event.RefundPercentage,
+
+		TransfersDisabled: event.TransfersDisabled,
+
+		MaxTicketsPerOrder: event.MaxTicketsPerOrder,
+		MaxTicketsPerUser:  event.MaxTicketsPerUser,
+	}
 }
 
-func (s *eventService) GetAllEventWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.EventPaginationResponse, error) {
-	dataWithPaginate, err := s.eventRepo.GetAllEventWithPagination(ctx, req)
+func isValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateEventSchedule checks that an event has a start and an end in that
+// order, a timezone the database understands and, for online events, a
+// meeting URL a browser can open.
+func validateEventSchedule(startsAt, endsAt *time.Time, timezone, meetingURL string) error {
+	if startsAt == nil || endsAt == nil {
+		return dto.ErrEventScheduleRequired
+	}
+
+	if !endsAt.After(*startsAt) {
+		return dto.ErrInvalidEventSchedule
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return dto.ErrInvalidTimezone
+	}
+
+	if meetingURL != "" {
+		u, err := url.Parse(meetingURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return dto.ErrInvalidMeetingURL
+		}
+	}
+
+	return nil
+}
+
+// formatEventTimestamp shows t in the timezone of the event.
+func formatEventTimestamp(t *time.Time, timezone string) string {
+	if t == nil {
+		return ""
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return formatTimestamp(t)
+	}
+
+	local := t.In(location)
+	return formatTimestamp(&local)
+}
+
+// hasEventEnded reports whether the event is over at now. Events without an
+// end never are.
+func hasEventEnded(event entity.Event, now time.Time) bool {
+	return event.EndsAt != nil && !now.Before(*event.EndsAt)
+}
+
+// buildEventTiers returns the tiers a new event starts with. Requests
+// without tiers get a single default tier from the event price and capacity.
+func buildEventTiers(req dto.EventCreateRequest) ([]entity.TicketTier, error) {
+	if len(req.Tiers) == 0 {
+		availability := req.Availabilty
+		if availability <= 0 || availability > req.Capacity {
+			availability = req.Capacity
+		}
+
+		tier := entity.TicketTier{
+			Name:         constants.ENUM_TICKET_TIER_DEFAULT,
+			Price:        req.Price,
+			Capacity:     req.Capacity,
+			Availability: availability,
+		}
+		if err := validateTier(tier); err != nil {
+			return nil, err
+		}
+
+		return []entity.TicketTier{tier}, nil
+	}
+
+	tiers := make([]entity.TicketTier, 0, len(req.Tiers))
+	for _, tierReq := range req.Tiers {
+		tier := entity.TicketTier{
+			Name:         tierReq.Name,
+			Price:        tierReq.Price,
+			Capacity:     tierReq.Capacity,
+			Availability: tierReq.Capacity,
+			SaleStartsAt: tierReq.SaleStartsAt,
+			SaleEndsAt:   tierReq.SaleEndsAt,
+		}
+
+		if err := validateTier(tier); err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
+
+func (s *eventService) GetAllEventWithPagination(ctx context.Context, req dto.EventPaginationRequest) (dto.EventPaginationResponse, error) {
+	filter, err := buildEventFilter(req)
+	if err != nil {
+		return dto.EventPaginationResponse{}, err
+	}
+
+	if err := decodePageCursor(&filter.PaginationRequest, s.jwtService.CursorSigningKey()); err != nil {
+		return dto.EventPaginationResponse{}, err
+	}
+
+	dataWithPaginate, err := s.eventRepo.GetAllEventWithPagination(ctx, nil, filter)
 	if err != nil {
 		return dto.EventPaginationResponse{}, err
 	}
 
 	var datas []dto.EventResponse
 	for _, event := range dataWithPaginate.Events {
-		data := dto.EventResponse{
-			ID:          event.ID.String(),
-			Name:        event.Name,
-			AuthorID:    event.AuthorID.String(),
-			Price:       event.Price,
-			Capacity:    event.Capacity,
-			Availabilty: event.Availabilty,
+		data := toEventResponse(event)
+
+		if event.Author.ID == uuid.Nil {
+			data.AuthorName = "Unknown"
 		}
 
 		datas = append(datas, data)
 	}
 
+	// The next keyset page continues after the last row of this one.
+	var nextCursor string
+	if n := len(dataWithPaginate.Events); dataWithPaginate.HasMore && n > 0 {
+		last := dataWithPaginate.Events[n-1]
+		nextCursor = utils.EncodeCursor(s.jwtService.CursorSigningKey(), last.CreatedAt, last.ID.String())
+	}
+
 	return dto.EventPaginationResponse{
 		Data: datas,
 		PaginationResponse: dto.PaginationResponse{
-			Page:    dataWithPaginate.Page,
-			PerPage: dataWithPaginate.PerPage,
-			MaxPage: dataWithPaginate.MaxPage,
-			Count:   dataWithPaginate.Count,
+			Page:       dataWithPaginate.Page,
+			PerPage:    dataWithPaginate.PerPage,
+			MaxPage:    dataWithPaginate.MaxPage,
+			Count:      dataWithPaginate.Count,
+			HasMore:    dataWithPaginate.HasMore,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
 
+// buildEventFilter validates the search and filters of the event list.
+func buildEventFilter(req dto.EventPaginationRequest) (dto.EventFilter, error) {
+	filter := dto.EventFilter{
+		PaginationRequest: req.PaginationRequest,
+		When:              req.When,
+		MinPrice:          req.MinPrice,
+		MaxPrice:          req.MaxPrice,
+		AuthorID:          req.AuthorID,
+		HideSoldOut:       req.HideSoldOut,
+		PublishedOnly:     req.PublishedOnly,
+	}
+	filter.Search = strings.TrimSpace(req.Search)
+
+	switch req.When {
+	case "", constants.ENUM_EVENT_WHEN_UPCOMING, constants.ENUM_EVENT_WHEN_ONGOING, constants.ENUM_EVENT_WHEN_PAST:
+	default:
+		return dto.EventFilter{}, dto.ErrInvalidEventWhen
+	}
+
+	if (req.MinPrice != nil && *req.MinPrice < 0) || (req.MaxPrice != nil && *req.MaxPrice < 0) ||
+		(req.MinPrice != nil && req.MaxPrice != nil && *req.MaxPrice < *req.MinPrice) {
+		return dto.EventFilter{}, dto.ErrInvalidPriceRange
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, req.From, time.UTC)
+		if err != nil {
+			return dto.EventFilter{}, dto.ErrInvalidEventDate
+		}
+		filter.From = from
+	}
+
+	// To is inclusive, so the range ends when the following day starts.
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, time.UTC)
+		if err != nil {
+			return dto.EventFilter{}, dto.ErrInvalidEventDate
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return dto.EventFilter{}, dto.ErrInvalidEventDateRange
+	}
+
+	if req.AuthorID != "" {
+		if _, err := uuid.Parse(req.AuthorID); err != nil {
+			return dto.EventFilter{}, dto.ErrInvalidAuthorID
+		}
+	}
+
+	// Keyset pages keep the creation order the cursor points into.
+	if req.Cursor != nil && req.Sort != "" {
+		return dto.EventFilter{}, dto.ErrCursorWithSort
+	}
+
+	filter.SortBy = strings.TrimPrefix(req.Sort, "-")
+	filter.Descending = strings.HasPrefix(req.Sort, "-")
+	switch filter.SortBy {
+	case "", constants.ENUM_EVENT_SORT_PRICE, constants.ENUM_EVENT_SORT_DATE, constants.ENUM_EVENT_SORT_POPULARITY:
+	default:
+		return dto.EventFilter{}, dto.ErrInvalidEventSort
+	}
+
+	return filter, nil
+}
+
 func (s *eventService) GetEventById(ctx context.Context, eventId string) (dto.EventResponse, error) {
-	event, err := s.eventRepo.GetEventById(ctx, eventId)
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
 	if err != nil {
 		return dto.EventResponse{}, dto.ErrGetEventById
 	}
 
-	return dto.EventResponse{
-		ID:          event.ID.String(),
-		Name:        event.Name,
-		AuthorID:    event.AuthorID.String(),
-		Price:       event.Price,
-		Capacity:    event.Capacity,
-		Availabilty: event.Availabilty,
-	}, nil
+	return toEventResponse(event), nil
 }
 
 func (s *eventService) UpdateEvent(ctx context.Context, req dto.EventUpdateRequest, eventId string) (dto.EventUpdateResponse, error) {
-	existingEvent, err := s.eventRepo.GetEventById(ctx, eventId)
-	if err != nil {
-		return dto.EventUpdateResponse{}, fmt.Errorf("failed to fetch event: %v", err)
+	if req.RefundPercentage != nil && (*req.RefundPercentage < 0 || *req.RefundPercentage > 100) {
+		return dto.EventUpdateResponse{}, dto.ErrInvalidRefundPercentage
 	}
 
-	capacityDifference := req.Capacity - existingEvent.Capacity
-
-	newAvailability := existingEvent.Availabilty + capacityDifference
+	// A new currency only applies to purchases made after the change.
+	req.Currency = strings.ToUpper(req.Currency)
+	if req.Currency != "" && !isValidCurrency(req.Currency) {
+		return dto.EventUpdateResponse{}, dto.ErrInvalidCurrency
+	}
 
-	updatedEvent := entity.Event{
-		ID:          existingEvent.ID,
-		Name:        req.Name,
-		AuthorID:    existingEvent.AuthorID,
-		Price:       req.Price,
-		Capacity:    req.Capacity,
-		Availabilty: newAvailability,
+	if (req.MaxTicketsPerOrder != nil && *req.MaxTicketsPerOrder < 0) || (req.MaxTicketsPerUser != nil && *req.MaxTicketsPerUser < 0) {
+		return dto.EventUpdateResponse{}, dto.ErrInvalidPurchaseLimit
 	}
 
-	event, err := s.eventRepo.UpdateEvent(ctx, updatedEvent)
+	var event entity.Event
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existingEvent, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId)
+		if err != nil {
+			return fmt.Errorf("failed to fetch event: %v", err)
+		}
+
+		tiers, err := s.tierRepo.GetTiersByEventId(ctx, tx, eventId)
+		if err != nil {
+			return fmt.Errorf("failed to fetch ticket tiers: %v", err)
+		}
+
+		// Price and capacity on the event are totals of its tiers. They are
+		// only changed when sent, and only while the event sells a single
+		// tier.
+		priceChanged := req.Price != nil && *req.Price != existingEvent.Price
+		capacityChanged := req.Capacity != nil && *req.Capacity != existingEvent.Capacity
+		if priceChanged || capacityChanged {
+			if len(tiers) != 1 {
+				return dto.ErrEventHasTiers
+			}
+
+			tier := tiers[0]
+			if req.Price != nil {
+				tier.Price = *req.Price
+			}
+
+			if req.Capacity != nil {
+				if err := resizeTier(&tier, *req.Capacity); err != nil {
+					return err
+				}
+			}
+
+			if err := validateTier(tier); err != nil {
+				return err
+			}
+
+			if _, err := s.tierRepo.UpdateTier(ctx, tx, tier); err != nil {
+				return fmt.Errorf("failed to update ticket tier: %v", err)
+			}
+		}
+
+		updatedEvent := entity.Event{
+			ID:       existingEvent.ID,
+			Name:     req.Name,
+			AuthorID: existingEvent.AuthorID,
+			Currency: req.Currency,
+		}
+
+		if _, err := s.eventRepo.UpdateEvent(ctx, tx, updatedEvent); err != nil {
+			return fmt.Errorf("failed to update event: %v", err)
+		}
+
+		if err := s.eventRepo.SyncTierTotals(ctx, tx, eventId); err != nil {
+			return fmt.Errorf("failed to update event: %v", err)
+		}
+
+		if req.RefundDeadline != nil || req.RefundPercentage != nil {
+			if req.RefundDeadline != nil {
+				existingEvent.RefundDeadline = req.RefundDeadline
+			}
+
+			if req.RefundPercentage != nil {
+				existingEvent.RefundPercentage = *req.RefundPercentage
+			}
+
+			if err := s.eventRepo.UpdateRefundPolicy(ctx, tx, existingEvent); err != nil {
+				return fmt.Errorf("failed to update refund policy: %v", err)
+			}
+		}
+
+		// The schedule is checked as a whole, with the fields not sent kept
+		// as they are.
+		if req.StartsAt != nil || req.EndsAt != nil || req.Timezone != nil || req.Venue != nil ||
+			req.Address != nil || req.Description != nil || req.MeetingURL != nil {
+			if req.StartsAt != nil {
+				existingEvent.StartsAt = req.StartsAt
+			}
+
+			if req.EndsAt != nil {
+				existingEvent.EndsAt = req.EndsAt
+			}
+
+			if req.Timezone != nil {
+				existingEvent.Timezone = *req.Timezone
+			}
+
+			if req.Venue != nil {
+				existingEvent.Venue = *req.Venue
+			}
+
+			if req.Address != nil {
+				existingEvent.Address = *req.Address
+			}
+
+			if req.Description != nil {
+				existingEvent.Description = *req.Description
+			}
+
+			if req.MeetingURL != nil {
+				existingEvent.MeetingURL = *req.MeetingURL
+			}
+
+			if err := validateEventSchedule(existingEvent.StartsAt, existingEvent.EndsAt, existingEvent.Timezone, existingEvent.MeetingURL); err != nil {
+				return err
+			}
+
+			if err := s.eventRepo.UpdateSchedule(ctx, tx, existingEvent); err != nil {
+				return fmt.Errorf("failed to update schedule: %v", err)
+			}
+		}
+
+		if req.TransfersDisabled != nil {
+			existingEvent.TransfersDisabled = *req.TransfersDisabled
+
+			if err := s.eventRepo.UpdateTransferPolicy(ctx, tx, existingEvent); err != nil {
+				return fmt.Errorf("failed to update transfer policy: %v", err)
+			}
+		}
+
+		// Lower limits do not touch tickets already bought, they only stop
+		// further purchases.
+		if req.MaxTicketsPerOrder != nil || req.MaxTicketsPerUser != nil {
+			if req.MaxTicketsPerOrder != nil {
+				existingEvent.MaxTicketsPerOrder = *req.MaxTicketsPerOrder
+			}
+
+			if req.MaxTicketsPerUser != nil {
+				existingEvent.MaxTicketsPerUser = *req.MaxTicketsPerUser
+			}
+
+			if err := s.eventRepo.UpdatePurchaseLimits(ctx, tx, existingEvent); err != nil {
+				return fmt.Errorf("failed to update purchase limits: %v", err)
+			}
+		}
+
+		event, err = s.eventRepo.GetEventById(ctx, tx, eventId)
+		return err
+	})
 	if err != nil {
-		return dto.EventUpdateResponse{}, fmt.Errorf("failed to update event: %v", err)
+		return dto.EventUpdateResponse{}, err
+	}
+
+	// A larger capacity may free seats people are waiting for.
+	if err := s.waitlistService.OfferSeats(ctx, eventId); err != nil {
+		log.Printf("error offering seats of event %s: %v", eventId, err)
 	}
 
 	updatedEventDTO := dto.EventUpdateResponse{
@@ -142,18 +592,111 @@ func (s *eventService) UpdateEvent(ctx context.Context, req dto.EventUpdateReque
 		Price:       event.Price,
 		Capacity:    event.Capacity,
 		Availabilty: event.Availabilty,
+		Currency:    event.Currency,
+		Tiers:       toTicketTierResponses(event.Tiers),
+
+		Status:      event.Status,
+		PublishedAt: formatTimestamp(event.PublishedAt),
+		CancelledAt: formatTimestamp(event.CancelledAt),
+
+		StartsAt:    formatEventTimestamp(event.StartsAt, event.Timezone),
+		EndsAt:      formatEventTimestamp(event.EndsAt, event.Timezone),
+		Timezone:    event.Timezone,
+		Venue:       event.Venue,
+		Address:     event.Address,
+		Description: event.Description,
+		MeetingURL:  event.MeetingURL,
+
+		RefundDeadline:   formatTimestamp(event.RefundDeadline),
+		RefundPercentage: event.RefundPercentage,
+
+		TransfersDisabled: event.TransfersDisabled,
+
+		MaxTicketsPerOrder: event.MaxTicketsPerOrder,
+		MaxTicketsPerUser:  event.MaxTicketsPerUser,
 	}
 
 	return updatedEventDTO, nil
 }
 
+// UpdateEventStatus publishes and postpones events. Cancelling goes through
+// the cancellation of the transaction service, which also refunds buyers.
+func (s *eventService) UpdateEventStatus(ctx context.Context, req dto.EventStatusUpdateRequest, eventId string) (dto.EventResponse, error) {
+	if _, ok := eventStatusTransitions[req.Status]; !ok && req.Status != constants.ENUM_EVENT_STATUS_CANCELLED {
+		return dto.EventResponse{}, dto.ErrInvalidEventStatus
+	}
+
+	if req.Status == constants.ENUM_EVENT_STATUS_CANCELLED {
+		return dto.EventResponse{}, dto.ErrCancelEventAction
+	}
+
+	var event entity.Event
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existingEvent, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId)
+		if err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		if err := transitionEvent(&existingEvent, req.Status); err != nil {
+			return err
+		}
+
+		if err := s.eventRepo.UpdateStatus(ctx, tx, existingEvent); err != nil {
+			return fmt.Errorf("failed to update event status: %v", err)
+		}
+
+		event, err = s.eventRepo.GetEventById(ctx, tx, eventId)
+		return err
+	})
+	if err != nil {
+		return dto.EventResponse{}, err
+	}
+
+	return toEventResponse(event), nil
+}
+
+// transitionEvent moves an event to the given status and stamps the
+// timestamp belonging to it. The first publication is kept.
+func transitionEvent(event *entity.Event, status string) error {
+	if !canTransitionEvent(event.Status, status) {
+		return &dto.ErrInvalidEventStatusTransition{
+			From: event.Status,
+			To:   status,
+		}
+	}
+
+	now := time.Now()
+	event.Status = status
+
+	switch status {
+	case constants.ENUM_EVENT_STATUS_PUBLISHED:
+		if event.PublishedAt == nil {
+			event.PublishedAt = &now
+		}
+	case constants.ENUM_EVENT_STATUS_CANCELLED:
+		event.CancelledAt = &now
+	}
+
+	return nil
+}
+
+func canTransitionEvent(from string, to string) bool {
+	for _, next := range eventStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *eventService) DeleteEvent(ctx context.Context, eventId string) error {
-	event, err := s.eventRepo.GetEventById(ctx, eventId)
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
 	if err != nil {
 		return dto.ErrEventNotFound
 	}
 
-	err = s.eventRepo.DeleteEvent(ctx, event.ID.String())
+	err = s.eventRepo.DeleteEvent(ctx, nil, event.ID.String())
 	if err != nil {
 		return dto.ErrDeleteEvent
 	}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	// ExportFunc writes an export that was already validated. It runs while
+	// the response is streamed, after the status code was sent.
+	ExportFunc func(ctx context.Context, w io.Writer) error
+
+	ExportService interface {
+		ExportUsers(ctx context.Context, req dto.UserExportRequest) (ExportFunc, error)
+		ExportEvents(ctx context.Context, req dto.EventExportRequest) (ExportFunc, error)
+		ExportTransactions(ctx context.Context, req dto.TransactionExportRequest) (ExportFunc, error)
+		ExportAttendees(ctx context.Context, eventId string, req dto.ExportRequest) (ExportFunc, error)
+	}
+
+	exportService struct {
+		userRepo        repository.UserRepository
+		eventRepo       repository.EventRepository
+		transactionRepo repository.TransactionRepository
+		ticketRepo      repository.TicketRepository
+	}
+)
+
+func NewExportService(userRepo repository.UserRepository, eventRepo repository.EventRepository, transactionRepo repository.TransactionRepository, ticketRepo repository.TicketRepository) ExportService {
+	return &exportService{
+		userRepo:        userRepo,
+		eventRepo:       eventRepo,
+		transactionRepo: transactionRepo,
+		ticketRepo:      ticketRepo,
+	}
+}
+
+func (s *exportService) ExportUsers(ctx context.Context, req dto.UserExportRequest) (ExportFunc, error) {
+	format, err := exportFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, w io.Writer) error {
+		return writeExport(w, format, []string{"ID", "Name", "Email", "Phone", "Role", "Verified", "Created At"}, func(write func([]string) error) error {
+			return s.userRepo.FindUsersInBatches(ctx, nil, req.Search, constants.ENUM_EXPORT_BATCH_SIZE, func(users []entity.User) error {
+				for _, user := range users {
+					if err := write([]string{
+						user.ID.String(),
+						user.Name,
+						user.Email,
+						user.TelpNumber,
+						user.Role,
+						strconv.FormatBool(user.IsVerified),
+						user.CreatedAt.Format(time.RFC3339),
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	}, nil
+}
+
+// ExportEvents exports the events the event list would show for the same
+// search and filters, without paging or sorting them.
+func (s *exportService) ExportEvents(ctx context.Context, req dto.EventExportRequest) (ExportFunc, error) {
+	format, err := exportFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	req.PaginationRequest = dto.PaginationRequest{Search: req.Search}
+	req.Sort = ""
+
+	filter, err := buildEventFilter(req.EventPaginationRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, w io.Writer) error {
+		return writeExport(w, format, []string{"ID", "Name", "Author ID", "Currency", "Price", "Capacity", "Availability", "Refund Percentage", "Created At"}, func(write func([]string) error) error {
+			return s.eventRepo.FindEventsInBatches(ctx, nil, filter, constants.ENUM_EXPORT_BATCH_SIZE, func(events []entity.Event) error {
+				for _, event := range events {
+					if err := write([]string{
+						event.ID.String(),
+						event.Name,
+						event.AuthorID.String(),
+						event.Currency,
+						strconv.Itoa(event.Price),
+						strconv.Itoa(event.Capacity),
+						strconv.Itoa(event.Availabilty),
+						strconv.Itoa(event.RefundPercentage),
+						event.CreatedAt.Format(time.RFC3339),
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	}, nil
+}
+
+func (s *exportService) ExportTransactions(ctx context.Context, req dto.TransactionExportRequest) (ExportFunc, error) {
+	format, err := exportFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != "" && !isValidTransactionStatus(req.Status) {
+		return nil, dto.ErrInvalidTransactionStatus
+	}
+
+	filter := dto.TransactionPaginationRequest{
+		Status:   req.Status,
+		AuthorID: req.AuthorID,
+	}
+
+	return func(ctx context.Context, w io.Writer) error {
+		return writeExport(w, format, []string{"ID", "Order ID", "Buyer Name", "Buyer Email", "Event", "Tier", "Quantity", "Unit Price", "Subtotal", "Discount", "Fees", "Total", "Currency", "Status", "Created At", "Paid At"}, func(write func([]string) error) error {
+			return s.transactionRepo.FindTransactionsInBatches(ctx, nil, filter, constants.ENUM_EXPORT_BATCH_SIZE, func(transactions []entity.Transaction) error {
+				for _, transaction := range transactions {
+					if err := write([]string{
+						transaction.ID.String(),
+						transaction.OrderID,
+						transaction.Buyer.Name,
+						transaction.Buyer.Email,
+						transaction.Event.Name,
+						transaction.Tier.Name,
+						strconv.Itoa(transaction.Amount),
+						strconv.Itoa(transaction.UnitPrice),
+						strconv.Itoa(transaction.Subtotal),
+						strconv.Itoa(transaction.Discount),
+						strconv.Itoa(transaction.Fees),
+						strconv.Itoa(transaction.Total),
+						transaction.Currency,
+						transaction.Status,
+						transaction.CreatedAt.Format(time.RFC3339),
+						formatTimestamp(transaction.PaidAt),
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	}, nil
+}
+
+// ExportAttendees lists who holds tickets of an event for its organizer or
+// an admin, who sends an empty AuthorID. Events of other organizers are
+// reported as not found.
+func (s *exportService) ExportAttendees(ctx context.Context, eventId string, req dto.ExportRequest) (ExportFunc, error) {
+	format, err := exportFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uuid.Parse(eventId); err != nil {
+		return nil, dto.ErrInvalidEventID
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
+	if err != nil || (req.AuthorID != "" && event.AuthorID.String() != req.AuthorID) {
+		return nil, dto.ErrEventNotFound
+	}
+
+	return func(ctx context.Context, w io.Writer) error {
+		return writeExport(w, format, []string{"Name", "Email", "Quantity", "Checked In", "Check-in Status"}, func(write func([]string) error) error {
+			return s.ticketRepo.FindAttendeesByEventId(ctx, nil, eventId, func(attendee dto.AttendeeRow) error {
+				return write([]string{
+					attendee.Name,
+					attendee.Email,
+					strconv.Itoa(attendee.Quantity),
+					strconv.Itoa(attendee.CheckedIn),
+					attendeeStatus(attendee),
+				})
+			})
+		})
+	}, nil
+}
+
+// writeExport writes the header, then lets rows write every row, and
+// finishes the file even when rows failed so the client gets what was
+// written so far.
+func writeExport(w io.Writer, format string, header []string, rows func(write func([]string) error) error) error {
+	writer, err := utils.NewTableWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return err
+	}
+
+	rowsErr := rows(writer.WriteRow)
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return rowsErr
+}
+
+func exportFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return utils.EXPORT_FORMAT_CSV, nil
+	case utils.EXPORT_FORMAT_CSV, utils.EXPORT_FORMAT_XLSX:
+		return format, nil
+	}
+
+	return "", dto.ErrInvalidExportFormat
+}
+
+func attendeeStatus(attendee dto.AttendeeRow) string {
+	switch {
+	case attendee.CheckedIn == 0:
+		return constants.ENUM_ATTENDEE_NOT_CHECKED_IN
+	case attendee.CheckedIn < attendee.Quantity:
+		return constants.ENUM_ATTENDEE_PARTIAL
+	}
+
+	return constants.ENUM_ATTENDEE_CHECKED_IN
+}
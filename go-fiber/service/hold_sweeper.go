@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+)
+
+type (
+	// HoldSweeper releases seat holds that expired before their purchase was
+	// paid and waitlist offers that were not claimed in time, and deletes
+	// idempotency keys past their TTL. All are expired in batches so a
+	// backlog after downtime does not end up in one long database
+	// transaction.
+	HoldSweeper interface {
+		Run(ctx context.Context)
+	}
+
+	holdSweeper struct {
+		transactionService TransactionService
+		waitlistService    WaitlistService
+		idempotencyService IdempotencyService
+		interval           time.Duration
+		batchSize          int
+	}
+)
+
+func NewHoldSweeper(transactionService TransactionService, waitlistService WaitlistService, idempotencyService IdempotencyService) HoldSweeper {
+	interval := getEnvInt("HOLD_SWEEP_INTERVAL_SECONDS", constants.ENUM_HOLD_SWEEP_INTERVAL_DEFAULT)
+	if interval == 0 {
+		interval = constants.ENUM_HOLD_SWEEP_INTERVAL_DEFAULT
+	}
+
+	batchSize := getEnvInt("HOLD_SWEEP_BATCH_SIZE", constants.ENUM_HOLD_SWEEP_BATCH_SIZE_DEFAULT)
+	if batchSize == 0 {
+		batchSize = constants.ENUM_HOLD_SWEEP_BATCH_SIZE_DEFAULT
+	}
+
+	return &holdSweeper{
+		transactionService: transactionService,
+		waitlistService:    waitlistService,
+		idempotencyService: idempotencyService,
+		interval:           time.Duration(interval) * time.Second,
+		batchSize:          batchSize,
+	}
+}
+
+// Run sweeps every interval until ctx is cancelled.
+func (w *holdSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.sweep(ctx)
+		}
+	}
+}
+
+// sweep keeps taking batches until one comes back short. A failed batch is
+// retried on the next tick. Seats freed without an offer being made, for
+// example by failed payments, are offered to the waitlists last.
+func (w *holdSweeper) sweep(ctx context.Context) {
+	w.drain(ctx, "seat holds", w.transactionService.ExpireHolds)
+	w.drain(ctx, "waitlist offers", w.waitlistService.ExpireOffers)
+
+	if err := w.waitlistService.OfferFreedSeats(ctx); err != nil {
+		log.Printf("error offering freed seats: %v", err)
+	}
+
+	w.drain(ctx, "idempotency keys", w.idempotencyService.ExpireKeys)
+}
+
+func (w *holdSweeper) drain(ctx context.Context, name string, expire func(ctx context.Context, limit int) (int, error)) {
+	for {
+		expired, err := expire(ctx, w.batchSize)
+		if err != nil {
+			log.Printf("error expiring %s: %v", name, err)
+			return
+		}
+
+		if expired < w.batchSize {
+			return
+		}
+	}
+}
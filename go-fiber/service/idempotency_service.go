@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+)
+
+type (
+	IdempotencyService interface {
+		Begin(ctx context.Context, req dto.IdempotencyBeginRequest) (*dto.IdempotencyStoredResponse, error)
+		Complete(ctx context.Context, userId string, key string, statusCode int, body []byte) error
+		Release(ctx context.Context, userId string, key string) error
+		ExpireKeys(ctx context.Context, limit int) (int, error)
+	}
+
+	idempotencyService struct {
+		idempotencyRepo repository.IdempotencyRepository
+	}
+)
+
+const IDEMPOTENCY_KEY_TTL = 24 * time.Hour
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+	}
+}
+
+// Begin claims the key for a new request. When the key was already used for
+// the same request and that request finished, the stored response is
+// returned so the caller can replay it instead of running the handler again.
+func (s *idempotencyService) Begin(ctx context.Context, req dto.IdempotencyBeginRequest) (*dto.IdempotencyStoredResponse, error) {
+	for {
+		_, created, err := s.idempotencyRepo.CreateIdempotencyKey(ctx, nil, entity.IdempotencyKey{
+			UserID:      req.UserID,
+			Key:         req.Key,
+			Method:      req.Method,
+			Path:        req.Path,
+			Fingerprint: req.Fingerprint,
+			ExpiresAt:   time.Now().Add(IDEMPOTENCY_KEY_TTL),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if created {
+			return nil, nil
+		}
+
+		existing, err := s.idempotencyRepo.GetIdempotencyKey(ctx, nil, req.UserID, req.Key)
+		if err != nil {
+			// Released between the insert and the lookup, claim it again.
+			continue
+		}
+
+		if time.Now().After(existing.ExpiresAt) {
+			if err := s.idempotencyRepo.DeleteIdempotencyKey(ctx, nil, req.UserID, req.Key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.Fingerprint != req.Fingerprint {
+			return nil, dto.ErrIdempotencyKeyReused
+		}
+
+		if existing.StatusCode == 0 {
+			return nil, dto.ErrIdempotencyKeyInProgress
+		}
+
+		return &dto.IdempotencyStoredResponse{
+			StatusCode: existing.StatusCode,
+			Body:       []byte(existing.Response),
+		}, nil
+	}
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, userId string, key string, statusCode int, body []byte) error {
+	existing, err := s.idempotencyRepo.GetIdempotencyKey(ctx, nil, userId, key)
+	if err != nil {
+		return err
+	}
+
+	existing.StatusCode = statusCode
+	existing.Response = string(body)
+
+	_, err = s.idempotencyRepo.UpdateIdempotencyKey(ctx, nil, existing)
+	return err
+}
+
+func (s *idempotencyService) Release(ctx context.Context, userId string, key string) error {
+	return s.idempotencyRepo.DeleteIdempotencyKey(ctx, nil, userId, key)
+}
+
+// ExpireKeys deletes up to limit keys past their TTL and reports how many it
+// deleted. Begin already ignores expired keys; this keeps the table from
+// growing with keys that are never retried.
+func (s *idempotencyService) ExpireKeys(ctx context.Context, limit int) (int, error) {
+	return s.idempotencyRepo.DeleteExpiredIdempotencyKeys(ctx, nil, time.Now(), limit)
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+const INVOICE_DIR = "invoice"
+
+type InvoiceService interface {
+	GetTransactionInvoice(ctx context.Context, transactionId string) ([]byte, string, error)
+}
+
+// issueInvoice gives a transaction that just got paid its invoice number and
+// keeps what was billed. It runs inside the payment's database transaction,
+// so the number is only used up when the payment commits. The PDF is
+// rendered afterwards by deliverInvoices.
+func (s *transactionService) issueInvoice(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) error {
+	issuedAt := time.Now()
+	if transaction.PaidAt != nil {
+		issuedAt = *transaction.PaidAt
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, tx, transaction.EventID)
+	if err != nil {
+		return dto.ErrEventNotFound
+	}
+
+	item := event.Name
+	if tier := s.transactionTier(ctx, tx, transaction); tier.Name != "" {
+		item += " - " + tier.Name
+	}
+
+	sequence, err := s.invoiceRepo.NextInvoiceNumber(ctx, tx, issuedAt.Year())
+	if err != nil {
+		return dto.ErrIssueInvoice
+	}
+
+	if _, err := s.invoiceRepo.CreateInvoice(ctx, tx, entity.Invoice{
+		TransactionID: transaction.ID.String(),
+		Number:        fmt.Sprintf("INV/%d/%06d", issuedAt.Year(), sequence),
+		Year:          issuedAt.Year(),
+		Sequence:      sequence,
+		IssuedAt:      issuedAt,
+		Item:          item,
+		Quantity:      transaction.Amount,
+		UnitPrice:     transaction.UnitPrice,
+		Subtotal:      transaction.Subtotal,
+		Discount:      transaction.Discount,
+		Fees:          transaction.Fees,
+		Total:         transaction.Total,
+		Currency:      transaction.Currency,
+	}); err != nil {
+		return dto.ErrIssueInvoice
+	}
+
+	return nil
+}
+
+// deliverInvoices renders, stores and emails the invoices of transactions
+// that were paid. It runs after the payment committed, so failures are only
+// logged; the PDF is rendered again when the invoice is downloaded.
+func (s *transactionService) deliverInvoices(ctx context.Context, transactionIds ...string) {
+	for _, transactionId := range transactionIds {
+		if err := s.deliverInvoice(ctx, transactionId); err != nil {
+			log.Printf("failed to deliver invoice of transaction %s: %v", transactionId, err)
+		}
+	}
+}
+
+func (s *transactionService) deliverInvoice(ctx context.Context, transactionId string) error {
+	invoice, err := s.invoiceRepo.GetInvoiceByTransactionId(ctx, nil, transactionId)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, nil, transactionId)
+	if err != nil {
+		return err
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, transaction.BuyerID)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, transaction.EventID)
+	if err != nil {
+		return err
+	}
+
+	_, filePath, err := s.storeInvoice(ctx, invoice, buyer)
+	if err != nil {
+		return err
+	}
+
+	readHtml, err := os.ReadFile("utils/email-template/invoice.html")
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name   string
+		Event  string
+		Number string
+		Total  string
+	}{
+		Name:   buyer.Name,
+		Event:  event.Name,
+		Number: invoice.Number,
+		Total:  formatPrice(invoice.Total, invoice.Currency),
+	}
+
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, data); err != nil {
+		return err
+	}
+
+	return utils.SendMail(buyer.Email, fmt.Sprintf("Invoice %s for %s", invoice.Number, event.Name), strMail.String(), filePath)
+}
+
+// GetTransactionInvoice returns the PDF invoice of a paid transaction and
+// its file name. A PDF that was never stored or went missing is rendered
+// again from what the invoice billed.
+func (s *transactionService) GetTransactionInvoice(ctx context.Context, transactionId string) ([]byte, string, error) {
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, nil, transactionId)
+	if err != nil {
+		return nil, "", dto.ErrTransactionNotFound
+	}
+
+	invoice, err := s.invoiceRepo.GetInvoiceByTransactionId(ctx, nil, transactionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", dto.ErrInvoiceNotFound
+		}
+		return nil, "", err
+	}
+
+	// Invoices stored before they moved out of the public assets are
+	// rendered again into the private storage.
+	filename := invoiceFilename(invoice)
+	if utils.IsPrivateFile(invoice.FilePath) {
+		if pdf, err := os.ReadFile(invoice.FilePath); err == nil {
+			return pdf, filename, nil
+		}
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, transaction.BuyerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdf, _, err := s.storeInvoice(ctx, invoice, buyer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pdf, filename, nil
+}
+
+// storeInvoice renders an invoice and saves it to the private storage under
+// a random name, returning the PDF and where it was saved. Invoices are only
+// handed out through the download of their transaction.
+func (s *transactionService) storeInvoice(ctx context.Context, invoice entity.Invoice, buyer entity.User) ([]byte, string, error) {
+	pdf, err := renderInvoice(invoice, buyer)
+	if err != nil {
+		return nil, "", dto.ErrRenderInvoice
+	}
+
+	filePath, err := utils.SavePrivateFile(pdf, INVOICE_DIR+"/"+uuid.NewString()+".pdf")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.invoiceRepo.UpdateInvoiceFile(ctx, nil, invoice.ID.String(), filePath); err != nil {
+		return nil, "", err
+	}
+
+	// A copy left in the public assets by an earlier version goes away.
+	if invoice.FilePath != "" && !utils.IsPrivateFile(invoice.FilePath) {
+		if err := os.Remove(invoice.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove public invoice %s: %v", invoice.FilePath, err)
+		}
+	}
+
+	return pdf, filePath, nil
+}
+
+func renderInvoice(invoice entity.Invoice, buyer entity.User) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.Cell(0, 10, "INVOICE")
+	pdf.Ln(14)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, "Number: "+invoice.Number)
+	pdf.Ln(6)
+	pdf.Cell(0, 6, "Issued: "+invoice.IssuedAt.Format("2006-01-02"))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, "Transaction: "+invoice.TransactionID)
+	pdf.Ln(12)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.Cell(0, 6, "Billed to")
+	pdf.Ln(7)
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range []string{buyer.Name, buyer.Email, buyer.TelpNumber} {
+		if line == "" {
+			continue
+		}
+		pdf.Cell(0, 6, line)
+		pdf.Ln(6)
+	}
+	pdf.Ln(8)
+
+	widths := []float64{90, 20, 30, 30}
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, header := range []string{"Item", "Qty", "Unit price", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 8, header, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(widths[0], 8, invoice.Item, "", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[1], 8, fmt.Sprint(invoice.Quantity), "", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[2], 8, formatPrice(invoice.UnitPrice, invoice.Currency), "", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[3], 8, formatPrice(invoice.Subtotal, invoice.Currency), "", 0, "R", false, 0, "")
+	pdf.Ln(12)
+
+	totals := []struct {
+		label string
+		value int
+	}{
+		{"Subtotal", invoice.Subtotal},
+		{"Discount", -invoice.Discount},
+		{"Fees", invoice.Fees},
+		{"Total", invoice.Total},
+	}
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("Helvetica", "B", 10)
+		}
+		pdf.CellFormat(widths[0]+widths[1]+widths[2], 7, total.label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 7, formatPrice(total.value, invoice.Currency), "", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// invoiceFilename turns an invoice number into a file name, e.g.
+// INV/2024/000042 into INV-2024-000042.pdf.
+func invoiceFilename(invoice entity.Invoice) string {
+	return strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+}
+
+// currencyDecimals lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major unit.
+var currencyDecimals = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// formatPrice turns an amount in minor units into the major units of its
+// currency, e.g. 150000 IDR into "IDR 1500.00" and 1500 JPY into "JPY 1500".
+func formatPrice(amount int, currency string) string {
+	decimals, ok := currencyDecimals[currency]
+	if !ok {
+		decimals = 2
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	if decimals == 0 {
+		return fmt.Sprintf("%s %s%d", currency, sign, amount)
+	}
+
+	unit := 1
+	for i := 0; i < decimals; i++ {
+		unit *= 10
+	}
+
+	return fmt.Sprintf("%s %s%d.%0*d", currency, sign, amount/unit, decimals, amount%unit)
+}
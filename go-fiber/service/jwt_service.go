@@ -1,6 +1,9 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -13,6 +16,19 @@ type JWTService interface {
 	GenerateToken(userId string, role string) string
 	ValidateToken(token string) (*jwt.Token, error)
 	GetUserIDByToken(token string) (string, error)
+	GenerateTicketToken(ticketId string, code string, eventId string) (string, error)
+	ValidateTicketToken(token string, eventId string) (*TicketClaims, error)
+	TicketSigningKey(eventId string) string
+	CursorSigningKey() string
+}
+
+// TicketClaims is the payload encoded in a ticket QR code. It is signed with
+// a per-event key so scanners can verify tickets without a connection.
+type TicketClaims struct {
+	TicketID string `json:"ticket_id"`
+	Code     string `json:"code"`
+	EventID  string `json:"event_id"`
+	jwt.RegisteredClaims
 }
 
 type jwtCustomClaim struct {
@@ -81,3 +97,55 @@ func (j *jwtService) GetUserIDByToken(token string) (string, error) {
 	id := fmt.Sprintf("%v", claims["user_id"])
 	return id, nil
 }
+
+// TicketSigningKey derives the key tickets of an event are signed with from
+// the JWT secret, so handing it to a scanner does not expose the secret or
+// the keys of other events.
+func (j *jwtService) TicketSigningKey(eventId string) string {
+	mac := hmac.New(sha256.New, []byte(j.secretKey))
+	mac.Write([]byte("ticket:" + eventId))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CursorSigningKey derives the key page cursors are signed with from the JWT
+// secret.
+func (j *jwtService) CursorSigningKey() string {
+	mac := hmac.New(sha256.New, []byte(j.secretKey))
+	mac.Write([]byte("cursor"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (j *jwtService) GenerateTicketToken(ticketId string, code string, eventId string) (string, error) {
+	claims := TicketClaims{
+		ticketId,
+		code,
+		eventId,
+		jwt.RegisteredClaims{
+			Issuer:   j.issuer,
+			Subject:  ticketId,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(j.TicketSigningKey(eventId)))
+}
+
+func (j *jwtService) ValidateTicketToken(token string, eventId string) (*TicketClaims, error) {
+	claims := &TicketClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t_ *jwt.Token) (any, error) {
+		if _, ok := t_.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
+		}
+		return []byte(j.TicketSigningKey(eventId)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.EventID != eventId {
+		return nil, fmt.Errorf("ticket was issued for another event")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,641 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+)
+
+// OrderService is implemented by the transaction service: every item of an
+// order is a transaction, so both share the seat, voucher and payment flow.
+type OrderService interface {
+	CreateOrder(ctx context.Context, req dto.OrderCreateRequest) (dto.OrderResponse, error)
+	GetOrderById(ctx context.Context, orderId string) (dto.OrderResponse, error)
+	GetOrderReceipt(ctx context.Context, orderId string) (dto.OrderReceiptResponse, error)
+	CancelOrder(ctx context.Context, req dto.OrderCancelRequest, orderId string) (dto.OrderReceiptResponse, error)
+	ClaimWaitlistOffer(ctx context.Context, entryId string, userId string) (dto.OrderResponse, error)
+}
+
+// voucherUse is one voucher applied to one item of an order.
+type voucherUse struct {
+	voucher     entity.Voucher
+	transaction entity.Transaction
+	discount    int
+}
+
+func (s *transactionService) CreateOrder(ctx context.Context, req dto.OrderCreateRequest) (dto.OrderResponse, error) {
+	if s.paymentService.DefaultProvider() == nil {
+		return dto.OrderResponse{}, dto.ErrPaymentsUnavailable
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, req.BuyerID)
+	if err != nil {
+		return dto.OrderResponse{}, dto.ErrBuyerNotFound
+	}
+
+	order, err := s.placeOrder(ctx, buyer, req.Items)
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	// The order stays pending until the provider confirms the payment
+	// through the webhook.
+	if _, err := s.createCharge(ctx, order.ID.String(), buyer); err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	return s.GetOrderById(ctx, order.ID.String())
+}
+
+// ClaimWaitlistOffer turns the seats offered to a waitlist entry into a
+// pending order of its user. The offered seats are handed straight to the
+// order, so nobody else can buy them in between.
+func (s *transactionService) ClaimWaitlistOffer(ctx context.Context, entryId string, userId string) (dto.OrderResponse, error) {
+	if s.paymentService.DefaultProvider() == nil {
+		return dto.OrderResponse{}, dto.ErrPaymentsUnavailable
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, userId)
+	if err != nil {
+		return dto.OrderResponse{}, dto.ErrBuyerNotFound
+	}
+
+	var order entity.Order
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.waitlistRepo.GetEntryByIdForUpdate(ctx, tx, entryId)
+		if err != nil || entry.UserID != userId {
+			return dto.ErrWaitlistEntryNotFound
+		}
+
+		if entry.Status != constants.ENUM_WAITLIST_STATUS_OFFERED {
+			return dto.ErrWaitlistNoOffer
+		}
+
+		now := time.Now()
+		if entry.OfferExpiresAt != nil && now.After(*entry.OfferExpiresAt) {
+			return dto.ErrWaitlistOfferExpired
+		}
+
+		if err := s.releaseSeats(ctx, tx, entry.EventID, entry.TierID, entry.Quantity); err != nil {
+			return err
+		}
+
+		order, err = s.reserveOrder(ctx, tx, buyer, []dto.OrderItemRequest{{
+			EventID: entry.EventID,
+			TierID:  entry.TierID,
+			Amount:  entry.Quantity,
+		}})
+		if err != nil {
+			return err
+		}
+
+		orderId := order.ID.String()
+		entry.Status = constants.ENUM_WAITLIST_STATUS_CLAIMED
+		entry.ClaimedAt = &now
+		entry.OrderID = &orderId
+
+		_, err = s.waitlistRepo.UpdateEntry(ctx, tx, entry)
+		return err
+	})
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	if _, err := s.createCharge(ctx, order.ID.String(), buyer); err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	return s.GetOrderById(ctx, order.ID.String())
+}
+
+// placeOrder reserves the seats of every item, redeems their vouchers and
+// stores the order with its items. Either all items are reserved or none.
+func (s *transactionService) placeOrder(ctx context.Context, buyer entity.User, items []dto.OrderItemRequest) (entity.Order, error) {
+	var order entity.Order
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = s.reserveOrder(ctx, tx, buyer, items)
+		return err
+	})
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// reserveOrder does the work of placeOrder inside tx, so callers that hold
+// other locks can place an order in the same transaction.
+func (s *transactionService) reserveOrder(ctx context.Context, tx *gorm.DB, buyer entity.User, items []dto.OrderItemRequest) (entity.Order, error) {
+	if len(items) == 0 {
+		return entity.Order{}, dto.ErrEmptyOrder
+	}
+
+	for _, item := range items {
+		if item.Amount <= 0 {
+			return entity.Order{}, dto.ErrInvalidAmount
+		}
+	}
+
+	// Seats are taken in event and tier order, so two orders sharing events
+	// lock their rows in the same sequence.
+	items = append([]dto.OrderItemRequest(nil), items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].EventID != items[j].EventID {
+			return items[i].EventID < items[j].EventID
+		}
+		return items[i].TierID < items[j].TierID
+	})
+
+	var (
+		transactions = make([]entity.Transaction, 0, len(items))
+		vouchers     = make([][]entity.Voucher, 0, len(items))
+		discounts    = make([][]int, 0, len(items))
+		tiers        = make(map[string]bool)
+		perEvent     = make(map[string]int)
+	)
+
+	order := entity.Order{
+		BuyerID: buyer.ID.String(),
+		Status:  constants.ENUM_TRANSACTION_STATUS_PENDING,
+	}
+
+	for _, item := range items {
+		event, err := s.eventRepo.GetEventById(ctx, tx, item.EventID)
+		if err != nil {
+			return entity.Order{}, dto.ErrEventNotFound
+		}
+
+		if event.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+			return entity.Order{}, dto.ErrEventNotOnSale
+		}
+
+		if hasEventEnded(event, time.Now()) {
+			return entity.Order{}, dto.ErrEventEnded
+		}
+
+		tier, err := selectTier(event, item.TierID)
+		if err != nil {
+			return entity.Order{}, err
+		}
+
+		if tiers[tier.ID.String()] {
+			return entity.Order{}, dto.ErrDuplicateOrderItem
+		}
+		tiers[tier.ID.String()] = true
+
+		if !isTierOnSale(tier, time.Now()) {
+			return entity.Order{}, dto.ErrTierNotOnSale
+		}
+
+		if order.Currency == "" {
+			order.Currency = event.Currency
+		} else if order.Currency != event.Currency {
+			return entity.Order{}, dto.ErrOrderCurrencyMismatch
+		}
+
+		if err := s.takeSeats(ctx, tx, item.EventID, tier.ID.String(), item.Amount); err != nil {
+			return entity.Order{}, err
+		}
+
+		// Items of earlier tiers of the same event are not stored yet, so
+		// they are added to what the buyer already holds.
+		held, err := s.transactionRepo.SumLiveAmount(ctx, tx, buyer.ID.String(), item.EventID, "")
+		if err != nil {
+			return entity.Order{}, err
+		}
+
+		if err := checkPurchaseLimits(event, perEvent[item.EventID], held+perEvent[item.EventID], item.Amount); err != nil {
+			return entity.Order{}, err
+		}
+		perEvent[item.EventID] += item.Amount
+
+		itemVouchers, err := s.getVouchers(ctx, tx, normalizeVoucherCodes(item.VoucherCode, item.VoucherCodes), item.EventID, tier.ID.String())
+		if err != nil {
+			return entity.Order{}, err
+		}
+
+		// The price is copied onto the item so later changes to the
+		// tier do not affect this purchase.
+		subtotal := tier.Price * item.Amount
+		itemDiscounts := calculateDiscounts(subtotal, itemVouchers)
+
+		transaction := entity.Transaction{
+			EventID:   item.EventID,
+			TierID:    tier.ID.String(),
+			BuyerID:   buyer.ID.String(),
+			Amount:    item.Amount,
+			Status:    constants.ENUM_TRANSACTION_STATUS_PENDING,
+			UnitPrice: tier.Price,
+			Subtotal:  subtotal,
+			Discount:  sumDiscounts(itemDiscounts),
+			Fees:      s.feePerTicket * item.Amount,
+			Currency:  event.Currency,
+		}
+		transaction.Total = transaction.Subtotal - transaction.Discount + transaction.Fees
+
+		transactions = append(transactions, transaction)
+		vouchers = append(vouchers, itemVouchers)
+		discounts = append(discounts, itemDiscounts)
+	}
+
+	addOrderTotals(&order, transactions)
+
+	order, err := s.orderRepo.CreateOrder(ctx, tx, order)
+	if err != nil {
+		return entity.Order{}, dto.ErrCreateOrder
+	}
+
+	var (
+		uses      []voucherUse
+		expiresAt = time.Now().Add(s.holdDuration)
+	)
+	for i, transaction := range transactions {
+		transaction.OrderID = order.ID.String()
+
+		transaction, err = s.transactionRepo.CreateTransaction(ctx, tx, transaction)
+		if err != nil {
+			return entity.Order{}, dto.ErrCreateTransaction
+		}
+
+		// The seats taken above stay held for the buyer until the hold
+		// expires or the order is paid.
+		if _, err := s.holdRepo.CreateHold(ctx, tx, entity.SeatHold{
+			TransactionID: transaction.ID.String(),
+			OrderID:       order.ID.String(),
+			EventID:       transaction.EventID,
+			TierID:        transaction.TierID,
+			Quantity:      transaction.Amount,
+			Status:        constants.ENUM_SEAT_HOLD_STATUS_ACTIVE,
+			ExpiresAt:     expiresAt,
+		}); err != nil {
+			return entity.Order{}, err
+		}
+
+		order.Items = append(order.Items, transaction)
+		for j, voucher := range vouchers[i] {
+			uses = append(uses, voucherUse{
+				voucher:     voucher,
+				transaction: transaction,
+				discount:    discounts[i][j],
+			})
+		}
+	}
+
+	if err := s.redeemVouchers(ctx, tx, uses); err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// createCharge asks the default payment provider for a charge over the whole
+// order and stores the reference on the order and its items. When the
+// provider refuses, the order is marked failed so its seats go back.
+func (s *transactionService) createCharge(ctx context.Context, orderId string, buyer entity.User) (entity.Order, error) {
+	order, err := s.orderRepo.GetOrderById(ctx, nil, orderId)
+	if err != nil {
+		return entity.Order{}, dto.ErrOrderNotFound
+	}
+
+	provider := s.paymentService.DefaultProvider()
+	if provider == nil {
+		return entity.Order{}, s.failOrder(ctx, orderId, dto.ErrPaymentsUnavailable)
+	}
+
+	charge, err := provider.CreateCharge(ctx, dto.PaymentChargeRequest{
+		Reference:     orderId,
+		Amount:        order.Total,
+		Currency:      order.Currency,
+		Description:   s.describeOrder(ctx, order),
+		CustomerName:  buyer.Name,
+		CustomerEmail: buyer.Email,
+	})
+	if err != nil {
+		return entity.Order{}, s.failOrder(ctx, orderId, dto.ErrCreatePayment)
+	}
+
+	order.PaymentProvider = provider.Name()
+	order.PaymentReference = charge.Reference
+	order.PaymentURL = charge.PaymentURL
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.orderRepo.UpdateOrder(ctx, tx, order); err != nil {
+			return err
+		}
+
+		return s.transactionRepo.UpdatePaymentByOrderId(ctx, tx, orderId, order.PaymentProvider, order.PaymentReference, order.PaymentURL)
+	})
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	return order, nil
+}
+
+// describeOrder names the tickets of an order for the payment page.
+func (s *transactionService) describeOrder(ctx context.Context, order entity.Order) string {
+	names := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		event, err := s.eventRepo.GetEventById(ctx, nil, item.EventID)
+		if err != nil {
+			continue
+		}
+
+		tier := s.transactionTier(ctx, nil, item)
+		names = append(names, fmt.Sprintf("%s - %s", event.Name, tier.Name))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// failOrder marks the pending items of an order failed after its charge
+// could not be created, giving their seats and vouchers back.
+func (s *transactionService) failOrder(ctx context.Context, orderId string, cause error) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.GetOrderByIdForUpdate(ctx, tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		return s.transitionOrder(ctx, tx, order, constants.ENUM_TRANSACTION_STATUS_FAILED)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", cause, err)
+	}
+
+	return cause
+}
+
+// transitionOrder moves every pending item of a locked order to the given
+// status, then updates the order itself.
+func (s *transactionService) transitionOrder(ctx context.Context, tx *gorm.DB, order entity.Order, status string) error {
+	items, err := s.transactionRepo.GetTransactionsByOrderIdForUpdate(ctx, tx, order.ID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+			continue
+		}
+
+		if _, err := s.transitionTransaction(ctx, tx, item, status); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.syncOrder(ctx, tx, order)
+	return err
+}
+
+// lockTransaction locks a transaction together with its order. The order is
+// always locked first, the same order the order flows use, so changing one
+// item never deadlocks with a change to the whole order.
+func (s *transactionService) lockTransaction(ctx context.Context, tx *gorm.DB, transactionId string) (entity.Order, entity.Transaction, error) {
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, tx, transactionId)
+	if err != nil {
+		return entity.Order{}, entity.Transaction{}, dto.ErrTransactionNotFound
+	}
+
+	order, err := s.orderRepo.GetOrderByIdForUpdate(ctx, tx, transaction.OrderID)
+	if err != nil {
+		return entity.Order{}, entity.Transaction{}, dto.ErrOrderNotFound
+	}
+
+	transaction, err = s.transactionRepo.GetTransactionByIdForUpdate(ctx, tx, transactionId)
+	if err != nil {
+		return entity.Order{}, entity.Transaction{}, dto.ErrTransactionNotFound
+	}
+
+	return order, transaction, nil
+}
+
+// syncOrder derives the status of a locked order from its items after one of
+// them changed. While the order is unpaid its totals follow the items too;
+// once paid they keep what was charged and refunds show on the receipt. An
+// order whose last item was deleted is deleted with it.
+func (s *transactionService) syncOrder(ctx context.Context, tx *gorm.DB, order entity.Order) (entity.Order, error) {
+	items, err := s.transactionRepo.GetTransactionsByOrderIdForUpdate(ctx, tx, order.ID.String())
+	if err != nil {
+		return entity.Order{}, err
+	}
+
+	if len(items) == 0 {
+		return entity.Order{}, s.orderRepo.DeleteOrder(ctx, tx, order.ID.String())
+	}
+
+	if order.Status == constants.ENUM_TRANSACTION_STATUS_PENDING {
+		addOrderTotals(&order, items)
+
+		if err := s.orderRepo.UpdateOrderTotals(ctx, tx, order); err != nil {
+			return entity.Order{}, err
+		}
+	}
+
+	status := orderStatus(items)
+	if status == order.Status {
+		return order, nil
+	}
+
+	now := time.Now()
+	order.Status = status
+
+	switch status {
+	case constants.ENUM_TRANSACTION_STATUS_PAID:
+		order.PaidAt = &now
+	case constants.ENUM_TRANSACTION_STATUS_CANCELLED:
+		order.CancelledAt = &now
+	case constants.ENUM_TRANSACTION_STATUS_FAILED:
+		order.FailedAt = &now
+	}
+
+	return s.orderRepo.UpdateOrder(ctx, tx, order)
+}
+
+func (s *transactionService) GetOrderById(ctx context.Context, orderId string) (dto.OrderResponse, error) {
+	order, err := s.orderRepo.GetOrderById(ctx, nil, orderId)
+	if err != nil {
+		return dto.OrderResponse{}, dto.ErrOrderNotFound
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, order.BuyerID)
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	items := make([]dto.TransactionResponse, 0, len(order.Items))
+	for _, item := range order.Items {
+		res, err := s.buildTransactionResponse(ctx, item)
+		if err != nil {
+			return dto.OrderResponse{}, err
+		}
+
+		items = append(items, res)
+	}
+
+	holds, err := s.holdRepo.GetActiveHoldsByOrderId(ctx, nil, order.ID.String())
+	if err != nil {
+		return dto.OrderResponse{}, err
+	}
+
+	var holdExpiresAt *time.Time
+	if len(holds) > 0 {
+		holdExpiresAt = &holds[0].ExpiresAt
+	}
+
+	return dto.OrderResponse{
+		ID:          order.ID.String(),
+		BuyerID:     buyer.ID.String(),
+		BuyerName:   buyer.Name,
+		BuyerEmail:  buyer.Email,
+		Status:      order.Status,
+		Items:       items,
+		Subtotal:    order.Subtotal,
+		Discount:    order.Discount,
+		Fees:        order.Fees,
+		Total:       order.Total,
+		Currency:    order.Currency,
+		CreatedAt:   order.CreatedAt.String(),
+		PaidAt:      formatTimestamp(order.PaidAt),
+		CancelledAt: formatTimestamp(order.CancelledAt),
+		FailedAt:    formatTimestamp(order.FailedAt),
+
+		HoldExpiresAt: formatTimestamp(holdExpiresAt),
+
+		PaymentProvider:  order.PaymentProvider,
+		PaymentReference: order.PaymentReference,
+		PaymentURL:       order.PaymentURL,
+	}, nil
+}
+
+func (s *transactionService) GetOrderReceipt(ctx context.Context, orderId string) (dto.OrderReceiptResponse, error) {
+	order, err := s.GetOrderById(ctx, orderId)
+	if err != nil {
+		return dto.OrderReceiptResponse{}, err
+	}
+
+	res := dto.OrderReceiptResponse{
+		Order:   order,
+		Refunds: []dto.RefundResponse{},
+	}
+
+	for _, item := range order.Items {
+		refunds, err := s.refundRepo.GetRefundsByTransactionId(ctx, nil, item.ID)
+		if err != nil {
+			return dto.OrderReceiptResponse{}, err
+		}
+
+		for _, refund := range refunds {
+			res.Refunds = append(res.Refunds, toRefundResponse(refund))
+			res.Refunded += refund.Amount
+		}
+	}
+
+	res.Net = order.Total - res.Refunded
+	return res, nil
+}
+
+// CancelOrder cancels every item of an order that can still be cancelled.
+// Paid items are refunded according to the policy of their event.
+func (s *transactionService) CancelOrder(ctx context.Context, req dto.OrderCancelRequest, orderId string) (dto.OrderReceiptResponse, error) {
+	var events []string
+	var refunds []*entity.Refund
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.GetOrderByIdForUpdate(ctx, tx, orderId)
+		if err != nil {
+			return dto.ErrOrderNotFound
+		}
+
+		items, err := s.transactionRepo.GetTransactionsByOrderIdForUpdate(ctx, tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		cancelled := 0
+		for _, item := range items {
+			if !canTransitionTransaction(item.Status, constants.ENUM_TRANSACTION_STATUS_CANCELLED) {
+				continue
+			}
+
+			// Only money that was actually paid is refunded.
+			if item.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+				if err := s.checkTicketsHeld(ctx, tx, item); err != nil {
+					return err
+				}
+
+				refund, err := s.refundTickets(ctx, tx, item, item.Amount, req.Reason)
+				if err != nil {
+					return err
+				}
+				refunds = append(refunds, refund)
+			}
+
+			if _, err := s.transitionTransaction(ctx, tx, item, constants.ENUM_TRANSACTION_STATUS_CANCELLED); err != nil {
+				return err
+			}
+			events = append(events, item.EventID)
+			cancelled++
+		}
+
+		if cancelled == 0 {
+			return &dto.ErrInvalidStatusTransition{
+				From: order.Status,
+				To:   constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+			}
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return dto.OrderReceiptResponse{}, err
+	}
+
+	s.sendRefunds(ctx, refunds...)
+	s.offerSeats(ctx, events...)
+	return s.GetOrderReceipt(ctx, orderId)
+}
+
+// addOrderTotals sets the totals of an order to the sums of its items.
+func addOrderTotals(order *entity.Order, items []entity.Transaction) {
+	order.Subtotal, order.Discount, order.Fees, order.Total = 0, 0, 0, 0
+
+	for _, item := range items {
+		order.Subtotal += item.Subtotal
+		order.Discount += item.Discount
+		order.Fees += item.Fees
+		order.Total += item.Total
+	}
+}
+
+// orderStatus derives the status of an order from its items: pending while
+// any item is, paid while any item is, otherwise the status all items
+// share. Items that ended differently leave the order cancelled.
+func orderStatus(items []entity.Transaction) string {
+	statuses := make(map[string]bool)
+	for _, item := range items {
+		statuses[item.Status] = true
+	}
+
+	switch {
+	case statuses[constants.ENUM_TRANSACTION_STATUS_PENDING]:
+		return constants.ENUM_TRANSACTION_STATUS_PENDING
+	case statuses[constants.ENUM_TRANSACTION_STATUS_PAID]:
+		return constants.ENUM_TRANSACTION_STATUS_PAID
+	case len(statuses) == 1:
+		return items[0].Status
+	}
+
+	return constants.ENUM_TRANSACTION_STATUS_CANCELLED
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+type (
+	// PaymentProvider is implemented by every payment gateway the app can
+	// charge through. Webhook payloads are verified by the provider itself
+	// because every gateway signs its notifications differently.
+	PaymentProvider interface {
+		Name() string
+		CreateCharge(ctx context.Context, req dto.PaymentChargeRequest) (dto.PaymentChargeResponse, error)
+		GetChargeStatus(ctx context.Context, reference string) (string, error)
+		Refund(ctx context.Context, reference string, amount int) error
+		ParseWebhook(payload []byte, signature string) (dto.PaymentNotification, error)
+	}
+
+	PaymentService interface {
+		GetProvider(name string) (PaymentProvider, error)
+		DefaultProvider() PaymentProvider
+	}
+
+	paymentService struct {
+		providers       map[string]PaymentProvider
+		defaultProvider string
+	}
+)
+
+func NewPaymentService(providers ...PaymentProvider) PaymentService {
+	registered := make(map[string]PaymentProvider, len(providers))
+	for _, provider := range providers {
+		registered[provider.Name()] = provider
+	}
+
+	defaultProvider := os.Getenv("PAYMENT_PROVIDER")
+	if defaultProvider == "" {
+		defaultProvider = constants.ENUM_PAYMENT_PROVIDER_MOCK
+	}
+
+	return &paymentService{
+		providers:       registered,
+		defaultProvider: defaultProvider,
+	}
+}
+
+func (s *paymentService) GetProvider(name string) (PaymentProvider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, dto.ErrPaymentProviderNotFound
+	}
+
+	return provider, nil
+}
+
+func (s *paymentService) DefaultProvider() PaymentProvider {
+	return s.providers[s.defaultProvider]
+}
+
+// NewPaymentProvidersFromEnv returns the gateways enabled in the
+// environment. The mock gateway settles any charge it is told to, so it is
+// only registered when PAYMENT_MOCK_ENABLED is set for development and tests,
+// and never without a webhook secret of its own. With no gateway enabled the
+// app still starts, but checkout is refused with ErrPaymentsUnavailable.
+func NewPaymentProvidersFromEnv() ([]PaymentProvider, error) {
+	var providers []PaymentProvider
+
+	if enabled, _ := strconv.ParseBool(os.Getenv("PAYMENT_MOCK_ENABLED")); enabled {
+		secret := os.Getenv("PAYMENT_MOCK_SECRET")
+		if secret == "" {
+			return nil, dto.ErrPaymentMockSecretRequired
+		}
+
+		providers = append(providers, NewMockPaymentProvider(secret))
+	}
+
+	return providers, nil
+}
+
+// mockPaymentProvider is a local development gateway. Charges live in memory
+// and are settled by posting a notification signed with secret to the
+// webhook route.
+type mockPaymentProvider struct {
+	secret  string
+	mu      sync.Mutex
+	charges map[string]string
+}
+
+func NewMockPaymentProvider(secret string) PaymentProvider {
+	return &mockPaymentProvider{
+		secret:  secret,
+		charges: make(map[string]string),
+	}
+}
+
+func (p *mockPaymentProvider) Name() string {
+	return constants.ENUM_PAYMENT_PROVIDER_MOCK
+}
+
+func (p *mockPaymentProvider) CreateCharge(ctx context.Context, req dto.PaymentChargeRequest) (dto.PaymentChargeResponse, error) {
+	reference := fmt.Sprintf("mock-%s", uuid.NewString())
+
+	p.mu.Lock()
+	p.charges[reference] = constants.ENUM_PAYMENT_STATUS_PENDING
+	p.mu.Unlock()
+
+	return dto.PaymentChargeResponse{
+		Reference:  reference,
+		PaymentURL: fmt.Sprintf("%s/payment/mock/%s", LOCAL_URL, reference),
+		Status:     constants.ENUM_PAYMENT_STATUS_PENDING,
+	}, nil
+}
+
+func (p *mockPaymentProvider) GetChargeStatus(ctx context.Context, reference string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, ok := p.charges[reference]
+	if !ok {
+		return "", dto.ErrPaymentNotFound
+	}
+
+	return status, nil
+}
+
+func (p *mockPaymentProvider) Refund(ctx context.Context, reference string, amount int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.charges[reference]; !ok {
+		return dto.ErrPaymentNotFound
+	}
+
+	p.charges[reference] = constants.ENUM_PAYMENT_STATUS_REFUNDED
+	return nil
+}
+
+func (p *mockPaymentProvider) ParseWebhook(payload []byte, signature string) (dto.PaymentNotification, error) {
+	if !utils.VerifyHMAC(p.secret, payload, signature) {
+		return dto.PaymentNotification{}, dto.ErrInvalidSignature
+	}
+
+	var notification dto.PaymentNotification
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return dto.PaymentNotification{}, err
+	}
+
+	switch notification.Status {
+	case constants.ENUM_PAYMENT_STATUS_PAID, constants.ENUM_PAYMENT_STATUS_FAILED:
+	default:
+		return dto.PaymentNotification{}, dto.ErrInvalidPaymentStatus
+	}
+
+	p.mu.Lock()
+	p.charges[notification.Reference] = notification.Status
+	p.mu.Unlock()
+
+	return notification, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/repository"
+)
+
+type (
+	ReportService interface {
+		GetSalesReport(ctx context.Context, req dto.SalesReportRequest) (dto.SalesReportResponse, error)
+	}
+
+	reportService struct {
+		reportRepo repository.ReportRepository
+	}
+)
+
+func NewReportService(reportRepo repository.ReportRepository) ReportService {
+	return &reportService{
+		reportRepo: reportRepo,
+	}
+}
+
+// GetSalesReport sums tickets sold, gross revenue, refunds and net revenue
+// per event, tier or day. Sales are grouped per event unless asked otherwise
+// and days are calendar days in UTC unless a timezone is given.
+func (s *reportService) GetSalesReport(ctx context.Context, req dto.SalesReportRequest) (dto.SalesReportResponse, error) {
+	if req.GroupBy == "" {
+		req.GroupBy = constants.ENUM_REPORT_GROUP_EVENT
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = constants.ENUM_REPORT_TIMEZONE_DEFAULT
+	}
+
+	// "Local" would mean the timezone of the server, which the database
+	// does not know about.
+	location, err := time.LoadLocation(req.Timezone)
+	if err != nil || req.Timezone == "Local" {
+		return dto.SalesReportResponse{}, dto.ErrInvalidTimezone
+	}
+
+	if req.EventID != "" {
+		if _, err := uuid.Parse(req.EventID); err != nil {
+			return dto.SalesReportResponse{}, dto.ErrInvalidEventID
+		}
+	}
+
+	filter := dto.SalesReportFilter{
+		GroupBy:  req.GroupBy,
+		EventID:  req.EventID,
+		AuthorID: req.AuthorID,
+		Timezone: location.String(),
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, req.From, location)
+		if err != nil {
+			return dto.SalesReportResponse{}, dto.ErrInvalidReportDate
+		}
+		filter.From = from
+	}
+
+	// To is inclusive, so the range ends when the following day starts.
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, location)
+		if err != nil {
+			return dto.SalesReportResponse{}, dto.ErrInvalidReportDate
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return dto.SalesReportResponse{}, dto.ErrInvalidReportRange
+	}
+
+	rows, err := s.reportRepo.GetSalesReport(ctx, nil, filter)
+	if err != nil {
+		return dto.SalesReportResponse{}, err
+	}
+
+	return dto.SalesReportResponse{
+		GroupBy:  req.GroupBy,
+		From:     req.From,
+		To:       req.To,
+		Timezone: filter.Timezone,
+		Rows:     rows,
+	}, nil
+}
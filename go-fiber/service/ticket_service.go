@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"gorm.io/gorm"
+)
+
+type (
+	TicketService interface {
+		TicketTransferService
+		GetTicketsByOwner(ctx context.Context, ownerId string) ([]dto.TicketResponse, error)
+		GetTicketByCode(ctx context.Context, code string, ownerId string) (dto.TicketResponse, error)
+		GenerateTicketQR(ctx context.Context, code string, ownerId string) ([]byte, error)
+		CheckInTicket(ctx context.Context, req dto.TicketCheckInRequest, staffId string) (dto.TicketResponse, error)
+		SyncCheckIns(ctx context.Context, req dto.TicketBatchCheckInRequest, staffId string) (dto.TicketBatchCheckInResponse, error)
+		GetSigningKey(ctx context.Context, eventId string, staffId string) (dto.TicketSigningKeyResponse, error)
+	}
+
+	ticketService struct {
+		ticketRepo   repository.TicketRepository
+		transferRepo repository.TicketTransferRepository
+		eventRepo    repository.EventRepository
+		userRepo     repository.UserRepository
+		jwtService   JWTService
+		db           *gorm.DB
+	}
+)
+
+const (
+	TICKET_CODE_SIZE = 16
+	TICKET_QR_SIZE   = 512
+)
+
+func NewTicketService(ticketRepo repository.TicketRepository, transferRepo repository.TicketTransferRepository, eventRepo repository.EventRepository, userRepo repository.UserRepository, jwtService JWTService, db *gorm.DB) TicketService {
+	return &ticketService{
+		ticketRepo:   ticketRepo,
+		transferRepo: transferRepo,
+		eventRepo:    eventRepo,
+		userRepo:     userRepo,
+		jwtService:   jwtService,
+		db:           db,
+	}
+}
+
+func (s *ticketService) GetTicketsByOwner(ctx context.Context, ownerId string) ([]dto.TicketResponse, error) {
+	tickets, err := s.ticketRepo.GetTicketsByOwnerId(ctx, nil, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	datas := []dto.TicketResponse{}
+	for _, ticket := range tickets {
+		datas = append(datas, s.toSignedTicketResponse(ticket))
+	}
+
+	return datas, nil
+}
+
+// GetTicketByCode returns a ticket owned by ownerId. An empty ownerId skips
+// the ownership check, which is meant for admins only. Tickets of other
+// owners are reported as not found.
+func (s *ticketService) GetTicketByCode(ctx context.Context, code string, ownerId string) (dto.TicketResponse, error) {
+	ticket, err := s.ticketRepo.GetTicketByCode(ctx, nil, code)
+	if err != nil {
+		return dto.TicketResponse{}, dto.ErrTicketNotFound
+	}
+
+	if ownerId != "" && ticket.OwnerID != ownerId {
+		return dto.TicketResponse{}, dto.ErrTicketNotFound
+	}
+
+	return s.toSignedTicketResponse(ticket), nil
+}
+
+// GenerateTicketQR encodes the signed ticket token, so the image can be
+// verified by a scanner without contacting the server.
+func (s *ticketService) GenerateTicketQR(ctx context.Context, code string, ownerId string) ([]byte, error) {
+	ticket, err := s.GetTicketByCode(ctx, code, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ticket.Status {
+	case constants.ENUM_TICKET_STATUS_USED:
+		return nil, dto.ErrTicketAlreadyUsed
+	case constants.ENUM_TICKET_STATUS_VOID:
+		return nil, dto.ErrTicketVoid
+	}
+
+	if ticket.Token == "" {
+		return nil, dto.ErrGenerateTicketQR
+	}
+
+	png, err := qrcode.Encode(ticket.Token, qrcode.Medium, TICKET_QR_SIZE)
+	if err != nil {
+		return nil, dto.ErrGenerateTicketQR
+	}
+
+	return png, nil
+}
+
+func (s *ticketService) CheckInTicket(ctx context.Context, req dto.TicketCheckInRequest, staffId string) (dto.TicketResponse, error) {
+	if err := s.authorizeStaff(ctx, req.EventID, staffId); err != nil {
+		return dto.TicketResponse{}, err
+	}
+
+	return s.checkIn(ctx, req.EventID, req, staffId, time.Now())
+}
+
+// SyncCheckIns records check-ins collected by a scanner while it was offline.
+// Every entry is handled on its own, so one rejected ticket does not undo
+// the rest of the batch.
+func (s *ticketService) SyncCheckIns(ctx context.Context, req dto.TicketBatchCheckInRequest, staffId string) (dto.TicketBatchCheckInResponse, error) {
+	if err := s.authorizeStaff(ctx, req.EventID, staffId); err != nil {
+		return dto.TicketBatchCheckInResponse{}, err
+	}
+
+	now := time.Now()
+	response := dto.TicketBatchCheckInResponse{
+		Results: make([]dto.TicketCheckInResult, 0, len(req.CheckIns)),
+	}
+
+	for _, item := range req.CheckIns {
+		usedAt := now
+		if item.ScannedAt != nil && item.ScannedAt.Before(now) {
+			usedAt = *item.ScannedAt
+		}
+
+		result := dto.TicketCheckInResult{
+			Code: item.Code,
+		}
+
+		ticket, err := s.checkIn(ctx, req.EventID, item, staffId, usedAt)
+		if err != nil {
+			result.Result = constants.ENUM_CHECK_IN_RESULT_REJECTED
+			result.Error = err.Error()
+			response.Rejected++
+		} else {
+			result.Result = constants.ENUM_CHECK_IN_RESULT_ACCEPTED
+			response.Accepted++
+		}
+
+		if ticket.ID != "" {
+			result.Code = ticket.Code
+			result.Ticket = &ticket
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// GetSigningKey hands out the key tickets of an event are signed with so a
+// scanner app can verify QR codes offline.
+func (s *ticketService) GetSigningKey(ctx context.Context, eventId string, staffId string) (dto.TicketSigningKeyResponse, error) {
+	if err := s.authorizeStaff(ctx, eventId, staffId); err != nil {
+		return dto.TicketSigningKeyResponse{}, err
+	}
+
+	return dto.TicketSigningKeyResponse{
+		EventID:   eventId,
+		Algorithm: "HS256",
+		Key:       s.jwtService.TicketSigningKey(eventId),
+	}, nil
+}
+
+// checkIn resolves the ticket from its code or signed token and marks it
+// used. The current ticket is returned alongside rejections so the caller
+// can tell when and by whom it was already scanned.
+func (s *ticketService) checkIn(ctx context.Context, eventId string, req dto.TicketCheckInRequest, staffId string, usedAt time.Time) (dto.TicketResponse, error) {
+	code, err := s.resolveCode(eventId, req)
+	if err != nil {
+		return dto.TicketResponse{}, err
+	}
+
+	checkedIn, err := s.ticketRepo.CheckInTicket(ctx, nil, eventId, code, staffId, usedAt)
+	if err != nil {
+		return dto.TicketResponse{}, err
+	}
+
+	ticket, err := s.ticketRepo.GetTicketByCode(ctx, nil, code)
+	if err != nil {
+		return dto.TicketResponse{}, dto.ErrTicketNotFound
+	}
+
+	if ticket.EventID != eventId {
+		return dto.TicketResponse{}, dto.ErrTicketNotForEvent
+	}
+
+	if checkedIn {
+		return toTicketResponse(ticket), nil
+	}
+
+	switch ticket.Status {
+	case constants.ENUM_TICKET_STATUS_USED:
+		return toTicketResponse(ticket), dto.ErrTicketAlreadyUsed
+	case constants.ENUM_TICKET_STATUS_VOID:
+		return toTicketResponse(ticket), dto.ErrTicketVoid
+	default:
+		return toTicketResponse(ticket), dto.ErrTicketNotFound
+	}
+}
+
+func (s *ticketService) resolveCode(eventId string, req dto.TicketCheckInRequest) (string, error) {
+	if req.Token != "" {
+		claims, err := s.jwtService.ValidateTicketToken(req.Token, eventId)
+		if err != nil {
+			return "", dto.ErrInvalidTicketToken
+		}
+
+		if req.Code != "" && req.Code != claims.Code {
+			return "", dto.ErrInvalidTicketToken
+		}
+
+		return claims.Code, nil
+	}
+
+	if req.Code == "" {
+		return "", dto.ErrTicketCodeRequired
+	}
+
+	return req.Code, nil
+}
+
+// authorizeStaff allows admins and the organizer of the event to scan its
+// tickets.
+func (s *ticketService) authorizeStaff(ctx context.Context, eventId string, staffId string) error {
+	staff, err := s.userRepo.GetUserById(ctx, nil, staffId)
+	if err != nil {
+		return dto.ErrUserNotFound
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
+	if err != nil {
+		return dto.ErrEventNotFound
+	}
+
+	if staff.Role != constants.ENUM_ROLE_ADMIN && event.AuthorID.String() != staffId {
+		return dto.ErrNotEventStaff
+	}
+
+	return nil
+}
+
+func (s *ticketService) toSignedTicketResponse(ticket entity.Ticket) dto.TicketResponse {
+	response := toTicketResponse(ticket)
+
+	if ticket.Status == constants.ENUM_TICKET_STATUS_ACTIVE {
+		token, err := s.jwtService.GenerateTicketToken(response.ID, ticket.Code, ticket.EventID)
+		if err == nil {
+			response.Token = token
+		}
+	}
+
+	return response
+}
+
+func toTicketResponse(ticket entity.Ticket) dto.TicketResponse {
+	response := dto.TicketResponse{
+		ID:            ticket.ID.String(),
+		Code:          ticket.Code,
+		TransactionID: ticket.TransactionID,
+		EventID:       ticket.EventID,
+		EventName:     ticket.Event.Name,
+		OwnerID:       ticket.OwnerID,
+		Status:        ticket.Status,
+		IssuedAt:      ticket.CreatedAt.String(),
+		VoidedAt:      formatTimestamp(ticket.VoidedAt),
+		UsedAt:        formatTimestamp(ticket.UsedAt),
+	}
+
+	if ticket.CheckedInBy != nil {
+		response.CheckedInBy = *ticket.CheckedInBy
+	}
+
+	return response
+}
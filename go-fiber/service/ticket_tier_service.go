@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"gorm.io/gorm"
+)
+
+type (
+	TicketTierService interface {
+		GetTiersByEvent(ctx context.Context, eventId string, publishedOnly bool) ([]dto.TicketTierResponse, error)
+		CreateTier(ctx context.Context, eventId string, req dto.TicketTierRequest) (dto.TicketTierResponse, error)
+		UpdateTier(ctx context.Context, eventId string, tierId string, req dto.TicketTierUpdateRequest) (dto.TicketTierResponse, error)
+		DeleteTier(ctx context.Context, eventId string, tierId string) error
+	}
+
+	ticketTierService struct {
+		tierRepo        repository.TicketTierRepository
+		eventRepo       repository.EventRepository
+		waitlistService WaitlistService
+		db              *gorm.DB
+	}
+)
+
+func NewTicketTierService(tierRepo repository.TicketTierRepository, eventRepo repository.EventRepository, waitlistService WaitlistService, db *gorm.DB) TicketTierService {
+	return &ticketTierService{
+		tierRepo:        tierRepo,
+		eventRepo:       eventRepo,
+		waitlistService: waitlistService,
+		db:              db,
+	}
+}
+
+func (s *ticketTierService) GetTiersByEvent(ctx context.Context, eventId string, publishedOnly bool) ([]dto.TicketTierResponse, error) {
+	event, err := s.eventRepo.GetEventById(ctx, nil, eventId)
+	if err != nil {
+		return nil, dto.ErrEventNotFound
+	}
+
+	// Events that are not published look like they do not exist to users.
+	if publishedOnly && event.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+		return nil, dto.ErrEventNotFound
+	}
+
+	tiers, err := s.tierRepo.GetTiersByEventId(ctx, nil, eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTicketTierResponses(tiers), nil
+}
+
+func (s *ticketTierService) CreateTier(ctx context.Context, eventId string, req dto.TicketTierRequest) (dto.TicketTierResponse, error) {
+	tier := entity.TicketTier{
+		EventID:      eventId,
+		Name:         req.Name,
+		Price:        req.Price,
+		Capacity:     req.Capacity,
+		Availability: req.Capacity,
+		SaleStartsAt: req.SaleStartsAt,
+		SaleEndsAt:   req.SaleEndsAt,
+	}
+
+	if err := validateTier(tier); err != nil {
+		return dto.TicketTierResponse{}, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId); err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		var err error
+		tier, err = s.tierRepo.CreateTier(ctx, tx, tier)
+		if err != nil {
+			return err
+		}
+
+		return s.eventRepo.SyncTierTotals(ctx, tx, eventId)
+	})
+	if err != nil {
+		return dto.TicketTierResponse{}, err
+	}
+
+	return toTicketTierResponse(tier), nil
+}
+
+func (s *ticketTierService) UpdateTier(ctx context.Context, eventId string, tierId string, req dto.TicketTierUpdateRequest) (dto.TicketTierResponse, error) {
+	var tier entity.TicketTier
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId); err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		var err error
+		tier, err = s.tierRepo.GetTierByIdForUpdate(ctx, tx, tierId)
+		if err != nil || tier.EventID != eventId {
+			return dto.ErrTierNotFound
+		}
+
+		if req.Name != "" {
+			tier.Name = req.Name
+		}
+
+		if req.Price != nil {
+			tier.Price = *req.Price
+		}
+
+		if req.Capacity != nil {
+			if err := resizeTier(&tier, *req.Capacity); err != nil {
+				return err
+			}
+		}
+
+		if req.SaleStartsAt != nil {
+			tier.SaleStartsAt = req.SaleStartsAt
+		}
+
+		if req.SaleEndsAt != nil {
+			tier.SaleEndsAt = req.SaleEndsAt
+		}
+
+		if err := validateTier(tier); err != nil {
+			return err
+		}
+
+		tier, err = s.tierRepo.UpdateTier(ctx, tx, tier)
+		if err != nil {
+			return err
+		}
+
+		return s.eventRepo.SyncTierTotals(ctx, tx, eventId)
+	})
+	if err != nil {
+		return dto.TicketTierResponse{}, err
+	}
+
+	// A larger capacity may free seats people are waiting for.
+	if err := s.waitlistService.OfferSeats(ctx, eventId); err != nil {
+		log.Printf("error offering seats of event %s: %v", eventId, err)
+	}
+
+	return toTicketTierResponse(tier), nil
+}
+
+func (s *ticketTierService) DeleteTier(ctx context.Context, eventId string, tierId string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId); err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		tier, err := s.tierRepo.GetTierByIdForUpdate(ctx, tx, tierId)
+		if err != nil || tier.EventID != eventId {
+			return dto.ErrTierNotFound
+		}
+
+		if tier.Availability != tier.Capacity {
+			return dto.ErrTierHasSales
+		}
+
+		tiers, err := s.tierRepo.GetTiersByEventId(ctx, tx, eventId)
+		if err != nil {
+			return err
+		}
+
+		if len(tiers) <= 1 {
+			return dto.ErrLastTicketTier
+		}
+
+		if err := s.tierRepo.DeleteTier(ctx, tx, tierId); err != nil {
+			return err
+		}
+
+		return s.eventRepo.SyncTierTotals(ctx, tx, eventId)
+	})
+}
+
+// resizeTier changes the capacity of a tier while keeping the number of
+// tickets already sold.
+func resizeTier(tier *entity.TicketTier, capacity int) error {
+	sold := tier.Capacity - tier.Availability
+	if capacity < sold {
+		return dto.ErrCapacityBelowSold
+	}
+
+	tier.Capacity = capacity
+	tier.Availability = capacity - sold
+	return nil
+}
+
+func validateTier(tier entity.TicketTier) error {
+	if tier.Name == "" || tier.Price < 0 || tier.Capacity < 0 {
+		return dto.ErrInvalidTier
+	}
+
+	if tier.SaleStartsAt != nil && tier.SaleEndsAt != nil && !tier.SaleEndsAt.After(*tier.SaleStartsAt) {
+		return dto.ErrInvalidSaleWindow
+	}
+
+	return nil
+}
+
+// isTierOnSale reports whether the sale window of the tier is open at t.
+func isTierOnSale(tier entity.TicketTier, t time.Time) bool {
+	if tier.SaleStartsAt != nil && t.Before(*tier.SaleStartsAt) {
+		return false
+	}
+
+	if tier.SaleEndsAt != nil && !t.Before(*tier.SaleEndsAt) {
+		return false
+	}
+
+	return true
+}
+
+func toTicketTierResponse(tier entity.TicketTier) dto.TicketTierResponse {
+	return dto.TicketTierResponse{
+		ID:           tier.ID.String(),
+		EventID:      tier.EventID,
+		Name:         tier.Name,
+		Price:        tier.Price,
+		Capacity:     tier.Capacity,
+		Availability: tier.Availability,
+		SaleStartsAt: formatTimestamp(tier.SaleStartsAt),
+		SaleEndsAt:   formatTimestamp(tier.SaleEndsAt),
+		OnSale:       isTierOnSale(tier, time.Now()),
+	}
+}
+
+func toTicketTierResponses(tiers []entity.TicketTier) []dto.TicketTierResponse {
+	datas := make([]dto.TicketTierResponse, 0, len(tiers))
+	for _, tier := range tiers {
+		datas = append(datas, toTicketTierResponse(tier))
+	}
+
+	return datas
+}
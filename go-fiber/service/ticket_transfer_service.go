@@ -0,0 +1,442 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	TICKET_TRANSFER_ROUTE = "ticket/transfer/accept"
+	REGISTER_ROUTE        = "register"
+)
+
+// TicketTransferService is implemented by the ticket service: a transfer
+// reissues the ticket under a new code, so both share the ticket rows.
+type TicketTransferService interface {
+	TransferTicket(ctx context.Context, req dto.TicketTransferRequest) (dto.TicketTransferResponse, error)
+	TransferTickets(ctx context.Context, req dto.TicketTransferRequest) ([]dto.TicketTransferResponse, error)
+	GetTransfersByUser(ctx context.Context, userId string) ([]dto.TicketTransferResponse, error)
+	AcceptTransfer(ctx context.Context, transferId string, userId string) (dto.TicketResponse, error)
+	DeclineTransfer(ctx context.Context, transferId string, userId string) (dto.TicketTransferResponse, error)
+	CancelTransfer(ctx context.Context, transferId string, userId string) (dto.TicketTransferResponse, error)
+	GetTicketHistory(ctx context.Context, code string, ownerId string) (dto.TicketHistoryResponse, error)
+}
+
+// TransferTicket offers an active ticket to another user. The ticket stays
+// with its owner, and keeps its code, until the recipient accepts.
+func (s *ticketService) TransferTicket(ctx context.Context, req dto.TicketTransferRequest) (dto.TicketTransferResponse, error) {
+	req.Codes = []string{req.Code}
+
+	transfers, err := s.TransferTickets(ctx, req)
+	if err != nil {
+		return dto.TicketTransferResponse{}, err
+	}
+
+	return transfers[0], nil
+}
+
+// TransferTickets offers several tickets to the same user at once. Either
+// every ticket is offered or, when one of them cannot be, none is.
+func (s *ticketService) TransferTickets(ctx context.Context, req dto.TicketTransferRequest) ([]dto.TicketTransferResponse, error) {
+	codes := uniqueCodes(req.Codes)
+	if len(codes) == 0 {
+		return nil, dto.ErrTicketTransferNoTickets
+	}
+
+	recipient, email, err := s.transferRecipient(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if recipient != nil && recipient.ID.String() == req.FromUserID {
+		return nil, dto.ErrTicketTransferToSelf
+	}
+
+	transfers := make([]entity.TicketTransfer, 0, len(codes))
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, code := range codes {
+			transfer, err := s.createTransfer(ctx, tx, code, req.FromUserID, recipient, email)
+			if err != nil {
+				return err
+			}
+
+			transfers = append(transfers, transfer)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	datas := make([]dto.TicketTransferResponse, 0, len(transfers))
+	for _, created := range transfers {
+		transfer, err := s.transferRepo.GetTransferById(ctx, nil, created.ID.String())
+		if err != nil {
+			return nil, dto.ErrTicketTransferNotFound
+		}
+
+		if err := s.sendTransferEmail(transfer, recipient == nil); err != nil {
+			log.Printf("error sending ticket transfer %s: %v", transfer.ID, err)
+		}
+
+		datas = append(datas, toTicketTransferResponse(transfer))
+	}
+
+	return datas, nil
+}
+
+// createTransfer offers the ticket with code, which fromUserId has to own,
+// to the recipient.
+func (s *ticketService) createTransfer(ctx context.Context, tx *gorm.DB, code string, fromUserId string, recipient *entity.User, email string) (entity.TicketTransfer, error) {
+	ticket, err := s.ticketRepo.GetTicketByCodeForUpdate(ctx, tx, code)
+	if err != nil || ticket.OwnerID != fromUserId {
+		return entity.TicketTransfer{}, dto.ErrTicketNotFound
+	}
+
+	switch ticket.Status {
+	case constants.ENUM_TICKET_STATUS_USED:
+		return entity.TicketTransfer{}, dto.ErrTicketAlreadyUsed
+	case constants.ENUM_TICKET_STATUS_VOID:
+		return entity.TicketTransfer{}, dto.ErrTicketVoid
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, tx, ticket.EventID)
+	if err != nil {
+		return entity.TicketTransfer{}, dto.ErrEventNotFound
+	}
+
+	if event.TransfersDisabled {
+		return entity.TicketTransfer{}, dto.ErrTicketTransfersDisabled
+	}
+
+	pending, err := s.transferRepo.HasPendingTransfer(ctx, tx, ticket.ID.String())
+	if err != nil {
+		return entity.TicketTransfer{}, err
+	}
+
+	if pending {
+		return entity.TicketTransfer{}, dto.ErrTicketTransferPending
+	}
+
+	transfer := entity.TicketTransfer{
+		TicketID:   ticket.ID.String(),
+		EventID:    ticket.EventID,
+		FromUserID: fromUserId,
+		ToEmail:    email,
+		Status:     constants.ENUM_TICKET_TRANSFER_STATUS_PENDING,
+	}
+	if recipient != nil {
+		toUserId := recipient.ID.String()
+		transfer.ToUserID = &toUserId
+	}
+
+	return s.transferRepo.CreateTransfer(ctx, tx, transfer)
+}
+
+// uniqueCodes drops empty and repeated ticket codes, keeping their order.
+func uniqueCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+
+		seen[code] = true
+		unique = append(unique, code)
+	}
+
+	return unique
+}
+
+// transferRecipient resolves the user a ticket is sent to. A nil user with
+// an email means the recipient still has to register.
+func (s *ticketService) transferRecipient(ctx context.Context, req dto.TicketTransferRequest) (*entity.User, string, error) {
+	if req.ToUserID != "" {
+		user, err := s.userRepo.GetUserById(ctx, nil, req.ToUserID)
+		if err != nil {
+			return nil, "", dto.ErrUserNotFound
+		}
+
+		return &user, user.Email, nil
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return nil, "", dto.ErrTicketTransferRecipient
+	}
+
+	user, exists, err := s.userRepo.CheckEmail(ctx, nil, email)
+	if err != nil || !exists {
+		return nil, email, nil
+	}
+
+	return &user, user.Email, nil
+}
+
+func (s *ticketService) GetTransfersByUser(ctx context.Context, userId string) ([]dto.TicketTransferResponse, error) {
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
+	if err != nil {
+		return nil, dto.ErrUserNotFound
+	}
+
+	transfers, err := s.transferRepo.GetTransfersByUser(ctx, nil, userId, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	datas := []dto.TicketTransferResponse{}
+	for _, transfer := range transfers {
+		datas = append(datas, toTicketTransferResponse(transfer))
+	}
+
+	return datas, nil
+}
+
+// AcceptTransfer moves the ticket to the recipient under a new code, so the
+// code and QR image the previous owner holds stop working.
+func (s *ticketService) AcceptTransfer(ctx context.Context, transferId string, userId string) (dto.TicketResponse, error) {
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
+	if err != nil {
+		return dto.TicketResponse{}, dto.ErrUserNotFound
+	}
+
+	var code string
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.transferRepo.GetTransferByIdForUpdate(ctx, tx, transferId)
+		if err != nil || !isTransferRecipient(transfer, user) {
+			return dto.ErrTicketTransferNotFound
+		}
+
+		// Invites are matched by email, which only counts once the new
+		// account proved it owns the address.
+		if transfer.ToUserID == nil && !user.IsVerified {
+			return dto.ErrAccountNotVerified
+		}
+
+		if transfer.Status != constants.ENUM_TICKET_TRANSFER_STATUS_PENDING {
+			return dto.ErrTicketTransferNotPending
+		}
+
+		ticket, err := s.ticketRepo.GetTicketByIdForUpdate(ctx, tx, transfer.TicketID)
+		if err != nil {
+			return dto.ErrTicketNotFound
+		}
+
+		switch ticket.Status {
+		case constants.ENUM_TICKET_STATUS_USED:
+			return dto.ErrTicketAlreadyUsed
+		case constants.ENUM_TICKET_STATUS_VOID:
+			return dto.ErrTicketVoid
+		}
+
+		// Turning transfers off also stops the ones still waiting.
+		event, err := s.eventRepo.GetEventById(ctx, tx, ticket.EventID)
+		if err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		if event.TransfersDisabled {
+			return dto.ErrTicketTransfersDisabled
+		}
+
+		code, err = utils.GenerateCode(TICKET_CODE_SIZE)
+		if err != nil {
+			return dto.ErrIssueTickets
+		}
+
+		reissued, err := s.ticketRepo.ReissueTicket(ctx, tx, ticket.ID.String(), transfer.FromUserID, userId, code)
+		if err != nil {
+			return err
+		}
+
+		if !reissued {
+			return dto.ErrTicketNotFound
+		}
+
+		now := time.Now()
+		transfer.Status = constants.ENUM_TICKET_TRANSFER_STATUS_ACCEPTED
+		transfer.ToUserID = &userId
+		transfer.AcceptedAt = &now
+		transfer.ClosedAt = &now
+
+		_, err = s.transferRepo.UpdateTransfer(ctx, tx, transfer)
+		return err
+	})
+	if err != nil {
+		return dto.TicketResponse{}, err
+	}
+
+	return s.GetTicketByCode(ctx, code, userId)
+}
+
+func (s *ticketService) DeclineTransfer(ctx context.Context, transferId string, userId string) (dto.TicketTransferResponse, error) {
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
+	if err != nil {
+		return dto.TicketTransferResponse{}, dto.ErrUserNotFound
+	}
+
+	return s.closeTransfer(ctx, transferId, constants.ENUM_TICKET_TRANSFER_STATUS_DECLINED, func(transfer entity.TicketTransfer) bool {
+		return isTransferRecipient(transfer, user)
+	})
+}
+
+func (s *ticketService) CancelTransfer(ctx context.Context, transferId string, userId string) (dto.TicketTransferResponse, error) {
+	return s.closeTransfer(ctx, transferId, constants.ENUM_TICKET_TRANSFER_STATUS_CANCELLED, func(transfer entity.TicketTransfer) bool {
+		return transfer.FromUserID == userId
+	})
+}
+
+// closeTransfer ends a pending transfer without moving the ticket. Callers
+// that are not a party to the transfer are told it does not exist.
+func (s *ticketService) closeTransfer(ctx context.Context, transferId string, status string, allowed func(entity.TicketTransfer) bool) (dto.TicketTransferResponse, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.transferRepo.GetTransferByIdForUpdate(ctx, tx, transferId)
+		if err != nil || !allowed(transfer) {
+			return dto.ErrTicketTransferNotFound
+		}
+
+		if transfer.Status != constants.ENUM_TICKET_TRANSFER_STATUS_PENDING {
+			return dto.ErrTicketTransferNotPending
+		}
+
+		now := time.Now()
+		transfer.Status = status
+		transfer.ClosedAt = &now
+
+		_, err = s.transferRepo.UpdateTransfer(ctx, tx, transfer)
+		return err
+	})
+	if err != nil {
+		return dto.TicketTransferResponse{}, err
+	}
+
+	transfer, err := s.transferRepo.GetTransferById(ctx, nil, transferId)
+	if err != nil {
+		return dto.TicketTransferResponse{}, dto.ErrTicketTransferNotFound
+	}
+
+	return toTicketTransferResponse(transfer), nil
+}
+
+// GetTicketHistory lists the owners of a ticket from the buyer it was
+// issued to until today. Like GetTicketByCode, an empty ownerId is meant
+// for admins only.
+func (s *ticketService) GetTicketHistory(ctx context.Context, code string, ownerId string) (dto.TicketHistoryResponse, error) {
+	ticket, err := s.ticketRepo.GetTicketByCode(ctx, nil, code)
+	if err != nil {
+		return dto.TicketHistoryResponse{}, dto.ErrTicketNotFound
+	}
+
+	if ownerId != "" && ticket.OwnerID != ownerId {
+		return dto.TicketHistoryResponse{}, dto.ErrTicketNotFound
+	}
+
+	transfers, err := s.transferRepo.GetAcceptedTransfersByTicketId(ctx, nil, ticket.ID.String())
+	if err != nil {
+		return dto.TicketHistoryResponse{}, err
+	}
+
+	res := dto.TicketHistoryResponse{
+		TicketID:  ticket.ID.String(),
+		IssuedTo:  ticket.OwnerID,
+		IssuedAt:  ticket.CreatedAt.String(),
+		OwnerID:   ticket.OwnerID,
+		Transfers: make([]dto.TicketTransferResponse, 0, len(transfers)),
+	}
+
+	if len(transfers) > 0 {
+		res.IssuedTo = transfers[0].FromUserID
+	}
+
+	for _, transfer := range transfers {
+		res.Transfers = append(res.Transfers, toTicketTransferResponse(transfer))
+	}
+
+	return res, nil
+}
+
+func (s *ticketService) sendTransferEmail(transfer entity.TicketTransfer, invite bool) error {
+	readHtml, err := os.ReadFile("utils/email-template/ticket_transfer.html")
+	if err != nil {
+		return err
+	}
+
+	link := LOCAL_URL + "/" + TICKET_TRANSFER_ROUTE + "?id=" + transfer.ID.String()
+	if invite {
+		link = LOCAL_URL + "/" + REGISTER_ROUTE
+	}
+
+	data := struct {
+		Sender string
+		Event  string
+		Email  string
+		Invite bool
+		Link   string
+	}{
+		Sender: transfer.FromUser.Name,
+		Event:  transfer.Event.Name,
+		Email:  transfer.ToEmail,
+		Invite: invite,
+		Link:   link,
+	}
+
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, data); err != nil {
+		return err
+	}
+
+	return utils.SendMail(transfer.ToEmail, fmt.Sprintf("%s sent you a ticket for %s", transfer.FromUser.Name, transfer.Event.Name), strMail.String())
+}
+
+func isTransferRecipient(transfer entity.TicketTransfer, user entity.User) bool {
+	if transfer.ToUserID != nil {
+		return *transfer.ToUserID == user.ID.String()
+	}
+
+	return strings.EqualFold(transfer.ToEmail, user.Email)
+}
+
+func toTicketTransferResponse(transfer entity.TicketTransfer) dto.TicketTransferResponse {
+	response := dto.TicketTransferResponse{
+		ID:           transfer.ID.String(),
+		TicketID:     transfer.TicketID,
+		EventID:      transfer.EventID,
+		EventName:    transfer.Event.Name,
+		FromUserID:   transfer.FromUserID,
+		FromUserName: transfer.FromUser.Name,
+		ToEmail:      transfer.ToEmail,
+		Status:       transfer.Status,
+		CreatedAt:    transfer.CreatedAt.String(),
+		AcceptedAt:   formatTimestamp(transfer.AcceptedAt),
+		ClosedAt:     formatTimestamp(transfer.ClosedAt),
+	}
+
+	if transfer.ToUserID != nil {
+		response.ToUserID = *transfer.ToUserID
+	}
+
+	return response
+}
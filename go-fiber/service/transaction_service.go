@@ -2,188 +2,1366 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/tapeds/go-fiber-template/constants"
 	"github.com/tapeds/go-fiber-template/dto"
 	"github.com/tapeds/go-fiber-template/entity"
 	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
 )
 
 type (
 	TransactionService interface {
+		OrderService
+		InvoiceService
+		EventCancellationService
 		CreateTransaction(ctx context.Context, req dto.TransactionCreateRequest) (dto.TransactionResponse, error)
-		GetAllTransactionsWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.TransactionPaginationResponse, error)
+		GetAllTransactionsWithPagination(ctx context.Context, req dto.TransactionPaginationRequest) (dto.TransactionPaginationResponse, error)
 		GetTransactionById(ctx context.Context, transactionId string) (dto.TransactionResponse, error)
+		GetVisibleTransactionById(ctx context.Context, transactionId string, viewerId string) (dto.TransactionResponse, error)
 		UpdateTransaction(ctx context.Context, req dto.TransactionUpdateRequest, transactionId string) (dto.TransactionUpdateResponse, error)
+		UpdateTransactionStatus(ctx context.Context, req dto.TransactionStatusUpdateRequest, transactionId string) (dto.TransactionResponse, error)
+		CancelTransaction(ctx context.Context, req dto.TransactionCancelRequest, transactionId string) (dto.TransactionRefundResponse, error)
+		RefundTransaction(ctx context.Context, req dto.TransactionRefundRequest, transactionId string) (dto.TransactionRefundResponse, error)
 		DeleteTransaction(ctx context.Context, transactionId string) error
+		HandlePaymentWebhook(ctx context.Context, providerName string, payload []byte, signature string) error
+		ExpireHolds(ctx context.Context, limit int) (int, error)
 	}
 
 	transactionService struct {
 		transactionRepo repository.TransactionRepository
+		orderRepo       repository.OrderRepository
+		holdRepo        repository.SeatHoldRepository
+		waitlistRepo    repository.WaitlistRepository
 		eventRepo       repository.EventRepository
+		tierRepo        repository.TicketTierRepository
 		userRepo        repository.UserRepository
+		refundRepo      repository.RefundRepository
+		ticketRepo      repository.TicketRepository
+		voucherRepo     repository.VoucherRepository
+		invoiceRepo     repository.InvoiceRepository
+		paymentService  PaymentService
+		waitlistService WaitlistService
+		jwtService      JWTService
+		feePerTicket    int
+		holdDuration    time.Duration
+		db              *gorm.DB
 	}
 )
 
-func NewTransactionService(transactionRepo repository.TransactionRepository, eventRepo repository.EventRepository, userRepo repository.UserRepository) TransactionService {
+// transactionStatusTransitions lists, for every status, the statuses a
+// transaction may move to next. Statuses without an entry are final.
+var transactionStatusTransitions = map[string][]string{
+	constants.ENUM_TRANSACTION_STATUS_PENDING: {
+		constants.ENUM_TRANSACTION_STATUS_PAID,
+		constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+		constants.ENUM_TRANSACTION_STATUS_EXPIRED,
+		constants.ENUM_TRANSACTION_STATUS_FAILED,
+	},
+	constants.ENUM_TRANSACTION_STATUS_PAID: {
+		constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+		constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+	},
+}
+
+func NewTransactionService(transactionRepo repository.TransactionRepository, orderRepo repository.OrderRepository, holdRepo repository.SeatHoldRepository, waitlistRepo repository.WaitlistRepository, eventRepo repository.EventRepository, tierRepo repository.TicketTierRepository, userRepo repository.UserRepository, refundRepo repository.RefundRepository, ticketRepo repository.TicketRepository, voucherRepo repository.VoucherRepository, invoiceRepo repository.InvoiceRepository, paymentService PaymentService, waitlistService WaitlistService, jwtService JWTService, db *gorm.DB) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
+		orderRepo:       orderRepo,
+		holdRepo:        holdRepo,
+		waitlistRepo:    waitlistRepo,
 		eventRepo:       eventRepo,
+		tierRepo:        tierRepo,
 		userRepo:        userRepo,
+		refundRepo:      refundRepo,
+		ticketRepo:      ticketRepo,
+		voucherRepo:     voucherRepo,
+		invoiceRepo:     invoiceRepo,
+		paymentService:  paymentService,
+		waitlistService: waitlistService,
+		jwtService:      jwtService,
+		feePerTicket:    getEnvInt("TRANSACTION_FEE_PER_TICKET", 0),
+		holdDuration:    time.Duration(getEnvInt("SEAT_HOLD_MINUTES", constants.ENUM_SEAT_HOLD_MINUTES_DEFAULT)) * time.Minute,
+		db:              db,
+	}
+}
+
+// getEnvInt reads a non negative number from the environment, falling back
+// when it is unset or invalid. The service fee charged on every ticket,
+// TRANSACTION_FEE_PER_TICKET, is read this way and is off by default.
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return fallback
 	}
+
+	return value
 }
 
+// CreateTransaction buys tickets of a single event as an order with one
+// item and answers with that item.
 func (s *transactionService) CreateTransaction(ctx context.Context, req dto.TransactionCreateRequest) (dto.TransactionResponse, error) {
-	// Ensure valid BuyerID and EventID
-	event, err := s.eventRepo.GetEventById(ctx, req.EventID)
-	if err != nil {
-		return dto.TransactionResponse{}, dto.ErrEventNotFound
+	if req.Amount <= 0 {
+		return dto.TransactionResponse{}, dto.ErrInvalidAmount
 	}
 
-	buyer, err := s.userRepo.GetUserById(ctx, req.BuyerID)
+	if s.paymentService.DefaultProvider() == nil {
+		return dto.TransactionResponse{}, dto.ErrPaymentsUnavailable
+	}
+
+	buyer, err := s.userRepo.GetUserById(ctx, nil, req.BuyerID)
 	if err != nil {
 		return dto.TransactionResponse{}, dto.ErrBuyerNotFound
 	}
 
-	transaction := entity.Transaction{
-		EventID: req.EventID,
-		BuyerID: req.BuyerID,
-		Amount:  req.Amount,
+	order, err := s.placeOrder(ctx, buyer, []dto.OrderItemRequest{{
+		EventID:      req.EventID,
+		TierID:       req.TierID,
+		Amount:       req.Amount,
+		VoucherCode:  req.VoucherCode,
+		VoucherCodes: req.VoucherCodes,
+	}})
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	transactionReg, err := s.transactionRepo.CreateTransaction(ctx, transaction)
-	if err != nil {
-		return dto.TransactionResponse{}, dto.ErrCreateTransaction
+	// The purchase stays pending until the provider confirms the payment
+	// through the webhook.
+	if _, err := s.createCharge(ctx, order.ID.String(), buyer); err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	return dto.TransactionResponse{
-		ID:         transactionReg.ID.String(),
-		BuyerName:  buyer.Name,
-		BuyerEmail: buyer.Email,
-		EventName:  event.Name,
-		EventPrice: event.Price,
-		Amount:     transactionReg.Amount,
-		BuyedAt:    transactionReg.CreatedAt.String(),
-	}, nil
+	return s.GetTransactionById(ctx, order.Items[0].ID.String())
 }
 
-func (s *transactionService) GetAllTransactionsWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.TransactionPaginationResponse, error) {
-	dataWithPaginate, err := s.transactionRepo.GetAllTransactionsWithPagination(ctx, req)
+func (s *transactionService) GetAllTransactionsWithPagination(ctx context.Context, req dto.TransactionPaginationRequest) (dto.TransactionPaginationResponse, error) {
+	if req.Status != "" && !isValidTransactionStatus(req.Status) {
+		return dto.TransactionPaginationResponse{}, dto.ErrInvalidTransactionStatus
+	}
+
+	if err := decodePageCursor(&req.PaginationRequest, s.jwtService.CursorSigningKey()); err != nil {
+		return dto.TransactionPaginationResponse{}, err
+	}
+
+	dataWithPaginate, err := s.transactionRepo.GetAllTransactionsWithPagination(ctx, nil, req)
 	if err != nil {
 		return dto.TransactionPaginationResponse{}, err
 	}
 
+	// The buyer, event and tier come preloaded with the page.
 	var datas []dto.TransactionResponse
 	for _, transaction := range dataWithPaginate.Transactions {
-		event, err := s.eventRepo.GetEventById(ctx, transaction.EventID)
-		if err != nil {
-			return dto.TransactionPaginationResponse{}, err
-		}
-
-		buyer, err := s.userRepo.GetUserById(ctx, transaction.BuyerID)
-		if err != nil {
-			return dto.TransactionPaginationResponse{}, err
-		}
-
 		data := dto.TransactionResponse{
-			ID:         transaction.ID.String(),
-			BuyerID:    buyer.ID.String(),
-			BuyerName:  buyer.Name,
-			BuyerEmail: buyer.Email,
-			EventID:    event.ID.String(),
-			EventName:  event.Name,
-			EventPrice: event.Price,
-			Amount:     transaction.Amount,
-			BuyedAt:    transaction.CreatedAt.String(),
+			ID:          transaction.ID.String(),
+			OrderID:     transaction.OrderID,
+			BuyerID:     transaction.BuyerID,
+			BuyerName:   transaction.Buyer.Name,
+			BuyerEmail:  transaction.Buyer.Email,
+			EventID:     transaction.EventID,
+			EventName:   transaction.Event.Name,
+			EventPrice:  transaction.UnitPrice,
+			TierID:      transaction.TierID,
+			TierName:    transaction.Tier.Name,
+			Amount:      transaction.Amount,
+			UnitPrice:   transaction.UnitPrice,
+			Subtotal:    transaction.Subtotal,
+			Discount:    transaction.Discount,
+			Fees:        transaction.Fees,
+			Total:       transaction.Total,
+			Currency:    transaction.Currency,
+			Status:      transaction.Status,
+			BuyedAt:     transaction.CreatedAt.String(),
+			PaidAt:      formatTimestamp(transaction.PaidAt),
+			CancelledAt: formatTimestamp(transaction.CancelledAt),
+			RefundedAt:  formatTimestamp(transaction.RefundedAt),
+			ExpiredAt:   formatTimestamp(transaction.ExpiredAt),
+			FailedAt:    formatTimestamp(transaction.FailedAt),
+
+			PaymentProvider:  transaction.PaymentProvider,
+			PaymentReference: transaction.PaymentReference,
+			PaymentURL:       transaction.PaymentURL,
 		}
 
 		datas = append(datas, data)
 	}
 
+	// The next keyset page continues after the last row of this one.
+	var nextCursor string
+	if n := len(dataWithPaginate.Transactions); dataWithPaginate.HasMore && n > 0 {
+		last := dataWithPaginate.Transactions[n-1]
+		nextCursor = utils.EncodeCursor(s.jwtService.CursorSigningKey(), last.CreatedAt, last.ID.String())
+	}
+
 	return dto.TransactionPaginationResponse{
 		Data: datas,
 		PaginationResponse: dto.PaginationResponse{
-			Page:    dataWithPaginate.Page,
-			PerPage: dataWithPaginate.PerPage,
-			MaxPage: dataWithPaginate.MaxPage,
-			Count:   dataWithPaginate.Count,
+			Page:       dataWithPaginate.Page,
+			PerPage:    dataWithPaginate.PerPage,
+			MaxPage:    dataWithPaginate.MaxPage,
+			Count:      dataWithPaginate.Count,
+			HasMore:    dataWithPaginate.HasMore,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
 
 func (s *transactionService) GetTransactionById(ctx context.Context, transactionId string) (dto.TransactionResponse, error) {
-	transaction, err := s.transactionRepo.GetTransactionById(ctx, transactionId)
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, nil, transactionId)
 	if err != nil {
 		return dto.TransactionResponse{}, dto.ErrGetTransactionById
 	}
 
-	event, err := s.eventRepo.GetEventById(ctx, transaction.EventID)
+	return s.buildTransactionResponse(ctx, transaction)
+}
+
+// GetVisibleTransactionById returns a transaction the viewer may see: one
+// they bought or one of an event they organize. An empty viewerId skips the
+// check, which is meant for admins only. Transactions the viewer may not
+// see are reported as not found, so their existence is not leaked.
+func (s *transactionService) GetVisibleTransactionById(ctx context.Context, transactionId string, viewerId string) (dto.TransactionResponse, error) {
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, nil, transactionId)
+	if err != nil {
+		return dto.TransactionResponse{}, dto.ErrTransactionNotFound
+	}
+
+	if viewerId != "" && transaction.BuyerID != viewerId {
+		event, err := s.eventRepo.GetEventById(ctx, nil, transaction.EventID)
+		if err != nil || event.AuthorID.String() != viewerId {
+			return dto.TransactionResponse{}, dto.ErrTransactionNotFound
+		}
+	}
+
+	return s.buildTransactionResponse(ctx, transaction)
+}
+
+func (s *transactionService) buildTransactionResponse(ctx context.Context, transaction entity.Transaction) (dto.TransactionResponse, error) {
+	event, err := s.eventRepo.GetEventById(ctx, nil, transaction.EventID)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	buyer, err := s.userRepo.GetUserById(ctx, transaction.BuyerID)
+	buyer, err := s.userRepo.GetUserById(ctx, nil, transaction.BuyerID)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
+	tier := s.transactionTier(ctx, nil, transaction)
+
 	return dto.TransactionResponse{
-		ID:         transaction.ID.String(),
-		BuyerID:    buyer.ID.String(),
-		BuyerName:  buyer.Name,
-		BuyerEmail: buyer.Email,
-		EventID:    event.ID.String(),
-		EventName:  event.Name,
-		EventPrice: event.Price,
-		Amount:     transaction.Amount,
-		BuyedAt:    transaction.CreatedAt.String(),
+		ID:          transaction.ID.String(),
+		OrderID:     transaction.OrderID,
+		BuyerID:     buyer.ID.String(),
+		BuyerName:   buyer.Name,
+		BuyerEmail:  buyer.Email,
+		EventID:     event.ID.String(),
+		EventName:   event.Name,
+		EventPrice:  transaction.UnitPrice,
+		TierID:      transaction.TierID,
+		TierName:    tier.Name,
+		Amount:      transaction.Amount,
+		UnitPrice:   transaction.UnitPrice,
+		Subtotal:    transaction.Subtotal,
+		Discount:    transaction.Discount,
+		Fees:        transaction.Fees,
+		Total:       transaction.Total,
+		Currency:    transaction.Currency,
+		Status:      transaction.Status,
+		BuyedAt:     transaction.CreatedAt.String(),
+		PaidAt:      formatTimestamp(transaction.PaidAt),
+		CancelledAt: formatTimestamp(transaction.CancelledAt),
+		RefundedAt:  formatTimestamp(transaction.RefundedAt),
+		ExpiredAt:   formatTimestamp(transaction.ExpiredAt),
+		FailedAt:    formatTimestamp(transaction.FailedAt),
+
+		PaymentProvider:  transaction.PaymentProvider,
+		PaymentReference: transaction.PaymentReference,
+		PaymentURL:       transaction.PaymentURL,
 	}, nil
 }
 
 func (s *transactionService) UpdateTransaction(ctx context.Context, req dto.TransactionUpdateRequest, transactionId string) (dto.TransactionUpdateResponse, error) {
-	transaction, err := s.transactionRepo.GetTransactionById(ctx, transactionId)
-	if err != nil {
-		return dto.TransactionUpdateResponse{}, fmt.Errorf("failed to fetch transaction: %v", err)
+	if req.Amount <= 0 {
+		return dto.TransactionUpdateResponse{}, dto.ErrInvalidAmount
 	}
 
-	transaction.Amount = req.Amount
+	var (
+		updatedTransaction entity.Transaction
+		delta              int
+	)
+
+	// Seats follow the amount: buying more takes them from the event and
+	// buying fewer gives them back, in the same database transaction.
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return fmt.Errorf("failed to fetch transaction: %v", err)
+		}
+
+		if transaction.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+			return dto.ErrTransactionNotEditable
+		}
+
+		delta = req.Amount - transaction.Amount
+		if delta > 0 {
+			if err := s.takeSeats(ctx, tx, transaction.EventID, transaction.TierID, delta); err != nil {
+				return err
+			}
 
-	updatedTransaction, err := s.transactionRepo.UpdateTransaction(ctx, transaction)
+			if err := s.checkTransactionLimits(ctx, tx, order, transaction, delta); err != nil {
+				return err
+			}
+		} else if delta < 0 {
+			if err := s.releaseSeats(ctx, tx, transaction.EventID, transaction.TierID, -delta); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repriceTransaction(ctx, tx, &transaction, req.Amount); err != nil {
+			return err
+		}
+
+		if err := s.holdRepo.UpdateHoldQuantity(ctx, tx, transaction.ID.String(), req.Amount); err != nil {
+			return err
+		}
+
+		updatedTransaction, err = s.transactionRepo.UpdateTransaction(ctx, tx, transaction)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction: %v", err)
+		}
+
+		if err := s.transactionRepo.UpdateTransactionTotals(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
 	if err != nil {
-		return dto.TransactionUpdateResponse{}, fmt.Errorf("failed to update transaction: %v", err)
+		return dto.TransactionUpdateResponse{}, err
 	}
 
-	event, err := s.eventRepo.GetEventById(ctx, updatedTransaction.EventID)
+	event, err := s.eventRepo.GetEventById(ctx, nil, updatedTransaction.EventID)
 	if err != nil {
 		return dto.TransactionUpdateResponse{}, err
 	}
 
-	buyer, err := s.userRepo.GetUserById(ctx, updatedTransaction.BuyerID)
+	buyer, err := s.userRepo.GetUserById(ctx, nil, updatedTransaction.BuyerID)
 	if err != nil {
 		return dto.TransactionUpdateResponse{}, err
 	}
 
+	tier := s.transactionTier(ctx, nil, updatedTransaction)
+
+	// The old charge of the order no longer matches the amount owed.
+	if delta != 0 {
+		if _, err := s.createCharge(ctx, updatedTransaction.OrderID, buyer); err != nil {
+			return dto.TransactionUpdateResponse{}, err
+		}
+	}
+
 	return dto.TransactionUpdateResponse{
 		ID:         updatedTransaction.ID.String(),
 		BuyerName:  buyer.Name,
 		BuyerEmail: buyer.Email,
 		EventName:  event.Name,
-		EventPrice: event.Price,
+		EventPrice: updatedTransaction.UnitPrice,
+		TierName:   tier.Name,
 		Amount:     updatedTransaction.Amount,
+		UnitPrice:  updatedTransaction.UnitPrice,
+		Subtotal:   updatedTransaction.Subtotal,
+		Discount:   updatedTransaction.Discount,
+		Fees:       updatedTransaction.Fees,
+		Total:      updatedTransaction.Total,
+		Currency:   updatedTransaction.Currency,
+		Status:     updatedTransaction.Status,
 		BuyedAt:    updatedTransaction.CreatedAt.String(),
 	}, nil
 }
 
+func (s *transactionService) UpdateTransactionStatus(ctx context.Context, req dto.TransactionStatusUpdateRequest, transactionId string) (dto.TransactionResponse, error) {
+	if !isValidTransactionStatus(req.Status) {
+		return dto.TransactionResponse{}, dto.ErrInvalidTransactionStatus
+	}
+
+	// Cancelling and refunding go through their own flows so the refund
+	// policy and the refund records are never skipped.
+	switch req.Status {
+	case constants.ENUM_TRANSACTION_STATUS_CANCELLED:
+		result, err := s.CancelTransaction(ctx, dto.TransactionCancelRequest{}, transactionId)
+		return result.Transaction, err
+	case constants.ENUM_TRANSACTION_STATUS_REFUNDED:
+		result, err := s.RefundTransaction(ctx, dto.TransactionRefundRequest{}, transactionId)
+		return result.Transaction, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.transitionTransaction(ctx, tx, transaction, req.Status); err != nil {
+			return err
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	if req.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+		s.deliverInvoices(ctx, transactionId)
+	}
+
+	return s.GetTransactionById(ctx, transactionId)
+}
+
+// transitionTransaction moves an already locked transaction to the given
+// status and stamps the timestamp belonging to that status.
+func (s *transactionService) transitionTransaction(ctx context.Context, tx *gorm.DB, transaction entity.Transaction, status string) (entity.Transaction, error) {
+	if !canTransitionTransaction(transaction.Status, status) {
+		return entity.Transaction{}, &dto.ErrInvalidStatusTransition{
+			From: transaction.Status,
+			To:   status,
+		}
+	}
+
+	now := time.Now()
+	transaction.Status = status
+
+	switch status {
+	case constants.ENUM_TRANSACTION_STATUS_PAID:
+		transaction.PaidAt = &now
+		transaction.PaidAmount = transaction.Amount
+		transaction.PaidTotal = transaction.Total
+	case constants.ENUM_TRANSACTION_STATUS_CANCELLED:
+		transaction.CancelledAt = &now
+	case constants.ENUM_TRANSACTION_STATUS_REFUNDED:
+		transaction.RefundedAt = &now
+	case constants.ENUM_TRANSACTION_STATUS_EXPIRED:
+		transaction.ExpiredAt = &now
+	case constants.ENUM_TRANSACTION_STATUS_FAILED:
+		transaction.FailedAt = &now
+	}
+
+	// Every final status other than paid gives the seats back to the event.
+	if releasesSeats(status) {
+		if err := s.releaseSeats(ctx, tx, transaction.EventID, transaction.TierID, transaction.Amount); err != nil {
+			return entity.Transaction{}, err
+		}
+
+		if err := s.ticketRepo.VoidTicketsByTransactionId(ctx, tx, transaction.ID.String(), "", 0); err != nil {
+			return entity.Transaction{}, err
+		}
+
+		if err := s.releaseVouchers(ctx, tx, transaction.ID.String()); err != nil {
+			return entity.Transaction{}, err
+		}
+	}
+
+	if status == constants.ENUM_TRANSACTION_STATUS_PAID {
+		if err := s.issueTickets(ctx, tx, transaction); err != nil {
+			return entity.Transaction{}, err
+		}
+
+		if err := s.issueInvoice(ctx, tx, transaction); err != nil {
+			return entity.Transaction{}, err
+		}
+	}
+
+	if err := s.holdRepo.SettleHold(ctx, tx, transaction.ID.String(), seatHoldStatus(status)); err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return s.transactionRepo.UpdateTransaction(ctx, tx, transaction)
+}
+
+// issueTickets creates one ticket with its own code for every unit bought.
+func (s *transactionService) issueTickets(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) error {
+	tickets := make([]entity.Ticket, 0, transaction.Amount)
+	for i := 0; i < transaction.Amount; i++ {
+		code, err := utils.GenerateCode(TICKET_CODE_SIZE)
+		if err != nil {
+			return dto.ErrIssueTickets
+		}
+
+		tickets = append(tickets, entity.Ticket{
+			TransactionID: transaction.ID.String(),
+			EventID:       transaction.EventID,
+			OwnerID:       transaction.BuyerID,
+			Code:          code,
+			Status:        constants.ENUM_TICKET_STATUS_ACTIVE,
+		})
+	}
+
+	if _, err := s.ticketRepo.CreateTickets(ctx, tx, tickets); err != nil {
+		return dto.ErrIssueTickets
+	}
+
+	return nil
+}
+
+func (s *transactionService) CancelTransaction(ctx context.Context, req dto.TransactionCancelRequest, transactionId string) (dto.TransactionRefundResponse, error) {
+	var refund *entity.Refund
+	var eventId string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return err
+		}
+
+		if !canTransitionTransaction(transaction.Status, constants.ENUM_TRANSACTION_STATUS_CANCELLED) {
+			return &dto.ErrInvalidStatusTransition{
+				From: transaction.Status,
+				To:   constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+			}
+		}
+		eventId = transaction.EventID
+
+		// Only money that was actually paid is refunded.
+		if transaction.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+			if err := s.checkTicketsHeld(ctx, tx, transaction); err != nil {
+				return err
+			}
+
+			refund, err = s.refundTickets(ctx, tx, transaction, transaction.Amount, req.Reason)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := s.transitionTransaction(ctx, tx, transaction, constants.ENUM_TRANSACTION_STATUS_CANCELLED); err != nil {
+			return err
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return dto.TransactionRefundResponse{}, err
+	}
+
+	s.sendRefunds(ctx, refund)
+	s.offerSeats(ctx, eventId)
+	return s.buildRefundResponse(ctx, transactionId, refund)
+}
+
+func (s *transactionService) RefundTransaction(ctx context.Context, req dto.TransactionRefundRequest, transactionId string) (dto.TransactionRefundResponse, error) {
+	var refund *entity.Refund
+	var eventId string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return err
+		}
+
+		if transaction.Status != constants.ENUM_TRANSACTION_STATUS_PAID {
+			return &dto.ErrInvalidStatusTransition{
+				From: transaction.Status,
+				To:   constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+			}
+		}
+
+		held, err := s.heldTickets(ctx, tx, transaction)
+		if err != nil {
+			return err
+		}
+
+		// Without a quantity every ticket the buyer still holds is refunded.
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = held
+		}
+
+		if quantity < 0 || quantity > transaction.Amount {
+			return dto.ErrInvalidRefundQuantity
+		}
+
+		if quantity == 0 || quantity > held {
+			return dto.ErrTicketsHandedOver
+		}
+		eventId = transaction.EventID
+
+		refund, err = s.refundTickets(ctx, tx, transaction, quantity, req.Reason)
+		if err != nil {
+			return err
+		}
+
+		if quantity == transaction.Amount {
+			if _, err := s.transitionTransaction(ctx, tx, transaction, constants.ENUM_TRANSACTION_STATUS_REFUNDED); err != nil {
+				return err
+			}
+
+			_, err = s.syncOrder(ctx, tx, order)
+			return err
+		}
+
+		if err := s.releaseSeats(ctx, tx, transaction.EventID, transaction.TierID, quantity); err != nil {
+			return err
+		}
+
+		if err := s.ticketRepo.VoidTicketsByTransactionId(ctx, tx, transaction.ID.String(), transaction.BuyerID, quantity); err != nil {
+			return err
+		}
+
+		// The remaining tickets keep their share of the discount and fees.
+		transaction.Subtotal -= transaction.Subtotal * quantity / transaction.Amount
+		transaction.Discount -= transaction.Discount * quantity / transaction.Amount
+		transaction.Fees -= transaction.Fees * quantity / transaction.Amount
+		transaction.Total = transaction.Subtotal - transaction.Discount + transaction.Fees
+		transaction.Amount -= quantity
+
+		if _, err := s.transactionRepo.UpdateTransaction(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		return s.transactionRepo.UpdateTransactionTotals(ctx, tx, transaction)
+	})
+	if err != nil {
+		return dto.TransactionRefundResponse{}, err
+	}
+
+	s.sendRefunds(ctx, refund)
+	s.offerSeats(ctx, eventId)
+	return s.buildRefundResponse(ctx, transactionId, refund)
+}
+
+// heldTickets returns how many tickets of a paid transaction its buyer still
+// holds. Tickets transferred to someone else or checked in were handed over
+// and cannot be given back.
+func (s *transactionService) heldTickets(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) (int, error) {
+	handedOver, err := s.ticketRepo.CountHandedOverTickets(ctx, tx, transaction.ID.String(), transaction.BuyerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return transaction.Amount - handedOver, nil
+}
+
+// checkTicketsHeld refuses to cancel a paid transaction once any of its
+// tickets were handed over.
+func (s *transactionService) checkTicketsHeld(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) error {
+	held, err := s.heldTickets(ctx, tx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if held < transaction.Amount {
+		return dto.ErrTicketsHandedOver
+	}
+
+	return nil
+}
+
+// refundTickets applies the event refund policy to quantity paid tickets,
+// records the refund and asks the payment provider to send the money back.
+// Seats are not released here; the caller does that together with the
+// status change.
+func (s *transactionService) refundTickets(ctx context.Context, tx *gorm.DB, transaction entity.Transaction, quantity int, reason string) (*entity.Refund, error) {
+	event, err := s.eventRepo.GetEventById(ctx, tx, transaction.EventID)
+	if err != nil {
+		return nil, dto.ErrEventNotFound
+	}
+
+	if event.RefundDeadline != nil && time.Now().After(*event.RefundDeadline) {
+		return nil, dto.ErrRefundDeadlinePassed
+	}
+
+	// Refunds are based on what was paid for the tickets after discounts.
+	amount := transaction.Total * quantity * event.RefundPercentage / (transaction.Amount * 100)
+
+	return s.recordRefund(ctx, tx, transaction, quantity, amount, reason)
+}
+
+// recordRefund records a pending refund of amount for quantity tickets. The
+// money is sent by sendRefunds after the caller committed, so a rolled back
+// change never sends money and a slow provider holds no row locks.
+func (s *transactionService) recordRefund(ctx context.Context, tx *gorm.DB, transaction entity.Transaction, quantity int, amount int, reason string) (*entity.Refund, error) {
+	refund := entity.Refund{
+		TransactionID: transaction.ID.String(),
+		Quantity:      quantity,
+		Amount:        amount,
+		Reason:        reason,
+		Status:        constants.ENUM_REFUND_STATUS_PENDING,
+	}
+
+	// Nothing goes back when the policy refunds nothing or nothing was
+	// charged.
+	if amount == 0 || transaction.PaymentReference == "" {
+		now := time.Now()
+		refund.Status = constants.ENUM_REFUND_STATUS_SENT
+		refund.SentAt = &now
+	}
+
+	refund, err := s.refundRepo.CreateRefund(ctx, tx, refund)
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// sendRefunds asks the payment provider to send back the money of pending
+// refunds and marks them sent or failed. It runs after the refunds
+// committed, so failures are only logged and left on the refund for an
+// admin to settle.
+func (s *transactionService) sendRefunds(ctx context.Context, refunds ...*entity.Refund) {
+	for _, refund := range refunds {
+		if refund == nil || refund.Status != constants.ENUM_REFUND_STATUS_PENDING {
+			continue
+		}
+
+		if err := s.sendRefund(ctx, refund); err != nil {
+			log.Printf("failed to send refund %s of transaction %s: %v", refund.ID, refund.TransactionID, err)
+		}
+	}
+}
+
+func (s *transactionService) sendRefund(ctx context.Context, refund *entity.Refund) error {
+	transaction, err := s.transactionRepo.GetTransactionById(ctx, nil, refund.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	provider, err := s.paymentService.GetProvider(transaction.PaymentProvider)
+	if err == nil {
+		err = provider.Refund(ctx, transaction.PaymentReference, refund.Amount)
+	}
+
+	if err != nil {
+		refund.Status = constants.ENUM_REFUND_STATUS_FAILED
+	} else {
+		now := time.Now()
+		refund.Status = constants.ENUM_REFUND_STATUS_SENT
+		refund.SentAt = &now
+	}
+
+	if updateErr := s.refundRepo.UpdateRefundStatus(ctx, nil, *refund); updateErr != nil {
+		return updateErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", dto.ErrRefundPayment, err)
+	}
+
+	return nil
+}
+
+func (s *transactionService) buildRefundResponse(ctx context.Context, transactionId string, refund *entity.Refund) (dto.TransactionRefundResponse, error) {
+	transaction, err := s.GetTransactionById(ctx, transactionId)
+	if err != nil {
+		return dto.TransactionRefundResponse{}, err
+	}
+
+	res := dto.TransactionRefundResponse{
+		Transaction: transaction,
+	}
+
+	if refund != nil {
+		refundRes := toRefundResponse(*refund)
+		res.Refund = &refundRes
+	}
+
+	return res, nil
+}
+
+func toRefundResponse(refund entity.Refund) dto.RefundResponse {
+	return dto.RefundResponse{
+		ID:            refund.ID.String(),
+		TransactionID: refund.TransactionID,
+		Quantity:      refund.Quantity,
+		Amount:        refund.Amount,
+		Reason:        refund.Reason,
+		Status:        refund.Status,
+		RefundedAt:    refund.CreatedAt.String(),
+	}
+}
+
 func (s *transactionService) DeleteTransaction(ctx context.Context, transactionId string) error {
-	transaction, err := s.transactionRepo.GetTransactionById(ctx, transactionId)
+	var eventId string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, transaction, err := s.lockTransaction(ctx, tx, transactionId)
+		if err != nil {
+			return err
+		}
+		eventId = transaction.EventID
+
+		// Money was taken for a paid purchase, so it has to go through the
+		// cancellation or refund that sends it back.
+		if transaction.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+			return dto.ErrDeletePaidTransaction
+		}
+
+		// Seats of a pending purchase go back to the event with the row.
+		if !releasesSeats(transaction.Status) {
+			if err := s.releaseSeats(ctx, tx, transaction.EventID, transaction.TierID, transaction.Amount); err != nil {
+				return err
+			}
+
+			if err := s.releaseVouchers(ctx, tx, transaction.ID.String()); err != nil {
+				return err
+			}
+
+			if err := s.holdRepo.SettleHold(ctx, tx, transaction.ID.String(), constants.ENUM_SEAT_HOLD_STATUS_RELEASED); err != nil {
+				return err
+			}
+		}
+
+		if err := s.ticketRepo.VoidTicketsByTransactionId(ctx, tx, transaction.ID.String(), "", 0); err != nil {
+			return err
+		}
+
+		if err := s.transactionRepo.DeleteTransaction(ctx, tx, transaction.ID.String()); err != nil {
+			return dto.ErrDeleteTransaction
+		}
+
+		_, err = s.syncOrder(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.offerSeats(ctx, eventId)
+	return nil
+}
+
+func (s *transactionService) HandlePaymentWebhook(ctx context.Context, providerName string, payload []byte, signature string) error {
+	provider, err := s.paymentService.GetProvider(providerName)
+	if err != nil {
+		return err
+	}
+
+	notification, err := provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	var status string
+	switch notification.Status {
+	case constants.ENUM_PAYMENT_STATUS_PAID:
+		status = constants.ENUM_TRANSACTION_STATUS_PAID
+	case constants.ENUM_PAYMENT_STATUS_FAILED:
+		status = constants.ENUM_TRANSACTION_STATUS_FAILED
+	default:
+		return dto.ErrInvalidPaymentStatus
+	}
+
+	// Charges are made per order, so the notification settles every item
+	// of the order at once.
+	var paid []string
+	var refunds []*entity.Refund
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.GetOrderByPaymentReferenceForUpdate(ctx, tx, provider.Name(), notification.Reference)
+		if err != nil {
+			return dto.ErrPaymentNotFound
+		}
+
+		// Providers retry notifications, so a repeated one is not an error.
+		if order.Status == status {
+			return nil
+		}
+
+		// A payment that lands after the order ended unpaid, typically once
+		// its hold expired, buys nothing: its seats may be sold again, so the
+		// charge is sent back instead.
+		if status == constants.ENUM_TRANSACTION_STATUS_PAID && order.PaidAt == nil &&
+			order.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+			refunds, err = s.refundLatePayment(ctx, tx, order)
+			return err
+		}
+
+		if order.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+			return &dto.ErrInvalidStatusTransition{
+				From: order.Status,
+				To:   status,
+			}
+		}
+
+		if err := s.transitionOrder(ctx, tx, order, status); err != nil {
+			return err
+		}
+
+		if status != constants.ENUM_TRANSACTION_STATUS_PAID {
+			return nil
+		}
+
+		items, err := s.transactionRepo.GetTransactionsByOrderIdForUpdate(ctx, tx, order.ID.String())
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if item.Status == constants.ENUM_TRANSACTION_STATUS_PAID {
+				paid = append(paid, item.ID.String())
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendRefunds(ctx, refunds...)
+	s.deliverInvoices(ctx, paid...)
+	return nil
+}
+
+// refundLatePayment records a refund of every item of an order that was
+// charged after it ended unpaid. Items refunded by an earlier notification
+// of the same charge are skipped, so retries do not refund twice. The
+// refunds are sent by the caller once they committed.
+func (s *transactionService) refundLatePayment(ctx context.Context, tx *gorm.DB, order entity.Order) ([]*entity.Refund, error) {
+	items, err := s.transactionRepo.GetTransactionsByOrderIdForUpdate(ctx, tx, order.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []*entity.Refund
+	for _, item := range items {
+		existing, err := s.refundRepo.GetRefundsByTransactionId(ctx, tx, item.ID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if len(existing) > 0 {
+			continue
+		}
+
+		refund, err := s.recordRefund(ctx, tx, item, item.Amount, item.Total, fmt.Sprintf("payment received after the order was %s", order.Status))
+		if err != nil {
+			return nil, err
+		}
+
+		refunds = append(refunds, refund)
+	}
+
+	return refunds, nil
+}
+
+// ExpireHolds expires up to limit pending purchases whose seat hold ran out,
+// giving their seats back, and reports how many holds it settled. Every
+// purchase is expired in its own database transaction; one that fails is
+// logged and retried on the next sweep while the others go ahead.
+func (s *transactionService) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	holds, err := s.holdRepo.GetExpiredHolds(ctx, nil, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]string, 0, len(holds))
+	for _, hold := range holds {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			order, transaction, err := s.lockTransaction(ctx, tx, hold.TransactionID)
+			if errors.Is(err, dto.ErrTransactionNotFound) {
+				// A hold left behind by a deleted purchase holds no seats
+				// anymore and would otherwise fail on every sweep.
+				return s.holdRepo.SettleHold(ctx, tx, hold.TransactionID, constants.ENUM_SEAT_HOLD_STATUS_RELEASED)
+			}
+			if err != nil {
+				return err
+			}
+
+			// The purchase may have been paid or ended since the holds were
+			// read; its hold then only needs to follow.
+			if transaction.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+				return s.holdRepo.SettleHold(ctx, tx, transaction.ID.String(), seatHoldStatus(transaction.Status))
+			}
+
+			if _, err := s.transitionTransaction(ctx, tx, transaction, constants.ENUM_TRANSACTION_STATUS_EXPIRED); err != nil {
+				return err
+			}
+
+			_, err = s.syncOrder(ctx, tx, order)
+			return err
+		})
+		if err != nil {
+			log.Printf("failed to expire hold %s: %v", hold.ID, err)
+			continue
+		}
+
+		events = append(events, hold.EventID)
+	}
+
+	s.offerSeats(ctx, events...)
+	return len(events), nil
+}
+
+// checkTransactionLimits checks that the event still sells tickets and its
+// purchase limits before a pending transaction grows by added tickets.
+func (s *transactionService) checkTransactionLimits(ctx context.Context, tx *gorm.DB, order entity.Order, transaction entity.Transaction, added int) error {
+	event, err := s.eventRepo.GetEventById(ctx, tx, transaction.EventID)
+	if err != nil {
+		return dto.ErrEventNotFound
+	}
+
+	if event.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+		return dto.ErrEventNotOnSale
+	}
+
+	if hasEventEnded(event, time.Now()) {
+		return dto.ErrEventEnded
+	}
+
+	inOrder, err := s.transactionRepo.SumLiveAmount(ctx, tx, transaction.BuyerID, transaction.EventID, order.ID.String())
+	if err != nil {
+		return err
+	}
+
+	held, err := s.transactionRepo.SumLiveAmount(ctx, tx, transaction.BuyerID, transaction.EventID, "")
+	if err != nil {
+		return err
+	}
+
+	return checkPurchaseLimits(event, inOrder, held, added)
+}
+
+// checkPurchaseLimits reports whether requested more tickets of an event fit
+// next to the inOrder tickets of the same order and the held tickets of the
+// buyer. Callers count after taking the seats: that update locks the event
+// row until commit, so concurrent purchases of the same buyer wait for this
+// one and then count its tickets too.
+func checkPurchaseLimits(event entity.Event, inOrder int, held int, requested int) error {
+	if event.MaxTicketsPerOrder > 0 && inOrder+requested > event.MaxTicketsPerOrder {
+		return &dto.ErrPurchaseLimitExceeded{
+			EventID:   event.ID.String(),
+			Scope:     constants.ENUM_PURCHASE_LIMIT_SCOPE_ORDER,
+			Limit:     event.MaxTicketsPerOrder,
+			Remaining: max(event.MaxTicketsPerOrder-inOrder, 0),
+		}
+	}
+
+	if event.MaxTicketsPerUser > 0 && held+requested > event.MaxTicketsPerUser {
+		return &dto.ErrPurchaseLimitExceeded{
+			EventID:   event.ID.String(),
+			Scope:     constants.ENUM_PURCHASE_LIMIT_SCOPE_USER,
+			Limit:     event.MaxTicketsPerUser,
+			Remaining: max(event.MaxTicketsPerUser-held, 0),
+		}
+	}
+
+	return nil
+}
+
+// offerSeats passes seats freed on the given events to their waitlists. The
+// seats are already back on sale, so a failure is only logged and the hold
+// sweeper offers them again later.
+func (s *transactionService) offerSeats(ctx context.Context, eventIds ...string) {
+	offered := make(map[string]bool, len(eventIds))
+	for _, eventId := range eventIds {
+		if eventId == "" || offered[eventId] {
+			continue
+		}
+		offered[eventId] = true
+
+		if err := s.waitlistService.OfferSeats(ctx, eventId); err != nil {
+			log.Printf("error offering freed seats of event %s: %v", eventId, err)
+		}
+	}
+}
+
+// takeSeats removes seats from the event and the tier they are bought in.
+// The event row is always updated before the tier, the same order tier
+// changes lock them in.
+func (s *transactionService) takeSeats(ctx context.Context, tx *gorm.DB, eventId string, tierId string, amount int) error {
+	if err := s.eventRepo.DecreaseAvailability(ctx, tx, eventId, amount); err != nil {
+		return err
+	}
+
+	if tierId == "" {
+		return nil
+	}
+
+	return s.tierRepo.DecreaseTierAvailability(ctx, tx, tierId, amount)
+}
+
+func (s *transactionService) releaseSeats(ctx context.Context, tx *gorm.DB, eventId string, tierId string, amount int) error {
+	if err := s.eventRepo.IncreaseAvailability(ctx, tx, eventId, amount); err != nil {
+		return err
+	}
+
+	if tierId == "" {
+		return nil
+	}
+
+	return s.tierRepo.IncreaseTierAvailability(ctx, tx, tierId, amount)
+}
+
+// transactionTier returns the tier a transaction was bought in, or an
+// unnamed tier when it no longer exists. Prices always come from the
+// snapshot on the transaction, never from the tier.
+func (s *transactionService) transactionTier(ctx context.Context, tx *gorm.DB, transaction entity.Transaction) entity.TicketTier {
+	if transaction.TierID != "" {
+		tier, err := s.tierRepo.GetTierById(ctx, tx, transaction.TierID)
+		if err == nil {
+			return tier
+		}
+	}
+
+	return entity.TicketTier{
+		EventID: transaction.EventID,
+	}
+}
+
+// getVouchers loads the vouchers of a purchase and checks they can be used
+// on it together.
+func (s *transactionService) getVouchers(ctx context.Context, tx *gorm.DB, codes []string, eventId string, tierId string) ([]entity.Voucher, error) {
+	now := time.Now()
+
+	vouchers := make([]entity.Voucher, 0, len(codes))
+	for _, code := range codes {
+		voucher, err := s.voucherRepo.GetVoucherByCode(ctx, tx, code)
+		if err != nil {
+			return nil, dto.ErrVoucherNotFound
+		}
+
+		if err := checkVoucher(voucher, eventId, tierId, now); err != nil {
+			return nil, err
+		}
+
+		vouchers = append(vouchers, voucher)
+	}
+
+	if len(vouchers) > 1 {
+		for _, voucher := range vouchers {
+			if !voucher.Stackable {
+				return nil, dto.ErrVoucherNotStackable
+			}
+		}
+	}
+
+	return vouchers, nil
+}
+
+// redeemVouchers counts the use of every voucher and records the discount
+// it gave. The per user limit is checked after RedeemVoucher locked the
+// voucher row, so two purchases of the same user cannot both pass it.
+// Vouchers are redeemed in code order across the whole order, which is the
+// order their rows get locked in.
+func (s *transactionService) redeemVouchers(ctx context.Context, tx *gorm.DB, uses []voucherUse) error {
+	sort.SliceStable(uses, func(i, j int) bool {
+		return uses[i].voucher.Code < uses[j].voucher.Code
+	})
+
+	for _, use := range uses {
+		if err := s.voucherRepo.RedeemVoucher(ctx, tx, use.voucher.ID.String()); err != nil {
+			return err
+		}
+
+		if use.voucher.MaxRedemptionsPerUser > 0 {
+			used, err := s.voucherRepo.CountUserRedemptions(ctx, tx, use.voucher.ID.String(), use.transaction.BuyerID)
+			if err != nil {
+				return err
+			}
+
+			if used >= int64(use.voucher.MaxRedemptionsPerUser) {
+				return dto.ErrVoucherUserLimit
+			}
+		}
+
+		if _, err := s.voucherRepo.CreateRedemption(ctx, tx, entity.VoucherRedemption{
+			VoucherID:     use.voucher.ID.String(),
+			UserID:        use.transaction.BuyerID,
+			TransactionID: use.transaction.ID.String(),
+			Discount:      use.discount,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseVouchers gives the uses of a purchase that did not go through back
+// to its vouchers.
+func (s *transactionService) releaseVouchers(ctx context.Context, tx *gorm.DB, transactionId string) error {
+	redemptions, err := s.voucherRepo.GetActiveRedemptionsByTransactionId(ctx, tx, transactionId)
 	if err != nil {
-		return dto.ErrTransactionNotFound
+		return err
+	}
+
+	for _, redemption := range redemptions {
+		if err := s.voucherRepo.ReleaseVoucher(ctx, tx, redemption.VoucherID); err != nil {
+			return err
+		}
+
+		if err := s.voucherRepo.ReleaseRedemption(ctx, tx, redemption.ID.String()); err != nil {
+			return err
+		}
 	}
 
-	err = s.transactionRepo.DeleteTransaction(ctx, transaction.ID.String())
+	return nil
+}
+
+// repriceTransaction changes the amount of a transaction and recomputes its
+// totals from the snapshotted unit price and fee, keeping the vouchers it
+// was bought with.
+func (s *transactionService) repriceTransaction(ctx context.Context, tx *gorm.DB, transaction *entity.Transaction, amount int) error {
+	feePerTicket := transaction.Fees / transaction.Amount
+
+	redemptions, err := s.voucherRepo.GetActiveRedemptionsByTransactionId(ctx, tx, transaction.ID.String())
 	if err != nil {
-		return dto.ErrDeleteTransaction
+		return err
+	}
+
+	vouchers := make([]entity.Voucher, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		vouchers = append(vouchers, redemption.Voucher)
 	}
 
+	transaction.Amount = amount
+	transaction.Subtotal = transaction.UnitPrice * amount
+	transaction.Fees = feePerTicket * amount
+
+	discounts := calculateDiscounts(transaction.Subtotal, vouchers)
+	for i, redemption := range redemptions {
+		if err := s.voucherRepo.UpdateRedemptionDiscount(ctx, tx, redemption.ID.String(), discounts[i]); err != nil {
+			return err
+		}
+	}
+
+	transaction.Discount = sumDiscounts(discounts)
+	transaction.Total = transaction.Subtotal - transaction.Discount + transaction.Fees
+	return nil
+}
+
+// normalizeVoucherCodes merges both voucher fields of a purchase into a
+// sorted list without duplicates.
+func normalizeVoucherCodes(voucherCode string, voucherCodes []string) []string {
+	seen := make(map[string]bool)
+	codes := []string{}
+
+	for _, code := range append([]string{voucherCode}, voucherCodes...) {
+		code = normalizeVoucherCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+	return codes
+}
+
+func sumDiscounts(discounts []int) int {
+	total := 0
+	for _, discount := range discounts {
+		total += discount
+	}
+
+	return total
+}
+
+// selectTier picks the tier a purchase is made in. The tier may be left out
+// for events that only sell one.
+func selectTier(event entity.Event, tierId string) (entity.TicketTier, error) {
+	if tierId == "" {
+		if len(event.Tiers) != 1 {
+			return entity.TicketTier{}, dto.ErrTierRequired
+		}
+
+		return event.Tiers[0], nil
+	}
+
+	for _, tier := range event.Tiers {
+		if tier.ID.String() == tierId {
+			return tier, nil
+		}
+	}
+
+	return entity.TicketTier{}, dto.ErrTierNotFound
+}
+
+func isValidTransactionStatus(status string) bool {
+	if status == constants.ENUM_TRANSACTION_STATUS_PENDING {
+		return true
+	}
+
+	for _, next := range transactionStatusTransitions {
+		for _, candidate := range next {
+			if candidate == status {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// releasesSeats reports whether a transaction in this status no longer
+// holds seats on its event.
+func releasesSeats(status string) bool {
+	switch status {
+	case constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+		constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_EXPIRED,
+		constants.ENUM_TRANSACTION_STATUS_FAILED:
+		return true
+	}
+
+	return false
+}
+
+// seatHoldStatus is the status the seat hold of a purchase ends with once
+// the purchase moved to the given status.
+func seatHoldStatus(transactionStatus string) string {
+	switch transactionStatus {
+	case constants.ENUM_TRANSACTION_STATUS_PAID:
+		return constants.ENUM_SEAT_HOLD_STATUS_CONVERTED
+	case constants.ENUM_TRANSACTION_STATUS_EXPIRED:
+		return constants.ENUM_SEAT_HOLD_STATUS_EXPIRED
+	}
+
+	return constants.ENUM_SEAT_HOLD_STATUS_RELEASED
+}
+
+func canTransitionTransaction(from string, to string) bool {
+	for _, candidate := range transactionStatusTransitions[from] {
+		if candidate == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodePageCursor turns the cursor of a keyset page request, signed with
+// key, into the position the page continues after. The first page has an
+// empty cursor.
+func decodePageCursor(req *dto.PaginationRequest, key string) error {
+	if req.Cursor == nil || *req.Cursor == "" {
+		return nil
+	}
+
+	createdAt, id, err := utils.DecodeCursor(key, *req.Cursor)
+	if err != nil {
+		return dto.ErrInvalidCursor
+	}
+
+	req.After = &dto.Cursor{CreatedAt: createdAt, ID: id}
 	return nil
 }
+
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.String()
+}
@@ -60,7 +60,7 @@ func (s *userService) RegisterUser(ctx context.Context, req dto.UserCreateReques
 
 	var filename string
 
-	_, flag, _ := s.userRepo.CheckEmail(ctx, req.Email)
+	_, flag, _ := s.userRepo.CheckEmail(ctx, nil, req.Email)
 	if flag {
 		return dto.UserResponse{}, dto.ErrEmailAlreadyExists
 	}
@@ -85,7 +85,7 @@ func (s *userService) RegisterUser(ctx context.Context, req dto.UserCreateReques
 		IsVerified: false,
 	}
 
-	userReg, err := s.userRepo.RegisterUser(ctx, user)
+	userReg, err := s.userRepo.RegisterUser(ctx, nil, user)
 	if err != nil {
 		return dto.UserResponse{}, dto.ErrCreateUser
 	}
@@ -153,7 +153,7 @@ func makeVerificationEmail(receiverEmail string) (map[string]string, error) {
 }
 
 func (s *userService) SendVerificationEmail(ctx context.Context, req dto.SendVerificationEmailRequest) error {
-	user, err := s.userRepo.GetUserByEmail(ctx, req.Email)
+	user, err := s.userRepo.GetUserByEmail(ctx, nil, req.Email)
 	if err != nil {
 		return dto.ErrEmailNotFound
 	}
@@ -198,7 +198,7 @@ func (s *userService) VerifyEmail(ctx context.Context, req dto.VerifyEmailReques
 		}, dto.ErrTokenExpired
 	}
 
-	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	user, err := s.userRepo.GetUserByEmail(ctx, nil, email)
 	if err != nil {
 		return dto.VerifyEmailResponse{}, dto.ErrUserNotFound
 	}
@@ -207,7 +207,7 @@ func (s *userService) VerifyEmail(ctx context.Context, req dto.VerifyEmailReques
 		return dto.VerifyEmailResponse{}, dto.ErrAccountAlreadyVerified
 	}
 
-	updatedUser, err := s.userRepo.UpdateUser(ctx, entity.User{
+	updatedUser, err := s.userRepo.UpdateUser(ctx, nil, entity.User{
 		ID:         user.ID,
 		IsVerified: true,
 	})
@@ -222,7 +222,11 @@ func (s *userService) VerifyEmail(ctx context.Context, req dto.VerifyEmailReques
 }
 
 func (s *userService) GetAllUserWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.UserPaginationResponse, error) {
-	dataWithPaginate, err := s.userRepo.GetAllUserWithPagination(ctx, req)
+	if err := decodePageCursor(&req, s.jwtService.CursorSigningKey()); err != nil {
+		return dto.UserPaginationResponse{}, err
+	}
+
+	dataWithPaginate, err := s.userRepo.GetAllUserWithPagination(ctx, nil, req)
 	if err != nil {
 		return dto.UserPaginationResponse{}, err
 	}
@@ -242,19 +246,28 @@ func (s *userService) GetAllUserWithPagination(ctx context.Context, req dto.Pagi
 		datas = append(datas, data)
 	}
 
+	// The next keyset page continues after the last row of this one.
+	var nextCursor string
+	if n := len(dataWithPaginate.Users); dataWithPaginate.HasMore && n > 0 {
+		last := dataWithPaginate.Users[n-1]
+		nextCursor = utils.EncodeCursor(s.jwtService.CursorSigningKey(), last.CreatedAt, last.ID.String())
+	}
+
 	return dto.UserPaginationResponse{
 		Data: datas,
 		PaginationResponse: dto.PaginationResponse{
-			Page:    dataWithPaginate.Page,
-			PerPage: dataWithPaginate.PerPage,
-			MaxPage: dataWithPaginate.MaxPage,
-			Count:   dataWithPaginate.Count,
+			Page:       dataWithPaginate.Page,
+			PerPage:    dataWithPaginate.PerPage,
+			MaxPage:    dataWithPaginate.MaxPage,
+			Count:      dataWithPaginate.Count,
+			HasMore:    dataWithPaginate.HasMore,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
 
 func (s *userService) GetUserById(ctx context.Context, userId string) (dto.UserResponse, error) {
-	user, err := s.userRepo.GetUserById(ctx, userId)
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
 	if err != nil {
 		return dto.UserResponse{}, dto.ErrGetUserById
 	}
@@ -271,7 +284,7 @@ func (s *userService) GetUserById(ctx context.Context, userId string) (dto.UserR
 }
 
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (dto.UserResponse, error) {
-	emails, err := s.userRepo.GetUserByEmail(ctx, email)
+	emails, err := s.userRepo.GetUserByEmail(ctx, nil, email)
 	if err != nil {
 		return dto.UserResponse{}, dto.ErrGetUserByEmail
 	}
@@ -288,7 +301,7 @@ func (s *userService) GetUserByEmail(ctx context.Context, email string) (dto.Use
 }
 
 func (s *userService) UpdateUser(ctx context.Context, req dto.UserUpdateRequest, userId string) (dto.UserUpdateResponse, error) {
-	user, err := s.userRepo.GetUserById(ctx, userId)
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
 	if err != nil {
 		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
 	}
@@ -301,7 +314,7 @@ func (s *userService) UpdateUser(ctx context.Context, req dto.UserUpdateRequest,
 		Email:      req.Email,
 	}
 
-	userUpdate, err := s.userRepo.UpdateUser(ctx, data)
+	userUpdate, err := s.userRepo.UpdateUser(ctx, nil, data)
 	if err != nil {
 		return dto.UserUpdateResponse{}, dto.ErrUpdateUser
 	}
@@ -317,12 +330,12 @@ func (s *userService) UpdateUser(ctx context.Context, req dto.UserUpdateRequest,
 }
 
 func (s *userService) DeleteUser(ctx context.Context, userId string) error {
-	user, err := s.userRepo.GetUserById(ctx, userId)
+	user, err := s.userRepo.GetUserById(ctx, nil, userId)
 	if err != nil {
 		return dto.ErrUserNotFound
 	}
 
-	err = s.userRepo.DeleteUser(ctx, user.ID.String())
+	err = s.userRepo.DeleteUser(ctx, nil, user.ID.String())
 	if err != nil {
 		return dto.ErrDeleteUser
 	}
@@ -331,7 +344,7 @@ func (s *userService) DeleteUser(ctx context.Context, userId string) error {
 }
 
 func (s *userService) Verify(ctx context.Context, req dto.UserLoginRequest) (dto.UserLoginResponse, error) {
-	check, flag, err := s.userRepo.CheckEmail(ctx, req.Email)
+	check, flag, err := s.userRepo.CheckEmail(ctx, nil, req.Email)
 	if err != nil || !flag {
 		return dto.UserLoginResponse{}, dto.ErrEmailNotFound
 	}
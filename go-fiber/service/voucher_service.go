@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+)
+
+type (
+	VoucherService interface {
+		CreateVoucher(ctx context.Context, req dto.VoucherCreateRequest) (dto.VoucherResponse, error)
+		GetAllVouchersWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.VoucherPaginationResponse, error)
+		GetVoucherById(ctx context.Context, voucherId string) (dto.VoucherResponse, error)
+		UpdateVoucher(ctx context.Context, req dto.VoucherUpdateRequest, voucherId string) (dto.VoucherResponse, error)
+		DeleteVoucher(ctx context.Context, voucherId string) error
+	}
+
+	voucherService struct {
+		voucherRepo repository.VoucherRepository
+		eventRepo   repository.EventRepository
+		tierRepo    repository.TicketTierRepository
+	}
+)
+
+func NewVoucherService(voucherRepo repository.VoucherRepository, eventRepo repository.EventRepository, tierRepo repository.TicketTierRepository) VoucherService {
+	return &voucherService{
+		voucherRepo: voucherRepo,
+		eventRepo:   eventRepo,
+		tierRepo:    tierRepo,
+	}
+}
+
+func (s *voucherService) CreateVoucher(ctx context.Context, req dto.VoucherCreateRequest) (dto.VoucherResponse, error) {
+	voucher := entity.Voucher{
+		Code:                  normalizeVoucherCode(req.Code),
+		Description:           req.Description,
+		DiscountType:          req.DiscountType,
+		DiscountValue:         req.DiscountValue,
+		MaxRedemptions:        req.MaxRedemptions,
+		MaxRedemptionsPerUser: req.MaxRedemptionsPerUser,
+		ValidFrom:             req.ValidFrom,
+		ValidUntil:            req.ValidUntil,
+		Stackable:             req.Stackable,
+	}
+
+	if err := validateVoucher(voucher); err != nil {
+		return dto.VoucherResponse{}, err
+	}
+
+	// A tier scope implies the event of the tier.
+	if req.TierID != "" {
+		tier, err := s.tierRepo.GetTierById(ctx, nil, req.TierID)
+		if err != nil {
+			return dto.VoucherResponse{}, dto.ErrTierNotFound
+		}
+
+		if req.EventID != "" && req.EventID != tier.EventID {
+			return dto.VoucherResponse{}, dto.ErrTierNotFound
+		}
+
+		tierId := tier.ID.String()
+		voucher.TierID = &tierId
+		voucher.EventID = &tier.EventID
+	} else if req.EventID != "" {
+		event, err := s.eventRepo.GetEventById(ctx, nil, req.EventID)
+		if err != nil {
+			return dto.VoucherResponse{}, dto.ErrEventNotFound
+		}
+
+		eventId := event.ID.String()
+		voucher.EventID = &eventId
+	}
+
+	voucher, err := s.voucherRepo.CreateVoucher(ctx, nil, voucher)
+	if err != nil {
+		return dto.VoucherResponse{}, dto.ErrCreateVoucher
+	}
+
+	return toVoucherResponse(voucher), nil
+}
+
+func (s *voucherService) GetAllVouchersWithPagination(ctx context.Context, req dto.PaginationRequest) (dto.VoucherPaginationResponse, error) {
+	dataWithPaginate, err := s.voucherRepo.GetAllVouchersWithPagination(ctx, nil, req)
+	if err != nil {
+		return dto.VoucherPaginationResponse{}, err
+	}
+
+	datas := []dto.VoucherResponse{}
+	for _, voucher := range dataWithPaginate.Vouchers {
+		datas = append(datas, toVoucherResponse(voucher))
+	}
+
+	return dto.VoucherPaginationResponse{
+		Data: datas,
+		PaginationResponse: dto.PaginationResponse{
+			Page:    dataWithPaginate.Page,
+			PerPage: dataWithPaginate.PerPage,
+			MaxPage: dataWithPaginate.MaxPage,
+			Count:   dataWithPaginate.Count,
+		},
+	}, nil
+}
+
+func (s *voucherService) GetVoucherById(ctx context.Context, voucherId string) (dto.VoucherResponse, error) {
+	voucher, err := s.voucherRepo.GetVoucherById(ctx, nil, voucherId)
+	if err != nil {
+		return dto.VoucherResponse{}, dto.ErrVoucherNotFound
+	}
+
+	return toVoucherResponse(voucher), nil
+}
+
+func (s *voucherService) UpdateVoucher(ctx context.Context, req dto.VoucherUpdateRequest, voucherId string) (dto.VoucherResponse, error) {
+	voucher, err := s.voucherRepo.GetVoucherById(ctx, nil, voucherId)
+	if err != nil {
+		return dto.VoucherResponse{}, dto.ErrVoucherNotFound
+	}
+
+	if req.Description != nil {
+		voucher.Description = *req.Description
+	}
+
+	if req.DiscountType != nil {
+		voucher.DiscountType = *req.DiscountType
+	}
+
+	if req.DiscountValue != nil {
+		voucher.DiscountValue = *req.DiscountValue
+	}
+
+	if req.MaxRedemptions != nil {
+		voucher.MaxRedemptions = *req.MaxRedemptions
+	}
+
+	if req.MaxRedemptionsPerUser != nil {
+		voucher.MaxRedemptionsPerUser = *req.MaxRedemptionsPerUser
+	}
+
+	if req.ValidFrom != nil {
+		voucher.ValidFrom = req.ValidFrom
+	}
+
+	if req.ValidUntil != nil {
+		voucher.ValidUntil = req.ValidUntil
+	}
+
+	if req.Stackable != nil {
+		voucher.Stackable = *req.Stackable
+	}
+
+	if err := validateVoucher(voucher); err != nil {
+		return dto.VoucherResponse{}, err
+	}
+
+	voucher, err = s.voucherRepo.UpdateVoucher(ctx, nil, voucher)
+	if err != nil {
+		return dto.VoucherResponse{}, err
+	}
+
+	return toVoucherResponse(voucher), nil
+}
+
+func (s *voucherService) DeleteVoucher(ctx context.Context, voucherId string) error {
+	if _, err := s.voucherRepo.GetVoucherById(ctx, nil, voucherId); err != nil {
+		return dto.ErrVoucherNotFound
+	}
+
+	return s.voucherRepo.DeleteVoucher(ctx, nil, voucherId)
+}
+
+func validateVoucher(voucher entity.Voucher) error {
+	if voucher.Code == "" || voucher.DiscountValue <= 0 || voucher.MaxRedemptions < 0 || voucher.MaxRedemptionsPerUser < 0 {
+		return dto.ErrInvalidVoucher
+	}
+
+	switch voucher.DiscountType {
+	case constants.ENUM_VOUCHER_TYPE_PERCENTAGE:
+		if voucher.DiscountValue > 100 {
+			return dto.ErrInvalidVoucher
+		}
+	case constants.ENUM_VOUCHER_TYPE_FIXED:
+	default:
+		return dto.ErrInvalidVoucher
+	}
+
+	if voucher.ValidFrom != nil && voucher.ValidUntil != nil && !voucher.ValidUntil.After(*voucher.ValidFrom) {
+		return dto.ErrInvalidVoucherWindow
+	}
+
+	return nil
+}
+
+// checkVoucher reports why a voucher cannot be used on a purchase of the
+// given event and tier at t, or nil when it can.
+func checkVoucher(voucher entity.Voucher, eventId string, tierId string, t time.Time) error {
+	if voucher.ValidFrom != nil && t.Before(*voucher.ValidFrom) {
+		return dto.ErrVoucherNotActive
+	}
+
+	if voucher.ValidUntil != nil && !t.Before(*voucher.ValidUntil) {
+		return dto.ErrVoucherNotActive
+	}
+
+	if voucher.EventID != nil && *voucher.EventID != eventId {
+		return dto.ErrVoucherNotApplicable
+	}
+
+	if voucher.TierID != nil && *voucher.TierID != tierId {
+		return dto.ErrVoucherNotApplicable
+	}
+
+	return nil
+}
+
+// calculateDiscounts returns the discount every voucher gives on subtotal,
+// in the order the vouchers were given. Percentages are applied before
+// fixed amounts, each on what is left to pay, and the total never goes
+// below zero.
+func calculateDiscounts(subtotal int, vouchers []entity.Voucher) []int {
+	order := make([]int, len(vouchers))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return vouchers[order[a]].DiscountType == constants.ENUM_VOUCHER_TYPE_PERCENTAGE &&
+			vouchers[order[b]].DiscountType != constants.ENUM_VOUCHER_TYPE_PERCENTAGE
+	})
+
+	discounts := make([]int, len(vouchers))
+	remaining := subtotal
+	for _, i := range order {
+		discount := vouchers[i].DiscountValue
+		if vouchers[i].DiscountType == constants.ENUM_VOUCHER_TYPE_PERCENTAGE {
+			discount = remaining * vouchers[i].DiscountValue / 100
+		}
+
+		if discount > remaining {
+			discount = remaining
+		}
+
+		discounts[i] = discount
+		remaining -= discount
+	}
+
+	return discounts
+}
+
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func toVoucherResponse(voucher entity.Voucher) dto.VoucherResponse {
+	response := dto.VoucherResponse{
+		ID:                    voucher.ID.String(),
+		Code:                  voucher.Code,
+		Description:           voucher.Description,
+		DiscountType:          voucher.DiscountType,
+		DiscountValue:         voucher.DiscountValue,
+		MaxRedemptions:        voucher.MaxRedemptions,
+		MaxRedemptionsPerUser: voucher.MaxRedemptionsPerUser,
+		Redemptions:           voucher.Redemptions,
+		ValidFrom:             formatTimestamp(voucher.ValidFrom),
+		ValidUntil:            formatTimestamp(voucher.ValidUntil),
+		Stackable:             voucher.Stackable,
+	}
+
+	if voucher.EventID != nil {
+		response.EventID = *voucher.EventID
+	}
+
+	if voucher.TierID != nil {
+		response.TierID = *voucher.TierID
+	}
+
+	return response
+}
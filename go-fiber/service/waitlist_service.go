@@ -0,0 +1,406 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	WAITLIST_CLAIM_ROUTE = "waitlist/claim"
+)
+
+type (
+	WaitlistService interface {
+		JoinWaitlist(ctx context.Context, req dto.WaitlistJoinRequest) (dto.WaitlistEntryResponse, error)
+		GetWaitlistByUser(ctx context.Context, userId string) ([]dto.WaitlistEntryResponse, error)
+		LeaveWaitlist(ctx context.Context, entryId string, userId string) error
+		OfferSeats(ctx context.Context, eventId string) error
+		OfferFreedSeats(ctx context.Context) error
+		ExpireOffers(ctx context.Context, limit int) (int, error)
+	}
+
+	waitlistService struct {
+		waitlistRepo  repository.WaitlistRepository
+		eventRepo     repository.EventRepository
+		tierRepo      repository.TicketTierRepository
+		userRepo      repository.UserRepository
+		offerDuration time.Duration
+		db            *gorm.DB
+	}
+)
+
+func NewWaitlistService(waitlistRepo repository.WaitlistRepository, eventRepo repository.EventRepository, tierRepo repository.TicketTierRepository, userRepo repository.UserRepository, db *gorm.DB) WaitlistService {
+	return &waitlistService{
+		waitlistRepo:  waitlistRepo,
+		eventRepo:     eventRepo,
+		tierRepo:      tierRepo,
+		userRepo:      userRepo,
+		offerDuration: time.Duration(getEnvInt("WAITLIST_OFFER_MINUTES", constants.ENUM_WAITLIST_OFFER_MINUTES_DEFAULT)) * time.Minute,
+		db:            db,
+	}
+}
+
+// JoinWaitlist puts a user in line for a tier that cannot sell them the
+// quantity they want.
+func (s *waitlistService) JoinWaitlist(ctx context.Context, req dto.WaitlistJoinRequest) (dto.WaitlistEntryResponse, error) {
+	if req.Quantity <= 0 {
+		return dto.WaitlistEntryResponse{}, dto.ErrInvalidAmount
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, req.EventID)
+	if err != nil {
+		return dto.WaitlistEntryResponse{}, dto.ErrEventNotFound
+	}
+
+	if event.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+		return dto.WaitlistEntryResponse{}, dto.ErrEventNotOnSale
+	}
+
+	tier, err := selectTier(event, req.TierID)
+	if err != nil {
+		return dto.WaitlistEntryResponse{}, err
+	}
+
+	if tier.Availability >= req.Quantity {
+		return dto.WaitlistEntryResponse{}, dto.ErrSeatsStillAvailable
+	}
+
+	active, err := s.waitlistRepo.CountActiveEntries(ctx, nil, req.UserID, tier.ID.String())
+	if err != nil {
+		return dto.WaitlistEntryResponse{}, err
+	}
+
+	if active > 0 {
+		return dto.WaitlistEntryResponse{}, dto.ErrAlreadyOnWaitlist
+	}
+
+	entry, err := s.waitlistRepo.CreateEntry(ctx, nil, entity.WaitlistEntry{
+		EventID:  event.ID.String(),
+		TierID:   tier.ID.String(),
+		UserID:   req.UserID,
+		Quantity: req.Quantity,
+		Status:   constants.ENUM_WAITLIST_STATUS_WAITING,
+	})
+	if err != nil {
+		return dto.WaitlistEntryResponse{}, err
+	}
+
+	entry.Event = event
+	entry.Tier = tier
+	return s.toWaitlistEntryResponse(ctx, entry)
+}
+
+func (s *waitlistService) GetWaitlistByUser(ctx context.Context, userId string) ([]dto.WaitlistEntryResponse, error) {
+	entries, err := s.waitlistRepo.GetEntriesByUserId(ctx, nil, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.WaitlistEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		res, err := s.toWaitlistEntryResponse(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, res)
+	}
+
+	return responses, nil
+}
+
+// LeaveWaitlist takes a user out of line. Seats held for an open offer go
+// to the next entry right away.
+func (s *waitlistService) LeaveWaitlist(ctx context.Context, entryId string, userId string) error {
+	var offered bool
+	var eventId string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.waitlistRepo.GetEntryByIdForUpdate(ctx, tx, entryId)
+		if err != nil || entry.UserID != userId {
+			return dto.ErrWaitlistEntryNotFound
+		}
+
+		switch entry.Status {
+		case constants.ENUM_WAITLIST_STATUS_WAITING:
+		case constants.ENUM_WAITLIST_STATUS_OFFERED:
+			if err := s.returnSeats(ctx, tx, entry); err != nil {
+				return err
+			}
+			offered = true
+		default:
+			return dto.ErrWaitlistEntryNotActive
+		}
+
+		eventId = entry.EventID
+		entry.Status = constants.ENUM_WAITLIST_STATUS_CANCELLED
+		_, err = s.waitlistRepo.UpdateEntry(ctx, tx, entry)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if offered {
+		return s.OfferSeats(ctx, eventId)
+	}
+
+	return nil
+}
+
+// OfferSeats hands the free seats of an event to its waitlist, first come
+// first served per tier. An entry that does not fit in the seats left stops
+// its tier, so nobody behind it is served before it. The seats of every
+// offer are taken off sale and the users are emailed once the offers are
+// committed. Events that are not on sale make no offers.
+func (s *waitlistService) OfferSeats(ctx context.Context, eventId string) error {
+	var offers []entity.WaitlistEntry
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := s.eventRepo.GetEventByIdForUpdate(ctx, tx, eventId)
+		if err != nil {
+			return dto.ErrEventNotFound
+		}
+
+		if event.Status != constants.ENUM_EVENT_STATUS_PUBLISHED {
+			return nil
+		}
+
+		entries, err := s.waitlistRepo.GetWaitingEntriesByEventId(ctx, tx, eventId)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		tiers, err := s.tierRepo.GetTiersByEventId(ctx, tx, eventId)
+		if err != nil {
+			return err
+		}
+
+		available := make(map[string]int, len(tiers))
+		for _, tier := range tiers {
+			available[tier.ID.String()] = tier.Availability
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(s.offerDuration)
+		blocked := make(map[string]bool)
+
+		for _, entry := range entries {
+			if blocked[entry.TierID] {
+				continue
+			}
+
+			if available[entry.TierID] < entry.Quantity {
+				blocked[entry.TierID] = true
+				continue
+			}
+
+			ok, err := s.waitlistRepo.OfferEntry(ctx, tx, entry.ID.String(), now, expiresAt)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if err := s.takeSeats(ctx, tx, entry); err != nil {
+				return err
+			}
+
+			available[entry.TierID] -= entry.Quantity
+			entry.OfferExpiresAt = &expiresAt
+			offers = append(offers, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range offers {
+		if err := s.sendOfferEmail(ctx, entry); err != nil {
+			log.Printf("error sending waitlist offer %s: %v", entry.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// OfferFreedSeats offers seats on every event where seats are on sale while
+// people are still waiting for them. An event that fails is logged and
+// left for the next sweep.
+func (s *waitlistService) OfferFreedSeats(ctx context.Context) error {
+	eventIds, err := s.waitlistRepo.GetEventIdsWithFreeSeats(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, eventId := range eventIds {
+		if err := s.OfferSeats(ctx, eventId); err != nil {
+			log.Printf("failed to offer seats of event %s: %v", eventId, err)
+		}
+	}
+
+	return nil
+}
+
+// ExpireOffers closes up to limit offers that were not claimed in time and
+// passes their seats to the next entries in line. It reports how many offers
+// it closed. Every offer is closed in its own database transaction; one that
+// fails is logged and retried on the next sweep while the others go ahead.
+func (s *waitlistService) ExpireOffers(ctx context.Context, limit int) (int, error) {
+	entries, err := s.waitlistRepo.GetExpiredOffers(ctx, nil, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	events := make(map[string]bool)
+	for _, expired := range entries {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			entry, err := s.waitlistRepo.GetEntryByIdForUpdate(ctx, tx, expired.ID.String())
+			if err != nil {
+				return err
+			}
+
+			// The offer may have been claimed since the entries were read.
+			if entry.Status != constants.ENUM_WAITLIST_STATUS_OFFERED {
+				return nil
+			}
+
+			if err := s.returnSeats(ctx, tx, entry); err != nil {
+				return err
+			}
+
+			entry.Status = constants.ENUM_WAITLIST_STATUS_EXPIRED
+			_, err = s.waitlistRepo.UpdateEntry(ctx, tx, entry)
+			return err
+		})
+		if err != nil {
+			log.Printf("failed to expire waitlist offer %s: %v", expired.ID, err)
+			continue
+		}
+
+		closed++
+		events[expired.EventID] = true
+	}
+
+	for eventId := range events {
+		if err := s.OfferSeats(ctx, eventId); err != nil {
+			log.Printf("failed to offer seats of event %s: %v", eventId, err)
+		}
+	}
+
+	return closed, nil
+}
+
+// takeSeats and returnSeats move the seats of an offer off and back on sale,
+// event first and tier second like every purchase does.
+func (s *waitlistService) takeSeats(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) error {
+	if err := s.eventRepo.DecreaseAvailability(ctx, tx, entry.EventID, entry.Quantity); err != nil {
+		return err
+	}
+
+	return s.tierRepo.DecreaseTierAvailability(ctx, tx, entry.TierID, entry.Quantity)
+}
+
+func (s *waitlistService) returnSeats(ctx context.Context, tx *gorm.DB, entry entity.WaitlistEntry) error {
+	if err := s.eventRepo.IncreaseAvailability(ctx, tx, entry.EventID, entry.Quantity); err != nil {
+		return err
+	}
+
+	return s.tierRepo.IncreaseTierAvailability(ctx, tx, entry.TierID, entry.Quantity)
+}
+
+func (s *waitlistService) sendOfferEmail(ctx context.Context, entry entity.WaitlistEntry) error {
+	user, err := s.userRepo.GetUserById(ctx, nil, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, nil, entry.EventID)
+	if err != nil {
+		return err
+	}
+
+	tier, err := s.tierRepo.GetTierById(ctx, nil, entry.TierID)
+	if err != nil {
+		return err
+	}
+
+	readHtml, err := os.ReadFile("utils/email-template/waitlist_offer.html")
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Name      string
+		Event     string
+		Tier      string
+		Quantity  int
+		ExpiresAt string
+		Claim     string
+	}{
+		Name:      user.Name,
+		Event:     event.Name,
+		Tier:      tier.Name,
+		Quantity:  entry.Quantity,
+		ExpiresAt: entry.OfferExpiresAt.Format("2006-01-02 15:04:05 MST"),
+		Claim:     LOCAL_URL + "/" + WAITLIST_CLAIM_ROUTE + "?id=" + entry.ID.String(),
+	}
+
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, data); err != nil {
+		return err
+	}
+
+	return utils.SendMail(user.Email, fmt.Sprintf("Tickets for %s are waiting for you", event.Name), strMail.String())
+}
+
+func (s *waitlistService) toWaitlistEntryResponse(ctx context.Context, entry entity.WaitlistEntry) (dto.WaitlistEntryResponse, error) {
+	res := dto.WaitlistEntryResponse{
+		ID:             entry.ID.String(),
+		EventID:        entry.EventID,
+		EventName:      entry.Event.Name,
+		TierID:         entry.TierID,
+		TierName:       entry.Tier.Name,
+		Quantity:       entry.Quantity,
+		Status:         entry.Status,
+		CreatedAt:      entry.CreatedAt.String(),
+		OfferExpiresAt: formatTimestamp(entry.OfferExpiresAt),
+	}
+
+	if entry.OrderID != nil {
+		res.OrderID = *entry.OrderID
+	}
+
+	if entry.Status == constants.ENUM_WAITLIST_STATUS_WAITING {
+		ahead, err := s.waitlistRepo.CountEntriesAhead(ctx, nil, entry)
+		if err != nil {
+			return dto.WaitlistEntryResponse{}, err
+		}
+
+		res.Position = int(ahead) + 1
+	}
+
+	return res, nil
+}
@@ -0,0 +1,410 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/routes"
+	"github.com/tapeds/go-fiber-template/service"
+	"gorm.io/gorm"
+)
+
+func TestGetAllEvent_SearchesFiltersAndSorts(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "organizer", constants.ENUM_ROLE_ADMIN)
+
+	startsAt := time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(8 * time.Hour)
+	laterStartsAt := startsAt.AddDate(0, 1, 0)
+	laterEndsAt := laterStartsAt.Add(8 * time.Hour)
+
+	keyword := "kw" + uuid.NewString()[:8]
+	cheap := entity.Event{Name: keyword + " meetup", AuthorID: author.ID, Price: 1000, Capacity: 10, Availabilty: 2, StartsAt: &startsAt, EndsAt: &endsAt}
+	pricey := entity.Event{Name: "summit", Description: "the yearly " + keyword + " summit", AuthorID: author.ID, Price: 9000, Capacity: 10, Availabilty: 9, StartsAt: &laterStartsAt, EndsAt: &laterEndsAt}
+	soldOut := entity.Event{Name: keyword + " workshop", AuthorID: author.ID, Price: 5000, Capacity: 10, Availabilty: 0, StartsAt: &startsAt, EndsAt: &endsAt}
+	unrelated := entity.Event{Name: "concert", AuthorID: author.ID, Price: 1000, Capacity: 10, Availabilty: 10, StartsAt: &startsAt, EndsAt: &endsAt}
+	if err := db.Create(&[]*entity.Event{&cheap, &pricey, &soldOut, &unrelated}).Error; err != nil {
+		t.Fatalf("failed to create events: %v", err)
+	}
+
+	eventService := newEventTestService(db)
+
+	list := func(req dto.EventPaginationRequest) []string {
+		t.Helper()
+
+		req.Search = keyword
+		req.AuthorID = author.ID.String()
+		result, err := eventService.GetAllEventWithPagination(ctx, req)
+		if err != nil {
+			t.Fatalf("failed to list events: %v", err)
+		}
+		if int(result.Count) != len(result.Data) {
+			t.Errorf("expected the count %d to match the %d events listed", result.Count, len(result.Data))
+		}
+
+		var names []string
+		for _, event := range result.Data {
+			names = append(names, event.Name)
+		}
+		return names
+	}
+
+	if names := list(dto.EventPaginationRequest{HideSoldOut: true, Sort: "-" + constants.ENUM_EVENT_SORT_POPULARITY}); len(names) != 2 || names[0] != cheap.Name {
+		t.Errorf("expected the unsold matches, most popular first, got %v", names)
+	}
+
+	maxPrice := 5000
+	if names := list(dto.EventPaginationRequest{MaxPrice: &maxPrice, Sort: "-" + constants.ENUM_EVENT_SORT_PRICE}); len(names) != 2 || names[0] != soldOut.Name {
+		t.Errorf("expected the matches up to 5000, priciest first, got %v", names)
+	}
+
+	if names := list(dto.EventPaginationRequest{From: "2031-04-10", To: "2031-04-10"}); len(names) != 1 || names[0] != pricey.Name {
+		t.Errorf("expected only the summit on its day, got %v", names)
+	}
+
+	if _, err := eventService.GetAllEventWithPagination(ctx, dto.EventPaginationRequest{Sort: "name"}); err == nil {
+		t.Errorf("expected an unknown sort to be rejected")
+	}
+}
+
+func TestGetAllEvent_CursorPagesWithoutDuplicates(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	author := createTestUser(t, db, "organizer", constants.ENUM_ROLE_ADMIN)
+
+	// Created in one batch, so rows share created_at and the id breaks ties.
+	events := make([]entity.Event, 5)
+	for i := range events {
+		events[i] = entity.Event{Name: "paged event", AuthorID: author.ID, Price: 1000, Capacity: 10, Availabilty: 10}
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatalf("failed to create events: %v", err)
+	}
+
+	eventService := newEventTestService(db)
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(events) {
+			t.Fatalf("expected the pages to end, saw %d events", len(seen))
+		}
+
+		req := dto.EventPaginationRequest{AuthorID: author.ID.String()}
+		req.PerPage = 2
+		req.Cursor = &cursor
+		result, err := eventService.GetAllEventWithPagination(ctx, req)
+		if err != nil {
+			t.Fatalf("failed to list events: %v", err)
+		}
+
+		for _, event := range result.Data {
+			if seen[event.ID] {
+				t.Errorf("expected event %s only once", event.ID)
+			}
+			seen[event.ID] = true
+		}
+
+		// Events created while paging are newer than the cursor and do not
+		// shift the pages still to come.
+		if pages == 0 {
+			late := entity.Event{Name: "late event", AuthorID: author.ID, Price: 1000, Capacity: 10, Availabilty: 10}
+			if err := db.Create(&late).Error; err != nil {
+				t.Fatalf("failed to create event: %v", err)
+			}
+		}
+
+		if !result.HasMore {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	if len(seen) != len(events) {
+		t.Errorf("expected all %d events, got %d", len(events), len(seen))
+	}
+
+	tampered := cursor[:len(cursor)-1] + "0"
+	if tampered == cursor {
+		tampered = cursor[:len(cursor)-1] + "1"
+	}
+	req := dto.EventPaginationRequest{}
+	req.Cursor = &tampered
+	if _, err := eventService.GetAllEventWithPagination(ctx, req); !errors.Is(err, dto.ErrInvalidCursor) {
+		t.Errorf("expected a tampered cursor to be rejected, got %v", err)
+	}
+}
+
+func TestCancelEvent_RefundsPaidPurchasesInFull(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "cancel buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "cancelled event", AuthorID: buyer.ID, Price: 1000, Capacity: 5, Availabilty: 2, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	// The refund policy of the event does not apply to a cancellation.
+	if err := db.Model(&event).Update("refund_percentage", 0).Error; err != nil {
+		t.Fatalf("failed to set refund policy: %v", err)
+	}
+
+	paidOrder := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	pendingOrder := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PENDING}
+	if err := db.Create(&[]*entity.Order{&paidOrder, &pendingOrder}).Error; err != nil {
+		t.Fatalf("failed to create orders: %v", err)
+	}
+
+	paid := entity.Transaction{OrderID: paidOrder.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: buyer.ID.String(), Amount: 2, Subtotal: 2000, Total: 2000, Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	pending := entity.Transaction{OrderID: pendingOrder.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: buyer.ID.String(), Amount: 1, Subtotal: 1000, Total: 1000, Status: constants.ENUM_TRANSACTION_STATUS_PENDING}
+	if err := db.Create(&[]*entity.Transaction{&paid, &pending}).Error; err != nil {
+		t.Fatalf("failed to create transactions: %v", err)
+	}
+
+	transactionService := newTransactionTestService(db)
+
+	res, err := transactionService.CancelEvent(ctx, dto.EventCancelRequest{Reason: "venue closed"}, event.ID.String())
+	if err != nil {
+		t.Fatalf("failed to cancel event: %v", err)
+	}
+	if res.Event.Status != constants.ENUM_EVENT_STATUS_CANCELLED {
+		t.Errorf("expected the event to be cancelled, got %q", res.Event.Status)
+	}
+	if res.Cancelled != 2 || res.Refunded != 1 || res.Failed != 0 {
+		t.Errorf("expected 2 cancelled and 1 refunded purchase, got %+v", res)
+	}
+
+	var refund entity.Refund
+	if err := db.Take(&refund, "transaction_id = ?", paid.ID.String()).Error; err != nil {
+		t.Fatalf("failed to load refund: %v", err)
+	}
+	if refund.Amount != 2000 {
+		t.Errorf("expected a full refund of 2000, got %d", refund.Amount)
+	}
+
+	var transactions []entity.Transaction
+	if err := db.Where("buyer_id = ?", buyer.ID).Find(&transactions).Error; err != nil {
+		t.Fatalf("failed to reload transactions: %v", err)
+	}
+	for _, transaction := range transactions {
+		if transaction.Status != constants.ENUM_TRANSACTION_STATUS_CANCELLED {
+			t.Errorf("expected transaction %s to be cancelled, got %q", transaction.ID, transaction.Status)
+		}
+	}
+
+	var reloaded entity.TicketTier
+	if err := db.Take(&reloaded, "id = ?", tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloaded.Availability != 5 {
+		t.Errorf("expected every seat to be released, got availability %d", reloaded.Availability)
+	}
+
+	_, err = transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+		BuyerID: buyer.ID.String(),
+		Items:   []dto.OrderItemRequest{{EventID: event.ID.String(), Amount: 1}},
+	})
+	if !errors.Is(err, dto.ErrEventNotOnSale) {
+		t.Errorf("expected the cancelled event to be off sale, got %v", err)
+	}
+}
+
+// newEventTestApp serves the event routes, authenticating requests with the
+// returned JWT service.
+func newEventTestApp(db *gorm.DB) (*fiber.App, service.JWTService) {
+	jwtService := service.NewJWTService()
+	userService := service.NewUserService(repository.NewUserRepository(db), jwtService)
+	eventController := controller.NewEventController(newEventTestService(db), userService, newTransactionTestService(db))
+
+	app := fiber.New()
+	routes.Event(app, eventController, jwtService, service.NewIdempotencyService(repository.NewIdempotencyRepository(db)))
+
+	return app, jwtService
+}
+
+func putEvent(t *testing.T, app *fiber.App, jwtService service.JWTService, user entity.User, body string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPut, "/event", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+jwtService.GenerateToken(user.ID.String(), user.Role))
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	return res.StatusCode
+}
+
+func TestUpdateEvent_OnlyOrganizerOrAdmin(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_USER)
+	stranger := createTestUser(t, db, "stranger", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "guarded event", AuthorID: organizer.ID, Price: 1000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	app, jwtService := newEventTestApp(db)
+	body := `{"id":"` + event.ID.String() + `","name":"` + event.Name + `","transfers_disabled":true}`
+
+	transfersDisabled := func() bool {
+		t.Helper()
+
+		var reloaded entity.Event
+		if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+			t.Fatalf("failed to reload event: %v", err)
+		}
+		return reloaded.TransfersDisabled
+	}
+
+	if code := putEvent(t, app, jwtService, stranger, body); code != http.StatusForbidden {
+		t.Errorf("expected a non-organizer to be refused, got %d", code)
+	}
+	if transfersDisabled() {
+		t.Fatalf("expected the refused update to leave transfers enabled")
+	}
+
+	if code := putEvent(t, app, jwtService, organizer, body); code != http.StatusOK {
+		t.Fatalf("expected the organizer to update the event, got %d", code)
+	}
+	if !transfersDisabled() {
+		t.Errorf("expected the organizer to disable transfers")
+	}
+}
+
+func TestUpdateEvent_StrangerCannotChangeRefundPolicyLimitsOrSchedule(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_USER)
+	stranger := createTestUser(t, db, "stranger", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "guarded event", AuthorID: organizer.ID, Price: 1000, Capacity: 10, Availabilty: 10, Venue: "main hall", RefundPercentage: 100, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	app, jwtService := newEventTestApp(db)
+
+	body := `{"id":"` + event.ID.String() + `","name":"` + event.Name + `",` +
+		`"refund_percentage":0,"refund_deadline":"2000-01-01T00:00:00Z",` +
+		`"max_tickets_per_order":1,"max_tickets_per_user":1,` +
+		`"starts_at":"2031-01-01T09:00:00Z","ends_at":"2031-01-01T17:00:00Z","venue":"elsewhere"}`
+	if code := putEvent(t, app, jwtService, stranger, body); code != http.StatusForbidden {
+		t.Fatalf("expected a non-organizer to be refused, got %d", code)
+	}
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.RefundPercentage != 100 || reloaded.RefundDeadline != nil {
+		t.Errorf("expected the refund policy to be unchanged, got %d%% until %v", reloaded.RefundPercentage, reloaded.RefundDeadline)
+	}
+	if reloaded.MaxTicketsPerOrder != 0 || reloaded.MaxTicketsPerUser != 0 {
+		t.Errorf("expected the purchase limits to be unchanged, got %d per order and %d per user", reloaded.MaxTicketsPerOrder, reloaded.MaxTicketsPerUser)
+	}
+	if reloaded.StartsAt != nil || reloaded.EndsAt != nil || reloaded.Venue != "main hall" {
+		t.Errorf("expected the schedule to be unchanged, got %v to %v at %q", reloaded.StartsAt, reloaded.EndsAt, reloaded.Venue)
+	}
+}
+
+func TestUpdateEvent_LeavesTiersAloneWithoutPriceOrCapacity(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_ADMIN)
+	single := createTestEvent(t, db, entity.Event{Name: "single tier event", AuthorID: organizer.ID, Price: 1000, Capacity: 10, Availabilty: 10})
+	tier := createTestTier(t, db, single)
+
+	multi := createTestEvent(t, db, entity.Event{Name: "multi tier event", AuthorID: organizer.ID, Price: 1000, Capacity: 15, Availabilty: 15})
+	regular := entity.TicketTier{EventID: multi.ID.String(), Name: "Regular", Price: 1000, Capacity: 10, Availability: 10}
+	vip := entity.TicketTier{EventID: multi.ID.String(), Name: "VIP", Price: 5000, Capacity: 5, Availability: 5}
+	if err := db.Create(&[]*entity.TicketTier{&regular, &vip}).Error; err != nil {
+		t.Fatalf("failed to create tiers: %v", err)
+	}
+
+	eventService := newEventTestService(db)
+
+	if _, err := eventService.UpdateEvent(ctx, dto.EventUpdateRequest{Name: "renamed single tier event"}, single.ID.String()); err != nil {
+		t.Fatalf("failed to rename the single tier event: %v", err)
+	}
+
+	var reloaded entity.TicketTier
+	if err := db.Take(&reloaded, "id = ?", tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloaded.Price != 1000 || reloaded.Capacity != 10 || reloaded.Availability != 10 {
+		t.Errorf("expected the tier to be unchanged, got price %d, capacity %d, availability %d", reloaded.Price, reloaded.Capacity, reloaded.Availability)
+	}
+
+	if _, err := eventService.UpdateEvent(ctx, dto.EventUpdateRequest{Name: "renamed multi tier event"}, multi.ID.String()); err != nil {
+		t.Errorf("expected the multi tier event to be renamed, got %v", err)
+	}
+
+	capacity := 20
+	if _, err := eventService.UpdateEvent(ctx, dto.EventUpdateRequest{Name: multi.Name, Capacity: &capacity}, multi.ID.String()); !errors.Is(err, dto.ErrEventHasTiers) {
+		t.Errorf("expected a capacity change on a multi tier event to be refused, got %v", err)
+	}
+}
+
+func TestGetTiers_HidesUnpublishedEventsFromUsers(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	admin := createTestUser(t, db, "organizer", constants.ENUM_ROLE_ADMIN)
+	user := createTestUser(t, db, "visitor", constants.ENUM_ROLE_USER)
+	draft := createTestEvent(t, db, entity.Event{Name: "draft event", AuthorID: admin.ID, Price: 1000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_DRAFT})
+	createTestTier(t, db, draft)
+	published := createTestEvent(t, db, entity.Event{Name: "published event", AuthorID: admin.ID, Price: 1000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, published)
+
+	jwtService := service.NewJWTService()
+	userService := service.NewUserService(repository.NewUserRepository(db), jwtService)
+	tierService := service.NewTicketTierService(repository.NewTicketTierRepository(db), repository.NewEventRepository(db), newWaitlistTestService(db), db)
+
+	app := fiber.New()
+	routes.TicketTier(app, controller.NewTicketTierController(tierService, userService), jwtService, service.NewIdempotencyService(repository.NewIdempotencyRepository(db)))
+
+	getTiers := func(event entity.Event, caller entity.User) (int, string) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/event/"+event.ID.String()+"/tier", nil)
+		req.Header.Set("Authorization", "Bearer "+jwtService.GenerateToken(caller.ID.String(), caller.Role))
+
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("failed to get tiers: %v", err)
+		}
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return res.StatusCode, string(body)
+	}
+
+	if code, body := getTiers(draft, user); code != http.StatusBadRequest || !strings.Contains(body, dto.ErrEventNotFound.Error()) {
+		t.Errorf("expected the draft to look missing to a user, got %d %s", code, body)
+	}
+	if _, missing := getTiers(entity.Event{ID: uuid.New()}, user); !strings.Contains(missing, dto.ErrEventNotFound.Error()) {
+		t.Errorf("expected a missing event to be not found, got %s", missing)
+	}
+
+	if code, _ := getTiers(draft, admin); code != http.StatusOK {
+		t.Errorf("expected an admin to see the draft's tiers, got %d", code)
+	}
+	if code, _ := getTiers(published, user); code != http.StatusOK {
+		t.Errorf("expected a user to see the published event's tiers, got %d", code)
+	}
+}
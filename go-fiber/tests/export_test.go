@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+func TestExportAttendees_ListsHoldersWithCheckInStatus(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_USER)
+	buyer := createTestUser(t, db, "attendee", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "export event", AuthorID: organizer.ID, Price: 1000, Capacity: 3, Availabilty: 0})
+	tier := createTestTier(t, db, event)
+
+	order := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transaction := entity.Transaction{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: buyer.ID.String(), Amount: 3, Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	tickets := []entity.Ticket{
+		{TransactionID: transaction.ID.String(), EventID: event.ID.String(), OwnerID: buyer.ID.String(), Code: uuid.NewString(), Status: constants.ENUM_TICKET_STATUS_USED},
+		{TransactionID: transaction.ID.String(), EventID: event.ID.String(), OwnerID: buyer.ID.String(), Code: uuid.NewString(), Status: constants.ENUM_TICKET_STATUS_ACTIVE},
+		{TransactionID: transaction.ID.String(), EventID: event.ID.String(), OwnerID: buyer.ID.String(), Code: uuid.NewString(), Status: constants.ENUM_TICKET_STATUS_VOID},
+	}
+	if err := db.Create(&tickets).Error; err != nil {
+		t.Fatalf("failed to create tickets: %v", err)
+	}
+
+	exportService := service.NewExportService(
+		repository.NewUserRepository(db),
+		repository.NewEventRepository(db),
+		repository.NewTransactionRepository(db),
+		repository.NewTicketRepository(db),
+	)
+
+	if _, err := exportService.ExportAttendees(ctx, event.ID.String(), dto.ExportRequest{AuthorID: buyer.ID.String()}); !errors.Is(err, dto.ErrEventNotFound) {
+		t.Errorf("expected the event to be hidden from other users, got %v", err)
+	}
+
+	export, err := exportService.ExportAttendees(ctx, event.ID.String(), dto.ExportRequest{AuthorID: organizer.ID.String()})
+	if err != nil {
+		t.Fatalf("failed to start export: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := export(ctx, &buf); err != nil {
+		t.Fatalf("failed to export attendees: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected a header and one attendee, got %v", rows)
+	}
+
+	want := []string{buyer.Name, buyer.Email, "2", "1", constants.ENUM_ATTENDEE_PARTIAL}
+	for i, value := range want {
+		if rows[1][i] != value {
+			t.Errorf("expected column %s to be %q, got %q", rows[0][i], value, rows[1][i])
+		}
+	}
+}
+
+func TestEscapeCell_QuotesFormulas(t *testing.T) {
+	for value, want := range map[string]string{
+		"=SUM(A1:A2)": "'=SUM(A1:A2)",
+		"+1":          "'+1",
+		"-1":          "'-1",
+		"@cmd":        "'@cmd",
+		"\t=1":        "'\t=1",
+		"plain name":  "plain name",
+		"a=b":         "a=b",
+		"":            "",
+	} {
+		if got := utils.EscapeCell(value); got != want {
+			t.Errorf("expected %q to be written as %q, got %q", value, want, got)
+		}
+	}
+}
+
+func TestExportEvents_AppliesListFilters(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	organizer := createTestUser(t, db, "filtered organizer", constants.ENUM_ROLE_USER)
+	pricey := createTestEvent(t, db, entity.Event{Name: "=HYPERLINK(\"http://evil.test\")", AuthorID: organizer.ID, Price: 1000, Capacity: 5, Availabilty: 5})
+	createTestEvent(t, db, entity.Event{Name: "cheap export event", AuthorID: organizer.ID, Price: 100, Capacity: 5, Availabilty: 5})
+
+	exportService := service.NewExportService(
+		repository.NewUserRepository(db),
+		repository.NewEventRepository(db),
+		repository.NewTransactionRepository(db),
+		repository.NewTicketRepository(db),
+	)
+
+	minPrice := 500
+	req := dto.EventExportRequest{}
+	req.AuthorID = organizer.ID.String()
+	req.MinPrice = &minPrice
+
+	export, err := exportService.ExportEvents(ctx, req)
+	if err != nil {
+		t.Fatalf("failed to start export: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := export(ctx, &buf); err != nil {
+		t.Fatalf("failed to export events: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected a header and the event above the minimum price, got %v", rows)
+	}
+	if rows[1][0] != pricey.ID.String() {
+		t.Errorf("expected event %s, got %s", pricey.ID, rows[1][0])
+	}
+	if rows[1][1] != "'"+pricey.Name {
+		t.Errorf("expected the formula in the name to be escaped, got %q", rows[1][1])
+	}
+
+	req.When = "someday"
+	if _, err := exportService.ExportEvents(ctx, req); !errors.Is(err, dto.ErrInvalidEventWhen) {
+		t.Errorf("expected an invalid filter to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/middleware"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/service"
+	"gorm.io/gorm"
+)
+
+// newIdempotencyTestApp serves POST /purchase behind the idempotency
+// middleware for userId. The handler counts its calls and answers with the
+// count, waiting for release first when it is not nil.
+func newIdempotencyTestApp(db *gorm.DB, userId string, calls *int32, entered chan<- struct{}, release <-chan struct{}) *fiber.App {
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db))
+
+	app := fiber.New()
+	app.Post("/purchase", func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", userId)
+		return ctx.Next()
+	}, middleware.Idempotency(idempotencyService), func(ctx *fiber.Ctx) error {
+		call := atomic.AddInt32(calls, 1)
+		if entered != nil {
+			entered <- struct{}{}
+		}
+		if release != nil {
+			<-release
+		}
+		return ctx.Status(http.StatusCreated).JSON(fiber.Map{"call": call})
+	})
+
+	return app
+}
+
+func postIdempotent(t *testing.T, app *fiber.App, key string, body string) (int, string, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/purchase", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("failed to post: %v", err)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	return res.StatusCode, string(data), res.Header.Get("Idempotent-Replayed")
+}
+
+func TestIdempotency_ReplaysTheFirstResponse(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	userId := uuid.NewString()
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", userId).Delete(&entity.IdempotencyKey{})
+	})
+
+	var calls int32
+	app := newIdempotencyTestApp(db, userId, &calls, nil, nil)
+
+	firstStatus, firstBody, _ := postIdempotent(t, app, "replay-key", `{"amount":1}`)
+	if firstStatus != http.StatusCreated {
+		t.Fatalf("expected the first request to run, got %d", firstStatus)
+	}
+
+	status, body, replayed := postIdempotent(t, app, "replay-key", `{"amount":1}`)
+	if status != firstStatus || body != firstBody || replayed != "true" {
+		t.Errorf("expected the first response to be replayed, got %d %s (replayed %q)", status, body, replayed)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+
+	// The same body under a new key is a new request.
+	status, _, _ = postIdempotent(t, app, "other-key", `{"amount":1}`)
+	if calls := atomic.LoadInt32(&calls); status != http.StatusCreated || calls != 2 {
+		t.Errorf("expected a new key to run the handler again, got %d after %d calls", status, calls)
+	}
+}
+
+func TestIdempotency_RejectsAReusedKeyWithAnotherBody(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	userId := uuid.NewString()
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", userId).Delete(&entity.IdempotencyKey{})
+	})
+
+	var calls int32
+	app := newIdempotencyTestApp(db, userId, &calls, nil, nil)
+
+	if status, _, _ := postIdempotent(t, app, "reused-key", `{"amount":1}`); status != http.StatusCreated {
+		t.Fatalf("expected the first request to run, got %d", status)
+	}
+
+	if status, _, _ := postIdempotent(t, app, "reused-key", `{"amount":2}`); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected a different body to be rejected with 422, got %d", status)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+}
+
+func TestIdempotency_RejectsARetryWhileTheFirstRuns(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	userId := uuid.NewString()
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", userId).Delete(&entity.IdempotencyKey{})
+	})
+
+	var calls int32
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	app := newIdempotencyTestApp(db, userId, &calls, entered, release)
+
+	done := make(chan int, 1)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/purchase", strings.NewReader(`{"amount":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "running-key")
+
+		res, err := app.Test(req, -1)
+		if err != nil {
+			done <- 0
+			return
+		}
+		done <- res.StatusCode
+	}()
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first request never reached the handler")
+	}
+
+	status, _, _ := postIdempotent(t, app, "running-key", `{"amount":1}`)
+	close(release)
+
+	if status != http.StatusConflict {
+		t.Errorf("expected a retry of a running request to be rejected with 409, got %d", status)
+	}
+
+	if first := <-done; first != http.StatusCreated {
+		t.Errorf("expected the first request to finish, got %d", first)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+}
+
+func TestExpireKeys_DeletesKeysPastTheirTTL(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	userId := uuid.NewString()
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", userId).Delete(&entity.IdempotencyKey{})
+	})
+
+	for i, expiresAt := range []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-time.Minute), time.Now().Add(time.Hour)} {
+		key := entity.IdempotencyKey{
+			UserID:      userId,
+			Key:         "ttl-key-" + strconv.Itoa(i),
+			Method:      http.MethodPost,
+			Path:        "/purchase",
+			Fingerprint: "fingerprint",
+			StatusCode:  http.StatusCreated,
+			ExpiresAt:   expiresAt,
+		}
+		if err := db.Create(&key).Error; err != nil {
+			t.Fatalf("failed to create key: %v", err)
+		}
+	}
+
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db))
+
+	// Keys of other tests may have expired too, so the sweep runs until the
+	// table holds no expired keys.
+	for {
+		deleted, err := idempotencyService.ExpireKeys(ctx, 100)
+		if err != nil {
+			t.Fatalf("failed to expire keys: %v", err)
+		}
+		if deleted < 100 {
+			break
+		}
+	}
+
+	var keys []entity.IdempotencyKey
+	if err := db.Where("user_id = ?", userId).Find(&keys).Error; err != nil {
+		t.Fatalf("failed to load keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Key != "ttl-key-2" {
+		t.Errorf("expected only the live key to remain, got %+v", keys)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+func TestNextInvoiceNumber_ConcurrentPaymentsGetConsecutiveNumbers(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	const (
+		year     = 2999
+		payments = 50
+	)
+
+	db.Where("year = ?", year).Delete(&entity.InvoiceSequence{})
+	t.Cleanup(func() {
+		db.Where("year = ?", year).Delete(&entity.InvoiceSequence{})
+	})
+
+	invoiceRepo := repository.NewInvoiceRepository(db)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		numbers = map[int]bool{}
+		start   = make(chan struct{})
+	)
+
+	for i := 0; i < payments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				number, err := invoiceRepo.NextInvoiceNumber(ctx, tx, year)
+				if err != nil {
+					return err
+				}
+
+				mu.Lock()
+				numbers[number] = true
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("failed to get invoice number: %v", err)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	for i := 1; i <= payments; i++ {
+		if !numbers[i] {
+			t.Fatalf("expected invoice number %d to be handed out, got %v", i, numbers)
+		}
+	}
+}
+
+func TestInvoice_KeepsWhatWasBilled(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "invoice buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "invoiced event", AuthorID: buyer.ID, Price: 1000, Capacity: 5, Availabilty: 5, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	payload := []byte(`{"reference":"` + result.PaymentReference + `","status":"` + constants.ENUM_PAYMENT_STATUS_PAID + `"}`)
+	if err := transactionService.HandlePaymentWebhook(ctx, constants.ENUM_PAYMENT_PROVIDER_MOCK, payload, utils.SignHMAC(testPaymentSecret, payload)); err != nil {
+		t.Fatalf("failed to pay transaction: %v", err)
+	}
+
+	// A partial refund and a renamed event leave the invoice as billed.
+	if _, err := transactionService.RefundTransaction(ctx, dto.TransactionRefundRequest{Quantity: 1}, result.ID); err != nil {
+		t.Fatalf("failed to refund transaction: %v", err)
+	}
+	db.Model(&event).Update("name", "renamed event")
+
+	var invoice entity.Invoice
+	if err := db.Take(&invoice, "transaction_id = ?", result.ID).Error; err != nil {
+		t.Fatalf("failed to load invoice: %v", err)
+	}
+
+	if invoice.Item != "invoiced event - "+constants.ENUM_TICKET_TIER_DEFAULT {
+		t.Errorf("expected the item billed at payment, got %q", invoice.Item)
+	}
+	if invoice.Quantity != 3 || invoice.UnitPrice != 1000 || invoice.Subtotal != 3000 || invoice.Total != result.Total {
+		t.Errorf("expected 3 tickets at 1000 totalling %d, got %d at %d, subtotal %d, total %d",
+			result.Total, invoice.Quantity, invoice.UnitPrice, invoice.Subtotal, invoice.Total)
+	}
+
+	if _, _, err := transactionService.GetTransactionInvoice(ctx, result.ID); err != nil {
+		t.Errorf("failed to render invoice: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+)
+
+// countQueries counts the SELECTs run through db, preloads included.
+func countQueries(t *testing.T, db *gorm.DB) *atomic.Int64 {
+	t.Helper()
+
+	var queries atomic.Int64
+	count := func(*gorm.DB) { queries.Add(1) }
+	if err := db.Callback().Query().After("gorm:query").Register("tests:count_queries", count); err != nil {
+		t.Fatalf("failed to register query counter: %v", err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("tests:count_rows", count); err != nil {
+		t.Fatalf("failed to register row counter: %v", err)
+	}
+
+	return &queries
+}
+
+func TestListEndpoints_QueryCountDoesNotGrowWithRows(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	const rows = 10
+
+	buyer := createTestUser(t, db, "list buyer", constants.ENUM_ROLE_USER)
+
+	events := make([]entity.Event, rows)
+	tiers := make([]entity.TicketTier, rows)
+	for i := range events {
+		events[i] = createTestEvent(t, db, entity.Event{Name: "listed event", AuthorID: buyer.ID, Price: 1000, Capacity: 10, Availabilty: 10})
+		tiers[i] = createTestTier(t, db, events[i])
+	}
+
+	order := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transactions := make([]entity.Transaction, rows)
+	for i, event := range events {
+		transactions[i] = entity.Transaction{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tiers[i].ID.String(), BuyerID: buyer.ID.String(), Amount: 1, Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to create transactions: %v", err)
+	}
+
+	transactionService := newTransactionTestService(db)
+	eventService := newEventTestService(db)
+
+	queries := countQueries(t, db)
+
+	// A count, the page, then one query per preloaded relation.
+	transactionReq := dto.TransactionPaginationRequest{BuyerID: buyer.ID.String()}
+	transactionReq.PerPage = rows
+	transactionPage, err := transactionService.GetAllTransactionsWithPagination(ctx, transactionReq)
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(transactionPage.Data) != rows || transactionPage.Data[0].EventName == "" || transactionPage.Data[0].BuyerName == "" || transactionPage.Data[0].TierName == "" {
+		t.Fatalf("expected %d transactions with their details, got %+v", rows, transactionPage.Data)
+	}
+	if got := queries.Swap(0); got > 5 {
+		t.Errorf("expected listing transactions to take at most 5 queries, took %d", got)
+	}
+
+	eventReq := dto.EventPaginationRequest{AuthorID: buyer.ID.String()}
+	eventReq.PerPage = rows
+	eventPage, err := eventService.GetAllEventWithPagination(ctx, eventReq)
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(eventPage.Data) != rows || eventPage.Data[0].AuthorName != buyer.Name {
+		t.Fatalf("expected %d events with their author, got %+v", rows, eventPage.Data)
+	}
+	if got := queries.Swap(0); got > 4 {
+		t.Errorf("expected listing events to take at most 4 queries, took %d", got)
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/routes"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func TestCreateOrder_ReservesAllItemsOrNone(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "order buyer", constants.ENUM_ROLE_USER)
+	conference := createTestEvent(t, db, entity.Event{Name: "conference", AuthorID: buyer.ID, Price: 3000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	workshop := createTestEvent(t, db, entity.Event{Name: "workshop", AuthorID: buyer.ID, Price: 1000, Capacity: 1, Availabilty: 1, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+
+	pass := entity.TicketTier{EventID: conference.ID.String(), Name: "Pass", Price: 3000, Capacity: 10, Availability: 10}
+	seat := entity.TicketTier{EventID: workshop.ID.String(), Name: "Seat", Price: 1000, Capacity: 1, Availability: 1}
+	if err := db.Create(&[]*entity.TicketTier{&pass, &seat}).Error; err != nil {
+		t.Fatalf("failed to create tiers: %v", err)
+	}
+
+	transactionService := newTransactionTestService(db)
+
+	// The workshop only has one seat, so the whole order must be rejected
+	// and the conference passes must not stay reserved.
+	_, err := transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+		BuyerID: buyer.ID.String(),
+		Items: []dto.OrderItemRequest{
+			{EventID: conference.ID.String(), Amount: 2},
+			{EventID: workshop.ID.String(), Amount: 2},
+		},
+	})
+	if !errors.Is(err, dto.ErrInsufficientAvailability) {
+		t.Fatalf("expected the order to be rejected, got %v", err)
+	}
+
+	var reloaded entity.TicketTier
+	if err := db.Take(&reloaded, "id = ?", pass.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloaded.Availability != 10 {
+		t.Errorf("expected conference passes to be released, got availability %d", reloaded.Availability)
+	}
+
+	order, err := transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+		BuyerID: buyer.ID.String(),
+		Items: []dto.OrderItemRequest{
+			{EventID: conference.ID.String(), Amount: 2},
+			{EventID: workshop.ID.String(), Amount: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	if len(order.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(order.Items))
+	}
+	if order.Total != 7000 {
+		t.Errorf("expected order total 7000, got %d", order.Total)
+	}
+	for _, item := range order.Items {
+		if item.PaymentReference != order.PaymentReference {
+			t.Errorf("expected item %s to share the order payment", item.ID)
+		}
+	}
+}
+
+func TestCreateOrder_RejectsEndedEvent(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "late buyer", constants.ENUM_ROLE_USER)
+
+	startsAt := time.Now().Add(-3 * time.Hour)
+	endsAt := time.Now().Add(-time.Hour)
+	event := createTestEvent(t, db, entity.Event{Name: "yesterday's meetup", AuthorID: buyer.ID, Price: 1000, Capacity: 10, Availabilty: 10, StartsAt: &startsAt, EndsAt: &endsAt, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	_, err := transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+		BuyerID: buyer.ID.String(),
+		Items:   []dto.OrderItemRequest{{EventID: event.ID.String(), Amount: 1}},
+	})
+	if !errors.Is(err, dto.ErrEventEnded) {
+		t.Fatalf("expected the order to be rejected, got %v", err)
+	}
+
+	var reloaded entity.TicketTier
+	if err := db.Take(&reloaded, "id = ?", tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloaded.Availability != 10 {
+		t.Errorf("expected no seats to be taken, got availability %d", reloaded.Availability)
+	}
+}
+
+func TestGetOrder_OtherBuyersOrdersAreNotFound(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "order buyer", constants.ENUM_ROLE_USER)
+	stranger := createTestUser(t, db, "stranger", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "private order event", AuthorID: buyer.ID, Price: 1000, Capacity: 5, Availabilty: 5, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	order, err := transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+		BuyerID: buyer.ID.String(),
+		Items:   []dto.OrderItemRequest{{EventID: event.ID.String(), Amount: 1}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	jwtService := service.NewJWTService()
+	userService := service.NewUserService(repository.NewUserRepository(db), jwtService)
+
+	app := fiber.New()
+	routes.Order(app, controller.NewOrderController(transactionService, userService), jwtService, service.NewIdempotencyService(repository.NewIdempotencyRepository(db)))
+
+	request := func(method string, path string, user entity.User) int {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+jwtService.GenerateToken(user.ID.String(), user.Role))
+
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("failed to request %s: %v", path, err)
+		}
+		return res.StatusCode
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/order/" + order.ID},
+		{http.MethodGet, "/order/" + order.ID + "/receipt"},
+		{http.MethodPost, "/order/" + order.ID + "/cancel"},
+		{http.MethodGet, "/order/" + uuid.NewString()},
+	} {
+		if code := request(tc.method, tc.path, stranger); code != http.StatusNotFound {
+			t.Errorf("expected %s %s to be not found for another buyer, got %d", tc.method, tc.path, code)
+		}
+	}
+
+	if code := request(http.MethodGet, "/order/"+order.ID, buyer); code != http.StatusOK {
+		t.Errorf("expected the buyer to see their order, got %d", code)
+	}
+
+	var reloaded entity.Order
+	if err := db.Take(&reloaded, "id = ?", order.ID).Error; err != nil {
+		t.Fatalf("failed to reload order: %v", err)
+	}
+	if reloaded.Status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+		t.Errorf("expected another buyer's cancel to leave the order pending, got %q", reloaded.Status)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+func TestPaginationResponse_SetLinks(t *testing.T) {
+	meta := dto.PaginationResponse{Page: 2, PerPage: 10, MaxPage: 3, Count: 25}
+	meta.SetLinks("http://localhost:8888/api/event?search=conf&page=2")
+
+	if !meta.HasMore {
+		t.Errorf("expected more pages after page 2 of 3")
+	}
+
+	next, err := url.Parse(meta.Next)
+	if err != nil {
+		t.Fatalf("failed to parse next link %q: %v", meta.Next, err)
+	}
+	if next.Path != "/api/event" || next.Query().Get("page") != "3" || next.Query().Get("search") != "conf" {
+		t.Errorf("expected next to keep the filters and point at page 3, got %q", meta.Next)
+	}
+
+	prev, err := url.Parse(meta.Prev)
+	if err != nil {
+		t.Fatalf("failed to parse prev link %q: %v", meta.Prev, err)
+	}
+	if prev.Query().Get("page") != "1" || prev.Query().Get("per_page") != "10" {
+		t.Errorf("expected prev to point at page 1, got %q", meta.Prev)
+	}
+
+	last := dto.PaginationResponse{Page: 7, PerPage: 10, MaxPage: 3, Count: 25}
+	last.SetLinks("http://localhost:8888/api/event?page=7")
+	if last.HasMore || last.Next != "" {
+		t.Errorf("expected no next page past the end, got %q", last.Next)
+	}
+	if prev, _ := url.Parse(last.Prev); prev == nil || prev.Query().Get("page") != "3" {
+		t.Errorf("expected prev to go back to the last page, got %q", last.Prev)
+	}
+}
+
+func TestPaginationRequest_Validate(t *testing.T) {
+	if err := (&dto.PaginationRequest{Page: 1, PerPage: 101}).Validate(); !errors.Is(err, dto.ErrPerPageTooLarge) {
+		t.Errorf("expected per_page above the maximum to be rejected, got %v", err)
+	}
+
+	if err := (&dto.PaginationRequest{Page: -1}).Validate(); !errors.Is(err, dto.ErrInvalidPagination) {
+		t.Errorf("expected a negative page to be rejected, got %v", err)
+	}
+
+	if err := (&dto.PaginationRequest{}).Validate(); err != nil {
+		t.Errorf("expected defaults to be accepted, got %v", err)
+	}
+}
+
+func TestCursor_RejectsTampering(t *testing.T) {
+	createdAt := time.Date(2026, 10, 18, 9, 30, 0, 123456000, time.UTC)
+	id := uuid.NewString()
+
+	key := service.NewJWTService().CursorSigningKey()
+
+	token := utils.EncodeCursor(key, createdAt, id)
+	gotAt, gotID, err := utils.DecodeCursor(key, token)
+	if err != nil {
+		t.Fatalf("failed to decode cursor: %v", err)
+	}
+	if !gotAt.Equal(createdAt) || gotID != id {
+		t.Errorf("expected the cursor to hold %v/%s, got %v/%s", createdAt, id, gotAt, gotID)
+	}
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(createdAt.Add(time.Hour).Format(time.RFC3339Nano)+"|"+id)) + token[strings.Index(token, "."):]
+	if _, _, err := utils.DecodeCursor(key, forged); err == nil {
+		t.Errorf("expected a cursor with a changed position to be rejected")
+	}
+}
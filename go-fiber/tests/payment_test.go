@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/routes"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+func TestNewPaymentProvidersFromEnv_RequiresMockFlagAndSecret(t *testing.T) {
+	t.Setenv("PAYMENT_MOCK_ENABLED", "")
+	t.Setenv("PAYMENT_MOCK_SECRET", "secret")
+	if providers, err := service.NewPaymentProvidersFromEnv(); err != nil || len(providers) != 0 {
+		t.Errorf("expected the mock provider to stay off without the flag, got %d providers and %v", len(providers), err)
+	}
+
+	t.Setenv("PAYMENT_MOCK_ENABLED", "true")
+	t.Setenv("PAYMENT_MOCK_SECRET", "")
+	if _, err := service.NewPaymentProvidersFromEnv(); !errors.Is(err, dto.ErrPaymentMockSecretRequired) {
+		t.Errorf("expected the mock provider to require a secret, got %v", err)
+	}
+
+	t.Setenv("PAYMENT_MOCK_SECRET", "secret")
+	providers, err := service.NewPaymentProvidersFromEnv()
+	if err != nil {
+		t.Fatalf("failed to set up providers: %v", err)
+	}
+	if len(providers) != 1 || providers[0].Name() != constants.ENUM_PAYMENT_PROVIDER_MOCK {
+		t.Errorf("expected only the mock provider, got %d providers", len(providers))
+	}
+}
+
+func TestCreateTransaction_RefusedWithoutPaymentProvider(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "unpaid buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "unpayable event", AuthorID: buyer.ID, Price: 1000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	transactionService := newTransactionTestServiceWithPayments(db, service.NewPaymentService())
+
+	if _, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  1,
+	}); !errors.Is(err, dto.ErrPaymentsUnavailable) {
+		t.Fatalf("expected checkout to be refused, got %v", err)
+	}
+
+	var reloaded entity.TicketTier
+	if err := db.Take(&reloaded, "id = ?", tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloaded.Availability != 10 {
+		t.Errorf("expected no seat to be reserved, got availability %d", reloaded.Availability)
+	}
+}
+
+func TestPaymentWebhook_RequiresValidSignature(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "webhook buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "webhook event", AuthorID: buyer.ID, Price: 1000, Capacity: 10, Availabilty: 10, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	app := fiber.New()
+	routes.Payment(app, controller.NewPaymentController(transactionService))
+
+	post := func(payload string, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/payment/webhook/"+constants.ENUM_PAYMENT_PROVIDER_MOCK, strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if signature != "" {
+			req.Header.Set("X-Signature", signature)
+		}
+
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("failed to post webhook: %v", err)
+		}
+		return res.StatusCode
+	}
+
+	orderStatus := func(orderId string) string {
+		var order entity.Order
+		if err := db.Take(&order, "id = ?", orderId).Error; err != nil {
+			t.Fatalf("failed to reload order: %v", err)
+		}
+		return order.Status
+	}
+
+	for _, tc := range []struct {
+		name   string
+		status string
+		want   string
+	}{
+		{"paid", constants.ENUM_PAYMENT_STATUS_PAID, constants.ENUM_TRANSACTION_STATUS_PAID},
+		{"failed", constants.ENUM_PAYMENT_STATUS_FAILED, constants.ENUM_TRANSACTION_STATUS_FAILED},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := transactionService.CreateOrder(ctx, dto.OrderCreateRequest{
+				BuyerID: buyer.ID.String(),
+				Items:   []dto.OrderItemRequest{{EventID: event.ID.String(), Amount: 1}},
+			})
+			if err != nil {
+				t.Fatalf("failed to create order: %v", err)
+			}
+
+			payload := `{"reference":"` + order.PaymentReference + `","status":"` + tc.status + `"}`
+
+			if code := post(payload, ""); code != http.StatusUnauthorized {
+				t.Errorf("expected a missing signature to be rejected, got %d", code)
+			}
+			if code := post(payload, utils.SignHMAC("wrong-secret", []byte(payload))); code != http.StatusUnauthorized {
+				t.Errorf("expected a bad signature to be rejected, got %d", code)
+			}
+			if status := orderStatus(order.ID); status != constants.ENUM_TRANSACTION_STATUS_PENDING {
+				t.Fatalf("expected rejected webhooks to leave the order pending, got %q", status)
+			}
+
+			if code := post(payload, utils.SignHMAC(testPaymentSecret, []byte(payload))); code != http.StatusOK {
+				t.Fatalf("expected a signed webhook to be accepted, got %d", code)
+			}
+			if status := orderStatus(order.ID); status != tc.want {
+				t.Errorf("expected the order to be %s, got %q", tc.want, status)
+			}
+		})
+	}
+}
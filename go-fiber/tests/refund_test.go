@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+)
+
+// refundFixture is a paid purchase of quantity tickets at 1000 each, with
+// its tickets issued, of an event with the given refund policy.
+type refundFixture struct {
+	buyer       entity.User
+	event       entity.Event
+	tier        entity.TicketTier
+	transaction entity.Transaction
+}
+
+const refundFixtureCapacity = 10
+
+func createRefundFixture(t *testing.T, db *gorm.DB, quantity int, percentage int, deadline *time.Time) refundFixture {
+	t.Helper()
+
+	buyer := createTestUser(t, db, "refund buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "refund event", AuthorID: buyer.ID, Price: 1000, Capacity: refundFixtureCapacity, Availabilty: refundFixtureCapacity - quantity, RefundDeadline: deadline, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	if err := db.Model(&event).Update("refund_percentage", percentage).Error; err != nil {
+		t.Fatalf("failed to set refund policy: %v", err)
+	}
+
+	order := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	now := time.Now()
+	transaction := entity.Transaction{
+		OrderID:    order.ID.String(),
+		EventID:    event.ID.String(),
+		TierID:     tier.ID.String(),
+		BuyerID:    buyer.ID.String(),
+		Amount:     quantity,
+		UnitPrice:  1000,
+		Subtotal:   1000 * quantity,
+		Total:      1000 * quantity,
+		Status:     constants.ENUM_TRANSACTION_STATUS_PAID,
+		PaidAt:     &now,
+		PaidAmount: quantity,
+		PaidTotal:  1000 * quantity,
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	tickets := make([]entity.Ticket, quantity)
+	for i := range tickets {
+		tickets[i] = entity.Ticket{TransactionID: transaction.ID.String(), EventID: event.ID.String(), OwnerID: buyer.ID.String(), Code: uuid.NewString(), Status: constants.ENUM_TICKET_STATUS_ACTIVE}
+	}
+	if err := db.Create(&tickets).Error; err != nil {
+		t.Fatalf("failed to create tickets: %v", err)
+	}
+
+	return refundFixture{buyer: buyer, event: event, tier: tier, transaction: transaction}
+}
+
+// availability returns the free seats of the fixture's event and tier.
+func (f refundFixture) availability(t *testing.T, db *gorm.DB) (int, int) {
+	t.Helper()
+
+	var event entity.Event
+	if err := db.Take(&event, "id = ?", f.event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+
+	var tier entity.TicketTier
+	if err := db.Take(&tier, "id = ?", f.tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+
+	return event.Availabilty, tier.Availability
+}
+
+func TestEvent_RefundsInFullByDefault(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	author := createTestUser(t, db, "default author", constants.ENUM_ROLE_ADMIN)
+	event := createTestEvent(t, db, entity.Event{Name: "default policy", AuthorID: author.ID, Price: 1000, Capacity: 1, Availabilty: 1})
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.RefundPercentage != 100 {
+		t.Errorf("expected events to refund 100%% by default, got %d", reloaded.RefundPercentage)
+	}
+}
+
+func TestRefundTransaction_AppliesPercentageToPartialRefund(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	fixture := createRefundFixture(t, db, 4, 50, nil)
+	transactionService := newTransactionTestService(db)
+
+	res, err := transactionService.RefundTransaction(context.Background(), dto.TransactionRefundRequest{Quantity: 1}, fixture.transaction.ID.String())
+	if err != nil {
+		t.Fatalf("failed to refund: %v", err)
+	}
+
+	if res.Refund == nil || res.Refund.Amount != 500 || res.Refund.Quantity != 1 {
+		t.Errorf("expected half of one ticket to be refunded, got %+v", res.Refund)
+	}
+	if res.Transaction.Status != constants.ENUM_TRANSACTION_STATUS_PAID || res.Transaction.Amount != 3 || res.Transaction.Total != 3000 {
+		t.Errorf("expected 3 paid tickets worth 3000 to remain, got %+v", res.Transaction)
+	}
+
+	eventSeats, tierSeats := fixture.availability(t, db)
+	if eventSeats != refundFixtureCapacity-3 || tierSeats != refundFixtureCapacity-3 {
+		t.Errorf("expected one seat to be released, got event %d and tier %d", eventSeats, tierSeats)
+	}
+
+	var active int64
+	db.Model(&entity.Ticket{}).Where("transaction_id = ? AND status = ?", fixture.transaction.ID, constants.ENUM_TICKET_STATUS_ACTIVE).Count(&active)
+	if active != 3 {
+		t.Errorf("expected one ticket to be voided, got %d active", active)
+	}
+}
+
+func TestRefundTransaction_RejectsAfterDeadline(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	deadline := time.Now().Add(-time.Hour)
+	fixture := createRefundFixture(t, db, 2, 100, &deadline)
+	transactionService := newTransactionTestService(db)
+
+	_, err := transactionService.RefundTransaction(context.Background(), dto.TransactionRefundRequest{Quantity: 1}, fixture.transaction.ID.String())
+	if !errors.Is(err, dto.ErrRefundDeadlinePassed) {
+		t.Fatalf("expected the refund deadline to be enforced, got %v", err)
+	}
+
+	eventSeats, tierSeats := fixture.availability(t, db)
+	if eventSeats != refundFixtureCapacity-2 || tierSeats != refundFixtureCapacity-2 {
+		t.Errorf("expected no seat to be released, got event %d and tier %d", eventSeats, tierSeats)
+	}
+
+	var refunds int64
+	db.Model(&entity.Refund{}).Where("transaction_id = ?", fixture.transaction.ID).Count(&refunds)
+	if refunds != 0 {
+		t.Errorf("expected no refund to be recorded, got %d", refunds)
+	}
+}
+
+func TestCancelTransaction_RefundsPaidTicketsByPolicy(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	fixture := createRefundFixture(t, db, 2, 75, nil)
+	transactionService := newTransactionTestService(db)
+
+	res, err := transactionService.CancelTransaction(context.Background(), dto.TransactionCancelRequest{Reason: "cannot come"}, fixture.transaction.ID.String())
+	if err != nil {
+		t.Fatalf("failed to cancel: %v", err)
+	}
+
+	if res.Transaction.Status != constants.ENUM_TRANSACTION_STATUS_CANCELLED {
+		t.Errorf("expected the transaction to be cancelled, got %q", res.Transaction.Status)
+	}
+	if res.Refund == nil || res.Refund.Amount != 1500 || res.Refund.Status != constants.ENUM_REFUND_STATUS_SENT {
+		t.Errorf("expected 75%% of 2000 to be refunded, got %+v", res.Refund)
+	}
+
+	eventSeats, tierSeats := fixture.availability(t, db)
+	if eventSeats != refundFixtureCapacity || tierSeats != refundFixtureCapacity {
+		t.Errorf("expected both seats to be released, got event %d and tier %d", eventSeats, tierSeats)
+	}
+}
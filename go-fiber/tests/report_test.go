@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/service"
+)
+
+func TestGetSalesReport_BucketsSalesAndRefundsByLocalDay(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "report event", AuthorID: organizer.ID, Price: 1000, Capacity: 10, Availabilty: 5})
+	tier := createTestTier(t, db, event)
+
+	order := entity.Order{BuyerID: organizer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	// 23:30 UTC on the 1st is already the 2nd in Jakarta (UTC+7).
+	lateEvening := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+	morning := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	transactions := []*entity.Transaction{
+		{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: organizer.ID.String(), Amount: 2, Total: 2000, PaidAmount: 2, PaidTotal: 2000, Status: constants.ENUM_TRANSACTION_STATUS_PAID, PaidAt: &lateEvening},
+		{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: organizer.ID.String(), Amount: 3, Total: 3000, PaidAmount: 3, PaidTotal: 3000, Status: constants.ENUM_TRANSACTION_STATUS_REFUNDED, PaidAt: &morning},
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to create transactions: %v", err)
+	}
+
+	refund := entity.Refund{TransactionID: transactions[1].ID.String(), Quantity: 3, Amount: 1500}
+	refund.CreatedAt = morning.Add(time.Hour)
+	if err := db.Create(&refund).Error; err != nil {
+		t.Fatalf("failed to create refund: %v", err)
+	}
+
+	reportService := service.NewReportService(repository.NewReportRepository(db))
+
+	daily, err := reportService.GetSalesReport(ctx, dto.SalesReportRequest{
+		GroupBy:  constants.ENUM_REPORT_GROUP_DAY,
+		EventID:  event.ID.String(),
+		Timezone: "Asia/Jakarta",
+	})
+	if err != nil {
+		t.Fatalf("failed to get daily report: %v", err)
+	}
+	if len(daily.Rows) != 1 {
+		t.Fatalf("expected both sales on one Jakarta day, got %+v", daily.Rows)
+	}
+	if row := daily.Rows[0]; row.Date != "2024-03-02" || row.TicketsSold != 5 || row.Gross != 5000 || row.Refunds != 1500 || row.Net != 3500 {
+		t.Errorf("unexpected daily totals: %+v", row)
+	}
+
+	daily, err = reportService.GetSalesReport(ctx, dto.SalesReportRequest{
+		GroupBy: constants.ENUM_REPORT_GROUP_DAY,
+		EventID: event.ID.String(),
+		From:    "2024-03-02",
+		To:      "2024-03-02",
+	})
+	if err != nil {
+		t.Fatalf("failed to get daily report: %v", err)
+	}
+	if len(daily.Rows) != 1 || daily.Rows[0].TicketsSold != 3 || daily.Rows[0].TicketsRefunded != 3 {
+		t.Errorf("expected only the UTC sales of the 2nd, got %+v", daily.Rows)
+	}
+
+	tiers, err := reportService.GetSalesReport(ctx, dto.SalesReportRequest{
+		GroupBy:  constants.ENUM_REPORT_GROUP_TIER,
+		AuthorID: organizer.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("failed to get tier report: %v", err)
+	}
+	if len(tiers.Rows) != 1 || tiers.Rows[0].TierID != tier.ID.String() || tiers.Rows[0].Net != 3500 {
+		t.Errorf("unexpected tier report: %+v", tiers.Rows)
+	}
+}
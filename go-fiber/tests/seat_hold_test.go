@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+func TestExpireHolds_ReleasesSeatsOfUnpaidPurchases(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "hold buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "hold event", AuthorID: buyer.ID, Price: 1000, Capacity: 5, Availabilty: 5, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	if err := db.Model(&entity.SeatHold{}).
+		Where("transaction_id = ?", result.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("failed to expire hold: %v", err)
+	}
+
+	if _, err := transactionService.ExpireHolds(ctx, 100); err != nil {
+		t.Fatalf("failed to expire holds: %v", err)
+	}
+
+	var transaction entity.Transaction
+	if err := db.Take(&transaction, "id = ?", result.ID).Error; err != nil {
+		t.Fatalf("failed to reload transaction: %v", err)
+	}
+	if transaction.Status != constants.ENUM_TRANSACTION_STATUS_EXPIRED {
+		t.Errorf("expected transaction to be expired, got %s", transaction.Status)
+	}
+
+	var hold entity.SeatHold
+	if err := db.Take(&hold, "transaction_id = ?", result.ID).Error; err != nil {
+		t.Fatalf("failed to reload hold: %v", err)
+	}
+	if hold.Status != constants.ENUM_SEAT_HOLD_STATUS_EXPIRED {
+		t.Errorf("expected hold to be expired, got %s", hold.Status)
+	}
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.Availabilty != 5 {
+		t.Errorf("expected all seats back on sale, got availability %d", reloaded.Availabilty)
+	}
+}
+
+func TestHandlePaymentWebhook_RefundsPaymentAfterHoldExpired(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "late buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "late event", AuthorID: buyer.ID, Price: 1000, Capacity: 5, Availabilty: 5, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	if err := db.Model(&entity.SeatHold{}).
+		Where("transaction_id = ?", result.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("failed to expire hold: %v", err)
+	}
+
+	if _, err := transactionService.ExpireHolds(ctx, 100); err != nil {
+		t.Fatalf("failed to expire holds: %v", err)
+	}
+
+	// The provider retries the notification, which must not refund twice.
+	payload := []byte(`{"reference":"` + result.PaymentReference + `","status":"` + constants.ENUM_PAYMENT_STATUS_PAID + `"}`)
+	for i := 0; i < 2; i++ {
+		if err := transactionService.HandlePaymentWebhook(ctx, constants.ENUM_PAYMENT_PROVIDER_MOCK, payload, utils.SignHMAC(testPaymentSecret, payload)); err != nil {
+			t.Fatalf("expected the late payment to be accepted, got %v", err)
+		}
+	}
+
+	var transaction entity.Transaction
+	if err := db.Take(&transaction, "id = ?", result.ID).Error; err != nil {
+		t.Fatalf("failed to reload transaction: %v", err)
+	}
+	if transaction.Status != constants.ENUM_TRANSACTION_STATUS_EXPIRED {
+		t.Errorf("expected transaction to stay expired, got %s", transaction.Status)
+	}
+
+	var tickets int64
+	db.Model(&entity.Ticket{}).Where("transaction_id = ?", result.ID).Count(&tickets)
+	if tickets != 0 {
+		t.Errorf("expected no tickets for a late payment, got %d", tickets)
+	}
+
+	var refunds []entity.Refund
+	if err := db.Where("transaction_id = ?", result.ID).Find(&refunds).Error; err != nil {
+		t.Fatalf("failed to load refunds: %v", err)
+	}
+	if len(refunds) != 1 {
+		t.Fatalf("expected one refund of the late payment, got %d", len(refunds))
+	}
+	if refunds[0].Amount != transaction.Total || refunds[0].Status != constants.ENUM_REFUND_STATUS_SENT {
+		t.Errorf("expected a sent refund of %d, got %d (%s)", transaction.Total, refunds[0].Amount, refunds[0].Status)
+	}
+}
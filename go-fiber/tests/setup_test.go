@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/migrations"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/service"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testPaymentSecret signs the notifications tests send to the mock payment
+// provider.
+const testPaymentSecret = "test-payment-secret"
+
+// SetUpTestDatabase connects to the Postgres instance described by the
+// TEST_DB_* variables and migrates it. Tests that need a real database are
+// skipped when TEST_DB_HOST is not set.
+func SetUpTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbHost := os.Getenv("TEST_DB_HOST")
+	if dbHost == "" {
+		t.Skip("TEST_DB_HOST is not set, skipping database test")
+	}
+
+	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v TimeZone=Asia/Jakarta",
+		dbHost,
+		os.Getenv("TEST_DB_USER"),
+		os.Getenv("TEST_DB_PASS"),
+		os.Getenv("TEST_DB_NAME"),
+		os.Getenv("TEST_DB_PORT"),
+	)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  dsn,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(20)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := migrations.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	return db
+}
+
+func newTransactionTestService(db *gorm.DB) service.TransactionService {
+	return newTransactionTestServiceWithPayments(db, service.NewPaymentService(service.NewMockPaymentProvider(testPaymentSecret)))
+}
+
+// newTransactionTestServiceWithPayments builds the transaction service
+// charging through paymentService instead of the mock provider.
+func newTransactionTestServiceWithPayments(db *gorm.DB, paymentService service.PaymentService) service.TransactionService {
+	return service.NewTransactionService(
+		repository.NewTransactionRepository(db),
+		repository.NewOrderRepository(db),
+		repository.NewSeatHoldRepository(db),
+		repository.NewWaitlistRepository(db),
+		repository.NewEventRepository(db),
+		repository.NewTicketTierRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewRefundRepository(db),
+		repository.NewTicketRepository(db),
+		repository.NewVoucherRepository(db),
+		repository.NewInvoiceRepository(db),
+		paymentService,
+		newWaitlistTestService(db),
+		service.NewJWTService(),
+		db,
+	)
+}
+
+func newWaitlistTestService(db *gorm.DB) service.WaitlistService {
+	return service.NewWaitlistService(repository.NewWaitlistRepository(db), repository.NewEventRepository(db), repository.NewTicketTierRepository(db), repository.NewUserRepository(db), db)
+}
+
+func newEventTestService(db *gorm.DB) service.EventService {
+	return service.NewEventService(repository.NewEventRepository(db), repository.NewTicketTierRepository(db), service.NewJWTService(), newWaitlistTestService(db), db)
+}
+
+func newTicketTestService(db *gorm.DB) service.TicketService {
+	return service.NewTicketService(repository.NewTicketRepository(db), repository.NewTicketTransferRepository(db), repository.NewEventRepository(db), repository.NewUserRepository(db), service.NewJWTService(), db)
+}
+
+// createTestUser creates a verified user with the given role. The user and
+// everything they bought or were handed are removed when the test ends.
+func createTestUser(t *testing.T, db *gorm.DB, name string, role string) entity.User {
+	t.Helper()
+
+	user := entity.User{Name: name, Email: uuid.NewString() + "@test.local", Password: "password", Role: role, IsVerified: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		transactions := db.Model(&entity.Transaction{}).Select("id").Where("buyer_id = ?", user.ID)
+		tickets := db.Model(&entity.Ticket{}).Select("id").Where("owner_id = ? OR transaction_id IN (?)", user.ID, transactions)
+
+		db.Unscoped().Where("ticket_id IN (?) OR from_user_id = ? OR to_user_id = ?", tickets, user.ID, user.ID).Delete(&entity.TicketTransfer{})
+		db.Unscoped().Where("id IN (?)", tickets).Delete(&entity.Ticket{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.Refund{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.Invoice{})
+		db.Unscoped().Where("transaction_id IN (?) OR user_id = ?", transactions, user.ID).Delete(&entity.VoucherRedemption{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.SeatHold{})
+		db.Unscoped().Where("user_id = ?", user.ID).Delete(&entity.WaitlistEntry{})
+		db.Unscoped().Where("buyer_id = ?", user.ID).Delete(&entity.Transaction{})
+		db.Unscoped().Where("buyer_id = ?", user.ID).Delete(&entity.Order{})
+		db.Unscoped().Where("user_id = ?", user.ID).Delete(&entity.IdempotencyKey{})
+		db.Unscoped().Where("author_id = ?", user.ID).Delete(&entity.Event{})
+		db.Unscoped().Delete(&user)
+	})
+
+	return user
+}
+
+// createTestEvent creates event. The event and everything sold for it are
+// removed when the test ends; orders go with their buyer.
+func createTestEvent(t *testing.T, db *gorm.DB, event entity.Event) entity.Event {
+	t.Helper()
+
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+
+	t.Cleanup(func() {
+		transactions := db.Model(&entity.Transaction{}).Select("id").Where("event_id = ?", event.ID)
+
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.TicketTransfer{})
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.Ticket{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.Refund{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.Invoice{})
+		db.Unscoped().Where("transaction_id IN (?)", transactions).Delete(&entity.VoucherRedemption{})
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.SeatHold{})
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.WaitlistEntry{})
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.Transaction{})
+		db.Unscoped().Where("event_id = ?", event.ID).Delete(&entity.TicketTier{})
+		db.Unscoped().Delete(&event)
+	})
+
+	return event
+}
+
+// createTestTier creates the default tier of a single-tier event, priced
+// and sized like the event.
+func createTestTier(t *testing.T, db *gorm.DB, event entity.Event) entity.TicketTier {
+	t.Helper()
+
+	tier := entity.TicketTier{EventID: event.ID.String(), Name: constants.ENUM_TICKET_TIER_DEFAULT, Price: event.Price, Capacity: event.Capacity, Availability: event.Availabilty}
+	if err := db.Create(&tier).Error; err != nil {
+		t.Fatalf("failed to create tier: %v", err)
+	}
+
+	return tier
+}
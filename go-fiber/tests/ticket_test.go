@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+	"gorm.io/gorm"
+)
+
+// ticket issues a ticket of a paid purchase in the given status.
+func (f statusFixture) ticket(t *testing.T, db *gorm.DB, status string) entity.Ticket {
+	t.Helper()
+
+	transaction := f.purchase(t, db, constants.ENUM_TRANSACTION_STATUS_PAID)
+
+	ticket := entity.Ticket{TransactionID: transaction.ID.String(), EventID: f.event.ID.String(), OwnerID: f.buyer.ID.String(), Code: uuid.NewString(), Status: status}
+	if err := db.Create(&ticket).Error; err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	return ticket
+}
+
+func TestTicketToken_VerifiesOnlyForItsEvent(t *testing.T) {
+	jwtService := service.NewJWTService()
+
+	eventId := uuid.NewString()
+	token, err := jwtService.GenerateTicketToken(uuid.NewString(), "TICKETCODE", eventId)
+	if err != nil {
+		t.Fatalf("failed to sign ticket: %v", err)
+	}
+
+	claims, err := jwtService.ValidateTicketToken(token, eventId)
+	if err != nil {
+		t.Fatalf("expected ticket to verify for its event: %v", err)
+	}
+	if claims.Code != "TICKETCODE" {
+		t.Fatalf("expected code TICKETCODE, got %s", claims.Code)
+	}
+
+	if _, err := jwtService.ValidateTicketToken(token, uuid.NewString()); err == nil {
+		t.Fatal("expected ticket to be rejected for another event")
+	}
+
+	if _, err := jwtService.ValidateTicketToken(token+"x", eventId); err == nil {
+		t.Fatal("expected tampered ticket to be rejected")
+	}
+}
+
+func TestHandlePaymentWebhook_IssuesOneTicketPerUnit(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	transactionService := newTransactionTestService(db)
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: fixture.event.ID.String(),
+		BuyerID: fixture.buyer.ID.String(),
+		Amount:  3,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	loadTickets := func() []entity.Ticket {
+		var tickets []entity.Ticket
+		if err := db.Where("transaction_id = ?", result.ID).Find(&tickets).Error; err != nil {
+			t.Fatalf("failed to load tickets: %v", err)
+		}
+		return tickets
+	}
+
+	if tickets := loadTickets(); len(tickets) != 0 {
+		t.Fatalf("expected no tickets before payment, got %d", len(tickets))
+	}
+
+	// The provider retries the notification, which must not issue twice.
+	payload := []byte(`{"reference":"` + result.PaymentReference + `","status":"` + constants.ENUM_PAYMENT_STATUS_PAID + `"}`)
+	for i := 0; i < 2; i++ {
+		if err := transactionService.HandlePaymentWebhook(ctx, constants.ENUM_PAYMENT_PROVIDER_MOCK, payload, utils.SignHMAC(testPaymentSecret, payload)); err != nil {
+			t.Fatalf("failed to pay: %v", err)
+		}
+	}
+
+	tickets := loadTickets()
+	if len(tickets) != 3 {
+		t.Fatalf("expected one ticket per unit, got %d", len(tickets))
+	}
+
+	codes := map[string]bool{}
+	for _, ticket := range tickets {
+		if ticket.Status != constants.ENUM_TICKET_STATUS_ACTIVE {
+			t.Errorf("expected ticket %s to be active, got %s", ticket.Code, ticket.Status)
+		}
+		if ticket.OwnerID != fixture.buyer.ID.String() || ticket.EventID != fixture.event.ID.String() {
+			t.Errorf("expected ticket %s to belong to the buyer for the event, got %+v", ticket.Code, ticket)
+		}
+		if ticket.Code == "" || codes[ticket.Code] {
+			t.Errorf("expected every ticket to get its own code, got %q twice", ticket.Code)
+		}
+		codes[ticket.Code] = true
+	}
+}
+
+func TestCancelTransaction_VoidsTickets(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	transactionService := newTransactionTestService(db)
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: fixture.event.ID.String(),
+		BuyerID: fixture.buyer.ID.String(),
+		Amount:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	payload := []byte(`{"reference":"` + result.PaymentReference + `","status":"` + constants.ENUM_PAYMENT_STATUS_PAID + `"}`)
+	if err := transactionService.HandlePaymentWebhook(ctx, constants.ENUM_PAYMENT_PROVIDER_MOCK, payload, utils.SignHMAC(testPaymentSecret, payload)); err != nil {
+		t.Fatalf("failed to pay: %v", err)
+	}
+
+	if _, err := transactionService.CancelTransaction(ctx, dto.TransactionCancelRequest{Reason: "changed plans"}, result.ID); err != nil {
+		t.Fatalf("failed to cancel transaction: %v", err)
+	}
+
+	var tickets []entity.Ticket
+	if err := db.Where("transaction_id = ?", result.ID).Find(&tickets).Error; err != nil {
+		t.Fatalf("failed to load tickets: %v", err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("expected the two issued tickets, got %d", len(tickets))
+	}
+
+	for _, ticket := range tickets {
+		if ticket.Status != constants.ENUM_TICKET_STATUS_VOID || ticket.VoidedAt == nil {
+			t.Errorf("expected ticket %s to be void, got %s", ticket.Code, ticket.Status)
+		}
+	}
+}
+
+func TestCheckInTicket_AdmitsATicketOnce(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	other := createStatusFixture(t, db)
+	ticketService := newTicketTestService(db)
+
+	// The organizer of each fixture event is its buyer.
+	staffId := fixture.buyer.ID.String()
+	ticket := fixture.ticket(t, db, constants.ENUM_TICKET_STATUS_ACTIVE)
+
+	if _, err := ticketService.CheckInTicket(ctx, dto.TicketCheckInRequest{EventID: other.event.ID.String(), Code: ticket.Code}, other.buyer.ID.String()); !errors.Is(err, dto.ErrTicketNotForEvent) {
+		t.Errorf("expected a ticket of another event to be rejected, got %v", err)
+	}
+
+	if _, err := ticketService.CheckInTicket(ctx, dto.TicketCheckInRequest{EventID: fixture.event.ID.String(), Code: ticket.Code}, other.buyer.ID.String()); !errors.Is(err, dto.ErrNotEventStaff) {
+		t.Errorf("expected another organizer not to scan the event, got %v", err)
+	}
+
+	admitted, err := ticketService.CheckInTicket(ctx, dto.TicketCheckInRequest{EventID: fixture.event.ID.String(), Code: ticket.Code}, staffId)
+	if err != nil {
+		t.Fatalf("failed to check in: %v", err)
+	}
+	if admitted.Status != constants.ENUM_TICKET_STATUS_USED || admitted.CheckedInBy != staffId {
+		t.Errorf("expected the ticket to be used by the staff, got %+v", admitted)
+	}
+
+	again, err := ticketService.CheckInTicket(ctx, dto.TicketCheckInRequest{EventID: fixture.event.ID.String(), Code: ticket.Code}, staffId)
+	if !errors.Is(err, dto.ErrTicketAlreadyUsed) {
+		t.Errorf("expected a second scan to be rejected, got %v", err)
+	}
+	if again.UsedAt != admitted.UsedAt {
+		t.Errorf("expected the rejection to report the first scan at %s, got %s", admitted.UsedAt, again.UsedAt)
+	}
+
+	void := fixture.ticket(t, db, constants.ENUM_TICKET_STATUS_VOID)
+	if _, err := ticketService.CheckInTicket(ctx, dto.TicketCheckInRequest{EventID: fixture.event.ID.String(), Code: void.Code}, staffId); !errors.Is(err, dto.ErrTicketVoid) {
+		t.Errorf("expected a void ticket to be rejected, got %v", err)
+	}
+
+	var reloaded entity.Ticket
+	if err := db.Take(&reloaded, "id = ?", void.ID).Error; err != nil {
+		t.Fatalf("failed to reload ticket: %v", err)
+	}
+	if reloaded.Status != constants.ENUM_TICKET_STATUS_VOID || reloaded.UsedAt != nil {
+		t.Errorf("expected the void ticket to stay unused, got %+v", reloaded)
+	}
+}
+
+func TestSyncCheckIns_ReportsEveryEntry(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	other := createStatusFixture(t, db)
+	ticketService := newTicketTestService(db)
+
+	staffId := fixture.buyer.ID.String()
+	valid := fixture.ticket(t, db, constants.ENUM_TICKET_STATUS_ACTIVE)
+	void := fixture.ticket(t, db, constants.ENUM_TICKET_STATUS_VOID)
+	foreign := other.ticket(t, db, constants.ENUM_TICKET_STATUS_ACTIVE)
+
+	scannedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	result, err := ticketService.SyncCheckIns(ctx, dto.TicketBatchCheckInRequest{
+		EventID: fixture.event.ID.String(),
+		CheckIns: []dto.TicketCheckInRequest{
+			{Code: valid.Code, ScannedAt: &scannedAt},
+			{Code: valid.Code},
+			{Code: void.Code},
+			{Code: foreign.Code},
+			{Code: "UNKNOWN"},
+		},
+	}, staffId)
+	if err != nil {
+		t.Fatalf("failed to sync check-ins: %v", err)
+	}
+
+	if result.Accepted != 1 || result.Rejected != 4 {
+		t.Errorf("expected 1 accepted and 4 rejected, got %d and %d", result.Accepted, result.Rejected)
+	}
+
+	expected := []struct {
+		result string
+		err    error
+	}{
+		{constants.ENUM_CHECK_IN_RESULT_ACCEPTED, nil},
+		{constants.ENUM_CHECK_IN_RESULT_REJECTED, dto.ErrTicketAlreadyUsed},
+		{constants.ENUM_CHECK_IN_RESULT_REJECTED, dto.ErrTicketVoid},
+		{constants.ENUM_CHECK_IN_RESULT_REJECTED, dto.ErrTicketNotForEvent},
+		{constants.ENUM_CHECK_IN_RESULT_REJECTED, dto.ErrTicketNotFound},
+	}
+	if len(result.Results) != len(expected) {
+		t.Fatalf("expected a result per entry, got %d", len(result.Results))
+	}
+
+	for i, want := range expected {
+		got := result.Results[i]
+
+		wantErr := ""
+		if want.err != nil {
+			wantErr = want.err.Error()
+		}
+		if got.Result != want.result || got.Error != wantErr {
+			t.Errorf("entry %d: expected %s (%q), got %s (%q)", i, want.result, wantErr, got.Result, got.Error)
+		}
+	}
+
+	var reloaded entity.Ticket
+	if err := db.Take(&reloaded, "id = ?", valid.ID).Error; err != nil {
+		t.Fatalf("failed to reload ticket: %v", err)
+	}
+	if reloaded.UsedAt == nil || !reloaded.UsedAt.Equal(scannedAt) {
+		t.Errorf("expected the ticket to be used when it was scanned offline, got %v", reloaded.UsedAt)
+	}
+}
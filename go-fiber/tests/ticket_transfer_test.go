@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+)
+
+func TestAcceptTransfer_ReissuesTicketToRecipient(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	sender := createTestUser(t, db, "sender", constants.ENUM_ROLE_USER)
+	recipient := createTestUser(t, db, "recipient", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "transfer event", AuthorID: sender.ID, Price: 1000, Capacity: 1, Availabilty: 0})
+	tier := createTestTier(t, db, event)
+
+	order := entity.Order{BuyerID: sender.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transaction := entity.Transaction{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: sender.ID.String(), Amount: 1, Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	ticket := entity.Ticket{TransactionID: transaction.ID.String(), EventID: event.ID.String(), OwnerID: sender.ID.String(), Code: uuid.NewString(), Status: constants.ENUM_TICKET_STATUS_ACTIVE}
+	if err := db.Create(&ticket).Error; err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	ticketService := newTicketTestService(db)
+
+	transfer, err := ticketService.TransferTicket(ctx, dto.TicketTransferRequest{
+		Code:       ticket.Code,
+		FromUserID: sender.ID.String(),
+		Email:      recipient.Email,
+	})
+	if err != nil {
+		t.Fatalf("failed to transfer ticket: %v", err)
+	}
+	if transfer.ToUserID != recipient.ID.String() {
+		t.Errorf("expected the transfer to find the registered recipient, got %q", transfer.ToUserID)
+	}
+
+	if _, err := ticketService.AcceptTransfer(ctx, transfer.ID, sender.ID.String()); !errors.Is(err, dto.ErrTicketTransferNotFound) {
+		t.Errorf("expected the sender not to accept their own transfer, got %v", err)
+	}
+
+	reissued, err := ticketService.AcceptTransfer(ctx, transfer.ID, recipient.ID.String())
+	if err != nil {
+		t.Fatalf("failed to accept transfer: %v", err)
+	}
+	if reissued.OwnerID != recipient.ID.String() {
+		t.Errorf("expected recipient to own the ticket, got %s", reissued.OwnerID)
+	}
+	if reissued.Code == ticket.Code {
+		t.Errorf("expected the ticket to get a new code")
+	}
+
+	if _, err := ticketService.GetTicketByCode(ctx, ticket.Code, ""); !errors.Is(err, dto.ErrTicketNotFound) {
+		t.Errorf("expected the old code to stop working, got %v", err)
+	}
+
+	history, err := ticketService.GetTicketHistory(ctx, reissued.Code, recipient.ID.String())
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if history.IssuedTo != sender.ID.String() || len(history.Transfers) != 1 {
+		t.Errorf("expected one transfer away from the buyer, got %+v", history)
+	}
+}
+
+func TestRefundTransaction_KeepsTransferredTicketsValid(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+	fixture := createRefundFixture(t, db, 2, 100, nil)
+	transactionService := newTransactionTestService(db)
+
+	recipient := createTestUser(t, db, "recipient", constants.ENUM_ROLE_USER)
+
+	var given entity.Ticket
+	if err := db.Where("transaction_id = ?", fixture.transaction.ID).Order("created_at DESC").Take(&given).Error; err != nil {
+		t.Fatalf("failed to load ticket: %v", err)
+	}
+	if err := db.Model(&given).Update("owner_id", recipient.ID.String()).Error; err != nil {
+		t.Fatalf("failed to transfer ticket: %v", err)
+	}
+
+	if _, err := transactionService.CancelTransaction(ctx, dto.TransactionCancelRequest{}, fixture.transaction.ID.String()); !errors.Is(err, dto.ErrTicketsHandedOver) {
+		t.Errorf("expected cancelling a transferred purchase to be refused, got %v", err)
+	}
+
+	if _, err := transactionService.RefundTransaction(ctx, dto.TransactionRefundRequest{Quantity: 2}, fixture.transaction.ID.String()); !errors.Is(err, dto.ErrTicketsHandedOver) {
+		t.Errorf("expected refunding the transferred ticket to be refused, got %v", err)
+	}
+
+	res, err := transactionService.RefundTransaction(ctx, dto.TransactionRefundRequest{}, fixture.transaction.ID.String())
+	if err != nil {
+		t.Fatalf("failed to refund the ticket the buyer holds: %v", err)
+	}
+	if res.Refund == nil || res.Refund.Quantity != 1 || res.Refund.Amount != 1000 {
+		t.Errorf("expected the one held ticket to be refunded, got %+v", res.Refund)
+	}
+
+	var reloaded entity.Ticket
+	if err := db.Take(&reloaded, "id = ?", given.ID).Error; err != nil {
+		t.Fatalf("failed to reload ticket: %v", err)
+	}
+	if reloaded.Status != constants.ENUM_TICKET_STATUS_ACTIVE {
+		t.Errorf("expected the recipient's ticket to stay active, got %q", reloaded.Status)
+	}
+}
+
+func TestAcceptTransfer_RefusedOnceTransfersAreDisabled(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+	fixture := createRefundFixture(t, db, 1, 100, nil)
+
+	recipient := createTestUser(t, db, "recipient", constants.ENUM_ROLE_USER)
+
+	var ticket entity.Ticket
+	if err := db.Where("transaction_id = ?", fixture.transaction.ID).Take(&ticket).Error; err != nil {
+		t.Fatalf("failed to load ticket: %v", err)
+	}
+
+	ticketService := newTicketTestService(db)
+
+	transfer, err := ticketService.TransferTicket(ctx, dto.TicketTransferRequest{
+		Code:       ticket.Code,
+		FromUserID: fixture.buyer.ID.String(),
+		ToUserID:   recipient.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("failed to transfer ticket: %v", err)
+	}
+
+	if err := db.Model(&fixture.event).Update("transfers_disabled", true).Error; err != nil {
+		t.Fatalf("failed to disable transfers: %v", err)
+	}
+
+	if _, err := ticketService.AcceptTransfer(ctx, transfer.ID, recipient.ID.String()); !errors.Is(err, dto.ErrTicketTransfersDisabled) {
+		t.Errorf("expected the pending transfer to be refused, got %v", err)
+	}
+}
+
+func TestTransferTickets_OffersAllOrNone(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+	fixture := createRefundFixture(t, db, 3, 100, nil)
+
+	recipient := createTestUser(t, db, "recipient", constants.ENUM_ROLE_USER)
+
+	var tickets []entity.Ticket
+	if err := db.Where("transaction_id = ?", fixture.transaction.ID).Order("created_at").Find(&tickets).Error; err != nil {
+		t.Fatalf("failed to load tickets: %v", err)
+	}
+
+	if err := db.Model(&tickets[2]).Update("status", constants.ENUM_TICKET_STATUS_VOID).Error; err != nil {
+		t.Fatalf("failed to void ticket: %v", err)
+	}
+
+	ticketService := newTicketTestService(db)
+
+	req := dto.TicketTransferRequest{
+		Codes:      []string{tickets[0].Code, tickets[1].Code, tickets[2].Code},
+		FromUserID: fixture.buyer.ID.String(),
+		ToUserID:   recipient.ID.String(),
+	}
+	if _, err := ticketService.TransferTickets(ctx, req); !errors.Is(err, dto.ErrTicketVoid) {
+		t.Fatalf("expected the void ticket to stop the transfer, got %v", err)
+	}
+
+	var offered int64
+	db.Model(&entity.TicketTransfer{}).Where("from_user_id = ?", fixture.buyer.ID.String()).Count(&offered)
+	if offered != 0 {
+		t.Fatalf("expected no ticket to be offered, got %d", offered)
+	}
+
+	req.Codes = []string{tickets[0].Code, tickets[1].Code, tickets[0].Code}
+	transfers, err := ticketService.TransferTickets(ctx, req)
+	if err != nil {
+		t.Fatalf("failed to transfer tickets: %v", err)
+	}
+	if len(transfers) != 2 {
+		t.Errorf("expected one transfer per distinct ticket, got %d", len(transfers))
+	}
+}
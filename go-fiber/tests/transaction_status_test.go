@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"gorm.io/gorm"
+)
+
+// statusFixture is an event with seats to spare and a buyer, on which
+// purchases are created straight in the status a test starts from.
+type statusFixture struct {
+	buyer entity.User
+	event entity.Event
+	tier  entity.TicketTier
+}
+
+func createStatusFixture(t *testing.T, db *gorm.DB) statusFixture {
+	t.Helper()
+
+	buyer := createTestUser(t, db, "status buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "status event", AuthorID: buyer.ID, Price: 1000, Capacity: 100, Availabilty: 50, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	return statusFixture{buyer: buyer, event: event, tier: tier}
+}
+
+// purchase creates a purchase of one ticket in the given status.
+func (f statusFixture) purchase(t *testing.T, db *gorm.DB, status string) entity.Transaction {
+	t.Helper()
+
+	order := entity.Order{BuyerID: f.buyer.ID.String(), Status: status}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transaction := entity.Transaction{
+		OrderID:   order.ID.String(),
+		EventID:   f.event.ID.String(),
+		TierID:    f.tier.ID.String(),
+		BuyerID:   f.buyer.ID.String(),
+		Amount:    1,
+		UnitPrice: 1000,
+		Subtotal:  1000,
+		Total:     1000,
+		Status:    status,
+	}
+	if status == constants.ENUM_TRANSACTION_STATUS_PAID {
+		now := time.Now()
+		transaction.PaidAt = &now
+		transaction.PaidAmount = 1
+		transaction.PaidTotal = 1000
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	return transaction
+}
+
+func TestUpdateTransactionStatus_FollowsTheTransitionTable(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	transactionService := newTransactionTestService(db)
+
+	statuses := []string{
+		constants.ENUM_TRANSACTION_STATUS_PENDING,
+		constants.ENUM_TRANSACTION_STATUS_PAID,
+		constants.ENUM_TRANSACTION_STATUS_CANCELLED,
+		constants.ENUM_TRANSACTION_STATUS_REFUNDED,
+		constants.ENUM_TRANSACTION_STATUS_EXPIRED,
+		constants.ENUM_TRANSACTION_STATUS_FAILED,
+	}
+
+	allowed := map[string]map[string]bool{
+		constants.ENUM_TRANSACTION_STATUS_PENDING: {
+			constants.ENUM_TRANSACTION_STATUS_PAID:      true,
+			constants.ENUM_TRANSACTION_STATUS_CANCELLED: true,
+			constants.ENUM_TRANSACTION_STATUS_EXPIRED:   true,
+			constants.ENUM_TRANSACTION_STATUS_FAILED:    true,
+		},
+		constants.ENUM_TRANSACTION_STATUS_PAID: {
+			constants.ENUM_TRANSACTION_STATUS_CANCELLED: true,
+			constants.ENUM_TRANSACTION_STATUS_REFUNDED:  true,
+		},
+	}
+
+	// stamped returns the timestamp a status sets on a transaction.
+	stamped := func(transaction entity.Transaction, status string) *time.Time {
+		switch status {
+		case constants.ENUM_TRANSACTION_STATUS_PAID:
+			return transaction.PaidAt
+		case constants.ENUM_TRANSACTION_STATUS_CANCELLED:
+			return transaction.CancelledAt
+		case constants.ENUM_TRANSACTION_STATUS_REFUNDED:
+			return transaction.RefundedAt
+		case constants.ENUM_TRANSACTION_STATUS_EXPIRED:
+			return transaction.ExpiredAt
+		case constants.ENUM_TRANSACTION_STATUS_FAILED:
+			return transaction.FailedAt
+		}
+		return nil
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			t.Run(from+" to "+to, func(t *testing.T) {
+				transaction := fixture.purchase(t, db, from)
+
+				result, err := transactionService.UpdateTransactionStatus(ctx, dto.TransactionStatusUpdateRequest{Status: to}, transaction.ID.String())
+
+				var reloaded entity.Transaction
+				if err := db.Take(&reloaded, "id = ?", transaction.ID).Error; err != nil {
+					t.Fatalf("failed to reload transaction: %v", err)
+				}
+
+				if !allowed[from][to] {
+					var transitionErr *dto.ErrInvalidStatusTransition
+					if !errors.As(err, &transitionErr) {
+						t.Fatalf("expected an invalid status transition, got %v", err)
+					}
+					if transitionErr.From != from || transitionErr.To != to {
+						t.Errorf("expected the transition from %s to %s to be reported, got %s to %s", from, to, transitionErr.From, transitionErr.To)
+					}
+					if reloaded.Status != from {
+						t.Errorf("expected the transaction to stay %s, got %s", from, reloaded.Status)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("expected the transition to be allowed, got %v", err)
+				}
+				if result.Status != to || reloaded.Status != to {
+					t.Errorf("expected the transaction to be %s, got %s (stored %s)", to, result.Status, reloaded.Status)
+				}
+				if stamped(reloaded, to) == nil {
+					t.Errorf("expected the %s timestamp to be set", to)
+				}
+			})
+		}
+	}
+
+	if _, err := transactionService.UpdateTransactionStatus(ctx, dto.TransactionStatusUpdateRequest{Status: "shipped"}, fixture.purchase(t, db, constants.ENUM_TRANSACTION_STATUS_PENDING).ID.String()); !errors.Is(err, dto.ErrInvalidTransactionStatus) {
+		t.Errorf("expected an unknown status to be rejected, got %v", err)
+	}
+}
+
+func TestGetAllTransactions_FiltersByStatus(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	transactionService := newTransactionTestService(db)
+
+	paid := fixture.purchase(t, db, constants.ENUM_TRANSACTION_STATUS_PAID)
+	fixture.purchase(t, db, constants.ENUM_TRANSACTION_STATUS_PENDING)
+	fixture.purchase(t, db, constants.ENUM_TRANSACTION_STATUS_CANCELLED)
+
+	result, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{
+		Status:  constants.ENUM_TRANSACTION_STATUS_PAID,
+		BuyerID: fixture.buyer.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(result.Data) != 1 || result.Data[0].ID != paid.ID.String() {
+		t.Errorf("expected only the paid transaction, got %+v", result.Data)
+	}
+
+	all, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{BuyerID: fixture.buyer.ID.String()})
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(all.Data) != 3 {
+		t.Errorf("expected every transaction without a status filter, got %d", len(all.Data))
+	}
+
+	if _, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{Status: "shipped"}); !errors.Is(err, dto.ErrInvalidTransactionStatus) {
+		t.Errorf("expected an unknown status filter to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,422 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/controller"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+	"github.com/tapeds/go-fiber-template/repository"
+	"github.com/tapeds/go-fiber-template/service"
+	"github.com/tapeds/go-fiber-template/utils"
+)
+
+func TestCreateTransaction_ConcurrentPurchasesNeverOversell(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	const (
+		seats     = 50
+		purchases = 300
+	)
+
+	buyer := createTestUser(t, db, "concurrency buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "concurrency event", AuthorID: buyer.ID, Price: 1000, Capacity: seats, Availabilty: seats, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded int64
+		soldOut   int64
+		start     = make(chan struct{})
+		errs      = make(chan error, purchases)
+	)
+
+	for i := 0; i < purchases; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+				EventID: event.ID.String(),
+				BuyerID: buyer.ID.String(),
+				Amount:  1,
+			})
+			switch {
+			case err == nil:
+				atomic.AddInt64(&succeeded, 1)
+			case errors.Is(err, dto.ErrInsufficientAvailability):
+				atomic.AddInt64(&soldOut, 1)
+			default:
+				errs <- err
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected purchase error: %v", err)
+	}
+
+	if succeeded != seats {
+		t.Errorf("expected %d successful purchases, got %d", seats, succeeded)
+	}
+	if soldOut != purchases-seats {
+		t.Errorf("expected %d sold out rejections, got %d", purchases-seats, soldOut)
+	}
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.Availabilty != 0 {
+		t.Errorf("expected availability 0, got %d", reloaded.Availabilty)
+	}
+
+	var reloadedTier entity.TicketTier
+	if err := db.Take(&reloadedTier, "id = ?", tier.ID).Error; err != nil {
+		t.Fatalf("failed to reload tier: %v", err)
+	}
+	if reloadedTier.Availability != 0 {
+		t.Errorf("expected tier availability 0, got %d", reloadedTier.Availability)
+	}
+
+	var sold int64
+	if err := db.Model(&entity.Transaction{}).Where("event_id = ?", event.ID).Count(&sold).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+	if sold != seats {
+		t.Errorf("expected %d transactions, got %d", seats, sold)
+	}
+}
+
+func TestCreateTransaction_TierCannotSellBeyondItsCapacity(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	buyer := createTestUser(t, db, "tier buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "tier event", AuthorID: buyer.ID, Price: 1000, Capacity: 12, Availabilty: 12, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+
+	vip := entity.TicketTier{EventID: event.ID.String(), Name: "VIP", Price: 5000, Capacity: 2, Availability: 2}
+	regular := entity.TicketTier{EventID: event.ID.String(), Name: "Regular", Price: 1000, Capacity: 10, Availability: 10}
+	if err := db.Create(&[]*entity.TicketTier{&vip, &regular}).Error; err != nil {
+		t.Fatalf("failed to create tiers: %v", err)
+	}
+
+	transactionService := newTransactionTestService(db)
+
+	_, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  1,
+	})
+	if !errors.Is(err, dto.ErrTierRequired) {
+		t.Fatalf("expected tier to be required, got %v", err)
+	}
+
+	result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		TierID:  vip.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to buy vip tickets: %v", err)
+	}
+	if result.EventPrice != vip.Price {
+		t.Errorf("expected vip price %d, got %d", vip.Price, result.EventPrice)
+	}
+
+	_, err = transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: event.ID.String(),
+		TierID:  vip.ID.String(),
+		BuyerID: buyer.ID.String(),
+		Amount:  1,
+	})
+	if !errors.Is(err, dto.ErrInsufficientAvailability) {
+		t.Fatalf("expected vip tier to be sold out, got %v", err)
+	}
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.Availabilty != 10 {
+		t.Errorf("expected event availability 10, got %d", reloaded.Availabilty)
+	}
+}
+
+func TestCreateTransaction_ConcurrentPurchasesRespectUserLimit(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	const (
+		limit     = 3
+		purchases = 20
+	)
+
+	buyer := createTestUser(t, db, "limited buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "limited event", AuthorID: buyer.ID, Price: 1000, Capacity: 100, Availabilty: 100, MaxTicketsPerOrder: 2, MaxTicketsPerUser: limit, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	transactionService := newTransactionTestService(db)
+
+	var limitErr *dto.ErrPurchaseLimitExceeded
+	_, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{EventID: event.ID.String(), BuyerID: buyer.ID.String(), Amount: 3})
+	if !errors.As(err, &limitErr) || limitErr.Scope != constants.ENUM_PURCHASE_LIMIT_SCOPE_ORDER || limitErr.Remaining != 2 {
+		t.Fatalf("expected the per order limit with 2 remaining, got %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		succeeded int64
+		limited   int64
+		start     = make(chan struct{})
+		errs      = make(chan error, purchases)
+	)
+
+	for i := 0; i < purchases; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+				EventID: event.ID.String(),
+				BuyerID: buyer.ID.String(),
+				Amount:  1,
+			})
+
+			var limitErr *dto.ErrPurchaseLimitExceeded
+			switch {
+			case err == nil:
+				atomic.AddInt64(&succeeded, 1)
+			case errors.As(err, &limitErr):
+				atomic.AddInt64(&limited, 1)
+			default:
+				errs <- err
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected purchase error: %v", err)
+	}
+
+	if succeeded != limit {
+		t.Errorf("expected %d successful purchases, got %d", limit, succeeded)
+	}
+	if limited != purchases-limit {
+		t.Errorf("expected %d limit rejections, got %d", purchases-limit, limited)
+	}
+
+	var reloaded entity.Event
+	if err := db.Take(&reloaded, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if reloaded.Availabilty != 100-limit {
+		t.Errorf("expected rejected purchases to give their seats back, got availability %d", reloaded.Availabilty)
+	}
+}
+
+func TestGetVisibleTransactionById_HidesOtherBuyersPurchases(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	organizer := createTestUser(t, db, "organizer", constants.ENUM_ROLE_USER)
+	buyer := createTestUser(t, db, "buyer", constants.ENUM_ROLE_USER)
+	stranger := createTestUser(t, db, "stranger", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "visibility event", AuthorID: organizer.ID, Price: 1000, Capacity: 1, Availabilty: 0})
+	tier := createTestTier(t, db, event)
+
+	order := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transaction := entity.Transaction{OrderID: order.ID.String(), EventID: event.ID.String(), TierID: tier.ID.String(), BuyerID: buyer.ID.String(), Amount: 1, Status: constants.ENUM_TRANSACTION_STATUS_PAID}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	transactionService := newTransactionTestService(db)
+
+	for _, viewer := range []string{buyer.ID.String(), organizer.ID.String(), ""} {
+		if _, err := transactionService.GetVisibleTransactionById(ctx, transaction.ID.String(), viewer); err != nil {
+			t.Errorf("expected viewer %q to see the transaction, got %v", viewer, err)
+		}
+	}
+
+	if _, err := transactionService.GetVisibleTransactionById(ctx, transaction.ID.String(), stranger.ID.String()); !errors.Is(err, dto.ErrTransactionNotFound) {
+		t.Errorf("expected the transaction to be hidden from other users, got %v", err)
+	}
+
+	mine, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{BuyerID: stranger.ID.String()})
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(mine.Data) != 0 {
+		t.Errorf("expected no transactions for a user without purchases, got %d", len(mine.Data))
+	}
+
+	organized, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{AuthorID: organizer.ID.String()})
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(organized.Data) != 1 || organized.Data[0].ID != transaction.ID.String() {
+		t.Errorf("expected the organizer to see the purchase of their event, got %+v", organized.Data)
+	}
+}
+
+func TestGetTransactionById_AnswersWithTheWholeTransaction(t *testing.T) {
+	db := SetUpTestDatabase(t)
+
+	buyer := createTestUser(t, db, "by id buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "by id event", AuthorID: buyer.ID, Price: 1000, Capacity: 1, Availabilty: 0})
+
+	order := entity.Order{BuyerID: buyer.ID.String(), Status: constants.ENUM_TRANSACTION_STATUS_PENDING}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	transaction := entity.Transaction{
+		OrderID:          order.ID.String(),
+		EventID:          event.ID.String(),
+		BuyerID:          buyer.ID.String(),
+		Amount:           1,
+		Status:           constants.ENUM_TRANSACTION_STATUS_PENDING,
+		PaymentProvider:  constants.ENUM_PAYMENT_PROVIDER_MOCK,
+		PaymentReference: uuid.NewString(),
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	transactionController := controller.NewTransactionController(newTransactionTestService(db), service.NewUserService(repository.NewUserRepository(db), nil))
+
+	app := fiber.New()
+	app.Get("/transaction/by-id", func(ctx *fiber.Ctx) error {
+		ctx.Locals("user_id", buyer.ID.String())
+		return ctx.Next()
+	}, transactionController.GetTransactionById)
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/transaction/by-id?id="+transaction.ID.String(), nil), -1)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected the transaction to be found, got %d", res.StatusCode)
+	}
+
+	var body struct {
+		Data dto.TransactionResponse `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if body.Data.OrderID != order.ID.String() || body.Data.PaymentReference != transaction.PaymentReference || body.Data.EventName != event.Name {
+		t.Errorf("expected the order, payment and event of the transaction, got %+v", body.Data)
+	}
+}
+
+func TestTransaction_KeepsThePriceItWasSoldAt(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	fixture := createStatusFixture(t, db)
+	transactionService := newTransactionTestService(db)
+	reportService := service.NewReportService(repository.NewReportRepository(db))
+
+	eventService := newEventTestService(db)
+	tierService := service.NewTicketTierService(repository.NewTicketTierRepository(db), repository.NewEventRepository(db), newWaitlistTestService(db), db)
+
+	created, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+		EventID: fixture.event.ID.String(),
+		BuyerID: fixture.buyer.ID.String(),
+		Amount:  2,
+	})
+	if err != nil {
+		t.Fatalf("failed to create transaction: %v", err)
+	}
+
+	payload := []byte(`{"reference":"` + created.PaymentReference + `","status":"` + constants.ENUM_PAYMENT_STATUS_PAID + `"}`)
+	if err := transactionService.HandlePaymentWebhook(ctx, constants.ENUM_PAYMENT_PROVIDER_MOCK, payload, utils.SignHMAC(testPaymentSecret, payload)); err != nil {
+		t.Fatalf("failed to pay: %v", err)
+	}
+
+	report := func() dto.SalesReportRow {
+		t.Helper()
+
+		result, err := reportService.GetSalesReport(ctx, dto.SalesReportRequest{
+			GroupBy: constants.ENUM_REPORT_GROUP_TIER,
+			EventID: fixture.event.ID.String(),
+		})
+		if err != nil {
+			t.Fatalf("failed to get report: %v", err)
+		}
+		if len(result.Rows) != 1 {
+			t.Fatalf("expected one tier in the report, got %+v", result.Rows)
+		}
+		return result.Rows[0]
+	}
+
+	before, err := transactionService.GetTransactionById(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if before.UnitPrice != 1000 || before.EventPrice != 1000 || before.Total != 2000 {
+		t.Fatalf("expected the purchase at 1000 a ticket, got %+v", before)
+	}
+	reported := report()
+
+	price := 2500
+	if _, err := eventService.UpdateEvent(ctx, dto.EventUpdateRequest{Name: fixture.event.Name, Price: &price}, fixture.event.ID.String()); err != nil {
+		t.Fatalf("failed to change the event price: %v", err)
+	}
+
+	price = 4000
+	if _, err := tierService.UpdateTier(ctx, fixture.event.ID.String(), fixture.tier.ID.String(), dto.TicketTierUpdateRequest{Price: &price}); err != nil {
+		t.Fatalf("failed to change the tier price: %v", err)
+	}
+
+	after, err := transactionService.GetTransactionById(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if after != before {
+		t.Errorf("expected the transaction to keep its prices, got %+v instead of %+v", after, before)
+	}
+
+	listed, err := transactionService.GetAllTransactionsWithPagination(ctx, dto.TransactionPaginationRequest{BuyerID: fixture.buyer.ID.String()})
+	if err != nil {
+		t.Fatalf("failed to list transactions: %v", err)
+	}
+	if len(listed.Data) != 1 || listed.Data[0].UnitPrice != 1000 || listed.Data[0].EventPrice != 1000 || listed.Data[0].Total != 2000 {
+		t.Errorf("expected the listing to keep the prices, got %+v", listed.Data)
+	}
+
+	if row := report(); row != reported {
+		t.Errorf("expected the report to keep its totals, got %+v instead of %+v", row, reported)
+	}
+}
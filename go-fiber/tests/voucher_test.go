@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+)
+
+func TestCreateTransaction_ConcurrentRedemptionsRespectVoucherLimit(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	const (
+		limit     = 5
+		purchases = 50
+	)
+
+	buyer := createTestUser(t, db, "voucher buyer", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "voucher event", AuthorID: buyer.ID, Price: 1000, Capacity: purchases, Availabilty: purchases, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	createTestTier(t, db, event)
+
+	voucher := entity.Voucher{
+		Code:           "RACE" + strings.ToUpper(uuid.NewString()[:8]),
+		DiscountType:   constants.ENUM_VOUCHER_TYPE_PERCENTAGE,
+		DiscountValue:  25,
+		MaxRedemptions: limit,
+	}
+	if err := db.Create(&voucher).Error; err != nil {
+		t.Fatalf("failed to create voucher: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Unscoped().Where("voucher_id = ?", voucher.ID).Delete(&entity.VoucherRedemption{})
+		db.Unscoped().Delete(&voucher)
+	})
+
+	transactionService := newTransactionTestService(db)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded int64
+		exhausted int64
+		start     = make(chan struct{})
+		errs      = make(chan error, purchases)
+	)
+
+	for i := 0; i < purchases; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			result, err := transactionService.CreateTransaction(ctx, dto.TransactionCreateRequest{
+				EventID:     event.ID.String(),
+				BuyerID:     buyer.ID.String(),
+				Amount:      1,
+				VoucherCode: voucher.Code,
+			})
+			switch {
+			case err == nil:
+				atomic.AddInt64(&succeeded, 1)
+				if result.Total != 750 {
+					errs <- errors.New("discounted total was not applied")
+				}
+			case errors.Is(err, dto.ErrVoucherExhausted):
+				atomic.AddInt64(&exhausted, 1)
+			default:
+				errs <- err
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected purchase error: %v", err)
+	}
+
+	if succeeded != limit {
+		t.Errorf("expected %d discounted purchases, got %d", limit, succeeded)
+	}
+	if exhausted != purchases-limit {
+		t.Errorf("expected %d exhausted rejections, got %d", purchases-limit, exhausted)
+	}
+
+	var reloaded entity.Voucher
+	if err := db.Take(&reloaded, "id = ?", voucher.ID).Error; err != nil {
+		t.Fatalf("failed to reload voucher: %v", err)
+	}
+	if reloaded.Redemptions != limit {
+		t.Errorf("expected %d redemptions, got %d", limit, reloaded.Redemptions)
+	}
+
+	var seats entity.Event
+	if err := db.Take(&seats, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("failed to reload event: %v", err)
+	}
+	if seats.Availabilty != purchases-limit {
+		t.Errorf("expected rejected purchases to give their seats back, availability is %d", seats.Availabilty)
+	}
+}
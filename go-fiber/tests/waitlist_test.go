@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tapeds/go-fiber-template/constants"
+	"github.com/tapeds/go-fiber-template/dto"
+	"github.com/tapeds/go-fiber-template/entity"
+)
+
+func TestOfferSeats_ServesWaitlistInOrder(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	first := createTestUser(t, db, "first in line", constants.ENUM_ROLE_USER)
+	second := createTestUser(t, db, "second in line", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "sold out event", AuthorID: first.ID, Price: 1000, Capacity: 2, Availabilty: 0, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	waitlistService := newWaitlistTestService(db)
+	transactionService := newTransactionTestService(db)
+
+	firstEntry, err := waitlistService.JoinWaitlist(ctx, dto.WaitlistJoinRequest{EventID: event.ID.String(), UserID: first.ID.String(), Quantity: 2})
+	if err != nil {
+		t.Fatalf("failed to join waitlist: %v", err)
+	}
+
+	secondEntry, err := waitlistService.JoinWaitlist(ctx, dto.WaitlistJoinRequest{EventID: event.ID.String(), UserID: second.ID.String(), Quantity: 1})
+	if err != nil {
+		t.Fatalf("failed to join waitlist: %v", err)
+	}
+	if secondEntry.Position != 2 {
+		t.Errorf("expected second entry at position 2, got %d", secondEntry.Position)
+	}
+
+	// Two seats come back, which is exactly what the first entry waits for.
+	db.Model(&event).Update("availabilty", 2)
+	db.Model(&tier).Update("availability", 2)
+
+	if err := waitlistService.OfferSeats(ctx, event.ID.String()); err != nil {
+		t.Fatalf("failed to offer seats: %v", err)
+	}
+
+	var offered, waiting entity.WaitlistEntry
+	db.Take(&offered, "id = ?", firstEntry.ID)
+	db.Take(&waiting, "id = ?", secondEntry.ID)
+	if offered.Status != constants.ENUM_WAITLIST_STATUS_OFFERED {
+		t.Errorf("expected first entry to be offered, got %s", offered.Status)
+	}
+	if waiting.Status != constants.ENUM_WAITLIST_STATUS_WAITING {
+		t.Errorf("expected second entry to keep waiting, got %s", waiting.Status)
+	}
+
+	var reloaded entity.TicketTier
+	db.Take(&reloaded, "id = ?", tier.ID)
+	if reloaded.Availability != 0 {
+		t.Errorf("expected offered seats off sale, got availability %d", reloaded.Availability)
+	}
+
+	if _, err := transactionService.ClaimWaitlistOffer(ctx, firstEntry.ID, second.ID.String()); err == nil {
+		t.Errorf("expected another user's claim to be rejected")
+	}
+
+	order, err := transactionService.ClaimWaitlistOffer(ctx, firstEntry.ID, first.ID.String())
+	if err != nil {
+		t.Fatalf("failed to claim offer: %v", err)
+	}
+	if len(order.Items) != 1 || order.Items[0].Amount != 2 {
+		t.Errorf("expected one item of 2 tickets, got %+v", order.Items)
+	}
+
+	db.Take(&reloaded, "id = ?", tier.ID)
+	if reloaded.Availability != 0 {
+		t.Errorf("expected claimed seats to stay off sale, got availability %d", reloaded.Availability)
+	}
+}
+
+func TestCancelEvent_ClosesWaitlist(t *testing.T) {
+	db := SetUpTestDatabase(t)
+	ctx := context.Background()
+
+	first := createTestUser(t, db, "offered in line", constants.ENUM_ROLE_USER)
+	second := createTestUser(t, db, "waiting in line", constants.ENUM_ROLE_USER)
+	event := createTestEvent(t, db, entity.Event{Name: "cancelled waitlist event", AuthorID: first.ID, Price: 1000, Capacity: 2, Availabilty: 0, Status: constants.ENUM_EVENT_STATUS_PUBLISHED})
+	tier := createTestTier(t, db, event)
+
+	waitlistService := newWaitlistTestService(db)
+	transactionService := newTransactionTestService(db)
+
+	firstEntry, err := waitlistService.JoinWaitlist(ctx, dto.WaitlistJoinRequest{EventID: event.ID.String(), UserID: first.ID.String(), Quantity: 2})
+	if err != nil {
+		t.Fatalf("failed to join waitlist: %v", err)
+	}
+
+	secondEntry, err := waitlistService.JoinWaitlist(ctx, dto.WaitlistJoinRequest{EventID: event.ID.String(), UserID: second.ID.String(), Quantity: 1})
+	if err != nil {
+		t.Fatalf("failed to join waitlist: %v", err)
+	}
+
+	entryStatus := func(entryId string) string {
+		var entry entity.WaitlistEntry
+		if err := db.Take(&entry, "id = ?", entryId).Error; err != nil {
+			t.Fatalf("failed to reload entry: %v", err)
+		}
+		return entry.Status
+	}
+
+	// Seats freed while the event is postponed are not offered.
+	db.Model(&event).Updates(map[string]any{"availabilty": 2, "status": constants.ENUM_EVENT_STATUS_POSTPONED})
+	db.Model(&tier).Update("availability", 2)
+
+	if err := waitlistService.OfferSeats(ctx, event.ID.String()); err != nil {
+		t.Fatalf("failed to offer seats: %v", err)
+	}
+	if status := entryStatus(firstEntry.ID); status != constants.ENUM_WAITLIST_STATUS_WAITING {
+		t.Fatalf("expected no offer while the event is postponed, got %s", status)
+	}
+
+	db.Model(&event).Update("status", constants.ENUM_EVENT_STATUS_PUBLISHED)
+	if err := waitlistService.OfferSeats(ctx, event.ID.String()); err != nil {
+		t.Fatalf("failed to offer seats: %v", err)
+	}
+	if status := entryStatus(firstEntry.ID); status != constants.ENUM_WAITLIST_STATUS_OFFERED {
+		t.Fatalf("expected first entry to be offered, got %s", status)
+	}
+
+	if _, err := transactionService.CancelEvent(ctx, dto.EventCancelRequest{Reason: "venue closed"}, event.ID.String()); err != nil {
+		t.Fatalf("failed to cancel event: %v", err)
+	}
+
+	for _, entryId := range []string{firstEntry.ID, secondEntry.ID} {
+		if status := entryStatus(entryId); status != constants.ENUM_WAITLIST_STATUS_CANCELLED {
+			t.Errorf("expected entry %s to be cancelled, got %s", entryId, status)
+		}
+	}
+
+	var reloaded entity.TicketTier
+	db.Take(&reloaded, "id = ?", tier.ID)
+	if reloaded.Availability != 2 {
+		t.Errorf("expected the seats of the open offer back, got availability %d", reloaded.Availability)
+	}
+
+	if _, err := transactionService.ClaimWaitlistOffer(ctx, firstEntry.ID, first.ID.String()); err == nil {
+		t.Errorf("expected the offer of a cancelled event not to be claimable")
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor turns the position of a row in a (created_at, id) ordering
+// into an opaque token. The token is signed with key, so a client can hand
+// it back but not forge one pointing elsewhere.
+func EncodeCursor(key string, createdAt time.Time, id string) string {
+	payload := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + SignHMAC(key, []byte(payload))
+}
+
+// DecodeCursor verifies a token made by EncodeCursor and returns the
+// position it holds.
+func DecodeCursor(key string, token string) (time.Time, string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !VerifyHMAC(key, payload, signature) {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	rawTime, id, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, rawTime)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return createdAt, id, nil
+}
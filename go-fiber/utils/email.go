@@ -5,7 +5,8 @@ import (
 	"gopkg.in/gomail.v2"
 )
 
-func SendMail(toEmail string, subject string, body string) error {
+// SendMail sends an html email. Attachments are paths of files on disk.
+func SendMail(toEmail string, subject string, body string, attachments ...string) error {
 	emailConfig, err := config.NewEmailConfig()
 	if err != nil {
 		return err
@@ -16,6 +17,9 @@ func SendMail(toEmail string, subject string, body string) error {
 	mailer.SetHeader("To", toEmail)
 	mailer.SetHeader("Subject", subject)
 	mailer.SetBody("text/html", body)
+	for _, attachment := range attachments {
+		mailer.Attach(attachment)
+	}
 
 	dialer := gomail.NewDialer(
 		emailConfig.Host,
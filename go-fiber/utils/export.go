@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	EXPORT_FORMAT_CSV  = "csv"
+	EXPORT_FORMAT_XLSX = "xlsx"
+
+	EXPORT_SHEET = "Sheet1"
+
+	// EXPORT_FORMULA_PREFIXES start the cells spreadsheet apps run as
+	// formulas.
+	EXPORT_FORMULA_PREFIXES = "=+-@\t\r"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// TableWriter writes an export one row at a time, so callers can stream rows
+// from the database instead of collecting them first. Cells are escaped with
+// EscapeCell. Close must be called once every row was written.
+type TableWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+// NewTableWriter returns a writer for format that writes to w.
+func NewTableWriter(w io.Writer, format string) (TableWriter, error) {
+	switch format {
+	case EXPORT_FORMAT_CSV:
+		return &csvTableWriter{writer: csv.NewWriter(w)}, nil
+	case EXPORT_FORMAT_XLSX:
+		file := excelize.NewFile()
+		stream, err := file.NewStreamWriter(EXPORT_SHEET)
+		if err != nil {
+			return nil, err
+		}
+
+		return &xlsxTableWriter{out: w, file: file, stream: stream, row: 1}, nil
+	}
+
+	return nil, ErrUnknownExportFormat
+}
+
+// ExportContentType returns the content type and file extension of format.
+func ExportContentType(format string) (string, string) {
+	if format == EXPORT_FORMAT_XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"
+	}
+
+	return "text/csv; charset=utf-8", ".csv"
+}
+
+// EscapeCell keeps a cell that would run as a formula, for example a user
+// name starting with "=", as plain text by prefixing it with a quote.
+func EscapeCell(value string) string {
+	if value != "" && strings.ContainsRune(EXPORT_FORMULA_PREFIXES, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+type csvTableWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvTableWriter) WriteRow(row []string) error {
+	escaped := make([]string, len(row))
+	for i, value := range row {
+		escaped[i] = EscapeCell(value)
+	}
+
+	return w.writer.Write(escaped)
+}
+
+func (w *csvTableWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// xlsxTableWriter uses the excelize stream writer, which keeps rows in a
+// temporary file rather than in memory until the workbook is written out.
+type xlsxTableWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (w *xlsxTableWriter) WriteRow(row []string) error {
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]any, len(row))
+	for i, value := range row {
+		values[i] = EscapeCell(value)
+	}
+
+	w.row++
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxTableWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+
+	return w.file.Write(w.out)
+}
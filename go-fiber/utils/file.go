@@ -10,6 +10,10 @@ import (
 
 const PATH = "assets"
 
+// PRIVATE_PATH holds generated files that are not served as static assets
+// and are only handed out by the handlers that check who asks for them.
+const PRIVATE_PATH = "storage"
+
 func UploadFile(file *multipart.FileHeader, path string) error {
 	parts := strings.Split(path, "/")
 	fileID := parts[1]
@@ -45,6 +49,33 @@ func UploadFile(file *multipart.FileHeader, path string) error {
 	return nil
 }
 
+// SavePrivateFile writes generated content to the same "dir/file" layout
+// UploadFile uses, but under PRIVATE_PATH, and returns the full path of the
+// file.
+func SavePrivateFile(data []byte, path string) (string, error) {
+	parts := strings.Split(path, "/")
+	fileID := parts[1]
+	dirPath := fmt.Sprintf("%s/%s", PRIVATE_PATH, parts[0])
+
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(dirPath, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	filePath := fmt.Sprintf("%s/%s", dirPath, fileID)
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// IsPrivateFile reports whether path was saved by SavePrivateFile.
+func IsPrivateFile(path string) bool {
+	return strings.HasPrefix(path, PRIVATE_PATH+"/")
+}
+
 func GetExtensions(filename string) string {
 	return strings.Split(filename, ".")[1]
 }